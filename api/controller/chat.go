@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/utils"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Time allowed to write a frame to the peer
+	chatWriteWait = 10 * time.Second
+	// Time allowed to read the next pong from the peer
+	chatPongWait = 60 * time.Second
+	// Send pings with this period, must be less than chatPongWait
+	chatPingPeriod = (chatPongWait * 9) / 10
+	// Maximum inbound frame size
+	chatMaxFrameSize = 4096
+)
+
+var chatUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Connections are authenticated with a bearer token or a stream ticket, not cookies,
+	// so cross-origin clients are allowed
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+type ChatController struct {
+	ChatUseCase domain.ChatUseCase
+	Env         *bootstrap.Env
+}
+
+// JoinRoom godoc
+// @Summary Join a ticker chat room
+// @Description Upgrades to a WebSocket connected to the room of the given ticker. Clients send domain.SendChatMessageRequest frames and receive domain.ChatMessageResponse frames. Browsers that cannot set the Authorization header may pass a ticket from POST /stream-ticket in the ticket query parameter instead.
+// @Tags Chat
+// @Security BearerAuth
+// @Param ticker path string true "Ticker symbol"
+// @Param ticket query string false "Stream ticket (WebSocket clients only)"
+// @Success 101 "Switching protocols"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /chat/{ticker} [get]
+func (cc *ChatController) JoinRoom(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+	ticker := mux.Vars(r)["ticker"]
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// Subscribe before upgrading so a bad ticker is still a plain HTTP error
+	messages, err := cc.ChatUseCase.Subscribe(ctx, ticker)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	conn, err := chatUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied to the client
+		log.Error(err)
+		return
+	}
+	defer conn.Close()
+
+	conn.SetReadLimit(chatMaxFrameSize)
+	conn.SetReadDeadline(time.Now().Add(chatPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(chatPongWait))
+	})
+
+	// All writes happen in writeChatPump, the reader only hands it errors
+	errorsCh := make(chan domain.ErrorResponse, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		writeChatPump(ctx, conn, messages, errorsCh)
+		// Unblock the reader if the pump stopped first
+		conn.Close()
+	}()
+
+	for {
+		var request domain.SendChatMessageRequest
+		if err := conn.ReadJSON(&request); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Warnf("Chat connection for user %d closed: %v", userId, err)
+			}
+			break
+		}
+
+		if _, err := cc.ChatUseCase.SendMessage(ctx, userId, ticker, &request); err != nil {
+			log.Error(err)
+			select {
+			case errorsCh <- domain.ErrorResponse{Message: err.Error()}:
+			default:
+			}
+		}
+	}
+
+	cancel()
+	<-done
+}
+
+func writeChatPump(ctx context.Context, conn *websocket.Conn, messages <-chan *domain.ChatMessageResponse, errorsCh <-chan domain.ErrorResponse) {
+	ticker := time.NewTicker(chatPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			conn.SetWriteDeadline(time.Now().Add(chatWriteWait))
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(chatWriteWait))
+			if err := conn.WriteJSON(message); err != nil {
+				return
+			}
+		case errResponse := <-errorsCh:
+			conn.SetWriteDeadline(time.Now().Add(chatWriteWait))
+			if err := conn.WriteJSON(errResponse); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(chatWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// GetChatHistory godoc
+// @Summary Get chat room history
+// @Description Get messages of a ticker chat room, newest first, paginated
+// @Tags Chat
+// @Produce json
+// @Security BearerAuth
+// @Param ticker path string true "Ticker symbol"
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} domain.PaginatedResponse "Paginated chat messages"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /chat/{ticker}/messages [get]
+func (cc *ChatController) GetChatHistory(w http.ResponseWriter, r *http.Request) {
+	ticker := mux.Vars(r)["ticker"]
+
+	limit, offset := getPaginationParams(r)
+
+	history, err := cc.ChatUseCase.GetHistory(r.Context(), ticker, limit, offset)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, history)
+}
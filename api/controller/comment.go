@@ -20,13 +20,14 @@ type CommentController struct {
 
 // GetComments godoc
 // @Summary Get comments for a post
-// @Description Get all comments for a post, paginated
+// @Description Get the top-level comments of a post, newest first. Each lists its reply count and first 3 replies, deleted comments that still have replies show as "[deleted]".
 // @Tags Comments
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Post ID"
 // @Param limit query int false "Limit" default(10)
 // @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Cursor from next_cursor of the previous page, takes precedence over offset"
 // @Success 200 {object} domain.PaginatedResponse "Paginated comments"
 // @Failure 400 {object} domain.ErrorResponse "Bad request"
 // @Failure 401 {object} domain.ErrorResponse "Unauthorized"
@@ -40,9 +41,13 @@ func (cc *CommentController) GetComments(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	limit, offset := getPaginationParams(r)
+	page, err := getPageParams(r)
+	if err != nil {
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
 
-	comments, err := cc.CommentUseCase.GetComments(r.Context(), postId, limit, offset)
+	comments, err := cc.CommentUseCase.GetComments(r.Context(), postId, page)
 	if err != nil {
 		log.Error(err)
 		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
@@ -52,9 +57,48 @@ func (cc *CommentController) GetComments(w http.ResponseWriter, r *http.Request)
 	utils.JSON(w, http.StatusOK, comments)
 }
 
+// GetReplies godoc
+// @Summary Get replies to a comment
+// @Description Get the direct replies to a comment, oldest first, paginated
+// @Tags Comments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Comment ID"
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Cursor from next_cursor of the previous page, takes precedence over offset"
+// @Success 200 {object} domain.PaginatedResponse "Paginated replies"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /comments/{id}/replies [get]
+func (cc *CommentController) GetReplies(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	commentId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: "invalid comment id"})
+		return
+	}
+
+	page, err := getPageParams(r)
+	if err != nil {
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	replies, err := cc.CommentUseCase.GetReplies(r.Context(), commentId, page)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, replies)
+}
+
 // CreateComment godoc
 // @Summary Add a comment to a post
-// @Description Create a new comment on a post
+// @Description Create a new comment on a post, or a reply to one of its comments with parent_comment_id. Replies nest at most 4 levels deep.
 // @Tags Comments
 // @Accept json
 // @Produce json
@@ -93,9 +137,80 @@ func (cc *CommentController) CreateComment(w http.ResponseWriter, r *http.Reques
 	utils.JSON(w, http.StatusCreated, comment)
 }
 
+// UpdateComment godoc
+// @Summary Edit a comment
+// @Description Edit a comment (only owner can edit). The version it replaces is kept in the comment's history and the comment shows edited_at.
+// @Tags Comments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Comment ID"
+// @Param request body domain.UpdateCommentRequest true "Edited comment"
+// @Success 200 {object} domain.CommentResponse "Edited comment"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /comments/{id} [put]
+func (cc *CommentController) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	vars := mux.Vars(r)
+	commentId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: "invalid comment id"})
+		return
+	}
+
+	var request domain.UpdateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	comment, err := cc.CommentUseCase.UpdateComment(r.Context(), userId, commentId, &request)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, comment)
+}
+
+// GetCommentRevisions godoc
+// @Summary Get the history of a comment
+// @Description Get the earlier versions of an edited comment, oldest first. Each lists when it was written and when it was edited away.
+// @Tags Comments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Comment ID"
+// @Success 200 {array} domain.CommentRevision "Earlier versions"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /comments/{id}/revisions [get]
+func (cc *CommentController) GetCommentRevisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	commentId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: "invalid comment id"})
+		return
+	}
+
+	revisions, err := cc.CommentUseCase.GetCommentRevisions(r.Context(), commentId)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, revisions)
+}
+
 // DeleteComment godoc
 // @Summary Delete a comment
-// @Description Delete a comment (only owner can delete)
+// @Description Delete a comment (only owner can delete). A comment with replies is kept as a "[deleted]" placeholder.
 // @Tags Comments
 // @Produce json
 // @Security BearerAuth
@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/utils"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+type ConversationController struct {
+	ConversationUseCase domain.ConversationUseCase
+	Env                 *bootstrap.Env
+}
+
+// StartConversation godoc
+// @Summary Start a conversation
+// @Description Start a private conversation with one user, or a small group with several. Starting a one-to-one conversation that already exists returns it.
+// @Tags Conversations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body domain.StartConversationRequest true "Users to talk to"
+// @Success 201 {object} domain.ConversationResponse "Conversation"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /conversations [post]
+func (cc *ConversationController) StartConversation(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	var request domain.StartConversationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	conversation, err := cc.ConversationUseCase.StartConversation(r.Context(), userId, &request)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, conversation)
+}
+
+// GetInbox godoc
+// @Summary Get conversation inbox
+// @Description Get the current user's conversations, most recent activity first, with unread counts
+// @Tags Conversations
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} domain.PaginatedResponse "Paginated conversations"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /conversations [get]
+func (cc *ConversationController) GetInbox(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	limit, offset := getPaginationParams(r)
+
+	inbox, err := cc.ConversationUseCase.GetInbox(r.Context(), userId, limit, offset)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, inbox)
+}
+
+// GetMessages godoc
+// @Summary Get conversation messages
+// @Description Get the messages of a conversation, newest first, paginated
+// @Tags Conversations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Conversation ID"
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} domain.PaginatedResponse "Paginated messages"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /conversations/{id}/messages [get]
+func (cc *ConversationController) GetMessages(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	vars := mux.Vars(r)
+	conversationId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: "invalid conversation id"})
+		return
+	}
+
+	limit, offset := getPaginationParams(r)
+
+	messages, err := cc.ConversationUseCase.GetMessages(r.Context(), userId, conversationId, limit, offset)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, messages)
+}
+
+// SendMessage godoc
+// @Summary Send a message
+// @Description Send a message to a conversation the current user is a member of
+// @Tags Conversations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Conversation ID"
+// @Param request body domain.SendMessageRequest true "Message"
+// @Success 201 {object} domain.MessageResponse "Created message"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /conversations/{id}/messages [post]
+func (cc *ConversationController) SendMessage(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	vars := mux.Vars(r)
+	conversationId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: "invalid conversation id"})
+		return
+	}
+
+	var request domain.SendMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	message, err := cc.ConversationUseCase.SendMessage(r.Context(), userId, conversationId, &request)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, message)
+}
+
+// MarkAsRead godoc
+// @Summary Mark messages as read
+// @Description Mark messages of a conversation as read up to message_id, or all of them when it is omitted
+// @Tags Conversations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Conversation ID"
+// @Param request body domain.MarkReadRequest false "Last read message"
+// @Success 200 {string} string "Success"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /conversations/{id}/read [post]
+func (cc *ConversationController) MarkAsRead(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	vars := mux.Vars(r)
+	conversationId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: "invalid conversation id"})
+		return
+	}
+
+	// The body is optional, an empty one marks everything read
+	var request domain.MarkReadRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Debug("No message id provided in mark read request")
+	}
+
+	err = cc.ConversationUseCase.MarkAsRead(r.Context(), userId, conversationId, &request)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, "Success")
+}
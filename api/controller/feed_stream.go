@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Comment line sent on idle streams so proxies keep the connection open
+const feedStreamHeartbeat = 25 * time.Second
+
+type FeedStreamController struct {
+	FeedStreamUseCase domain.FeedStreamUseCase
+	Env               *bootstrap.Env
+}
+
+// StreamFeed godoc
+// @Summary Stream live feed updates
+// @Description Server-Sent Events stream of new posts, deleted posts, like count changes and new comments. Each event is named after its type and carries a domain.FeedEvent. EventSource clients that cannot set the Authorization header may pass a ticket from POST /stream-ticket in the ticket query parameter instead.
+// @Tags Posts
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param feed query string false "Feed to follow: global, following or ticker" default(global)
+// @Param ticker query string false "Ticker symbol, required for the ticker feed"
+// @Param ticket query string false "Stream ticket (EventSource clients only)"
+// @Success 200 {object} domain.FeedEvent "Event stream"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /feed/stream [get]
+func (fc *FeedStreamController) StreamFeed(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	filter := domain.FeedFilter{
+		Feed:   r.URL.Query().Get("feed"),
+		Ticker: r.URL.Query().Get("ticker"),
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	events, err := fc.FeedStreamUseCase.Subscribe(ctx, userId, filter)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	// The server-wide write timeout would cut the stream after a few seconds
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusInternalServerError, domain.ErrorResponse{Message: "streaming unsupported"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Disable response buffering in nginx
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		log.Error(err)
+		return
+	}
+
+	heartbeat := time.NewTicker(feedStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			payload, err := json.Marshal(event)
+			if err != nil {
+				log.Error(err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, payload); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
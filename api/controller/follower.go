@@ -90,6 +90,7 @@ func (fc *FollowerController) Unfollow(w http.ResponseWriter, r *http.Request) {
 // @Param id path int true "User ID"
 // @Param limit query int false "Limit" default(10)
 // @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Cursor from next_cursor of the previous page, takes precedence over offset"
 // @Success 200 {object} domain.PaginatedResponse "Paginated followers"
 // @Failure 400 {object} domain.ErrorResponse "Bad request"
 // @Failure 401 {object} domain.ErrorResponse "Unauthorized"
@@ -103,9 +104,13 @@ func (fc *FollowerController) GetFollowers(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	limit, offset := getPaginationParams(r)
+	page, err := getPageParams(r)
+	if err != nil {
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
 
-	followers, err := fc.FollowerUseCase.GetFollowers(r.Context(), userId, limit, offset)
+	followers, err := fc.FollowerUseCase.GetFollowers(r.Context(), userId, page)
 	if err != nil {
 		log.Error(err)
 		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
@@ -124,6 +129,7 @@ func (fc *FollowerController) GetFollowers(w http.ResponseWriter, r *http.Reques
 // @Param id path int true "User ID"
 // @Param limit query int false "Limit" default(10)
 // @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Cursor from next_cursor of the previous page, takes precedence over offset"
 // @Success 200 {object} domain.PaginatedResponse "Paginated following"
 // @Failure 400 {object} domain.ErrorResponse "Bad request"
 // @Failure 401 {object} domain.ErrorResponse "Unauthorized"
@@ -137,9 +143,13 @@ func (fc *FollowerController) GetFollowing(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	limit, offset := getPaginationParams(r)
+	page, err := getPageParams(r)
+	if err != nil {
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
 
-	following, err := fc.FollowerUseCase.GetFollowing(r.Context(), userId, limit, offset)
+	following, err := fc.FollowerUseCase.GetFollowing(r.Context(), userId, page)
 	if err != nil {
 		log.Error(err)
 		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
@@ -48,7 +48,7 @@ func (gc *GoogleController) HandleGoogleLogin(w http.ResponseWriter, r *http.Req
 // @Tags Authentication
 // @Param code query string true "Authorization code from Google"
 // @Param state query string true "State parameter for CSRF protection"
-// @Success 307 "Redirect to profile page with auth cookies"
+// @Success 307 "Redirect to profile page with auth cookies, or to the two-factor page with a challenge_token cookie"
 // @Failure 307 "Redirect to home on error"
 // @Router /google/callback [get]
 func (gc *GoogleController) HandleGoogleCallback(w http.ResponseWriter, r *http.Request) {
@@ -70,16 +70,23 @@ func (gc *GoogleController) HandleGoogleCallback(w http.ResponseWriter, r *http.
 		return
 	}
 
-	accessToken, refreshToken, err := gc.GoogleUseCase.GoogleLogin(ctx, data, gc.Env)
+	response, err := gc.GoogleUseCase.GoogleLogin(ctx, data, sessionClient(r), gc.Env)
 	if err != nil {
 		log.Error(err)
 		http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
 		return
 	}
 
+	// ask for the second factor, the page posts the challenge token with a code to /login/2fa
+	if response.TwoFactorRequired {
+		utils.SetCookie(w, "challenge_token", response.ChallengeToken)
+		http.Redirect(w, r, "/login/2fa", http.StatusTemporaryRedirect)
+		return
+	}
+
 	// write access token and refresh token to cookie
-	utils.SetCookie(w, "access_token", accessToken)
-	utils.SetCookie(w, "refresh_token", refreshToken)
+	utils.SetCookie(w, "access_token", response.AccessToken)
+	utils.SetCookie(w, "refresh_token", response.RefreshToken)
 
 	// redirect to home page
 	http.Redirect(w, r, "/profile", http.StatusTemporaryRedirect)
@@ -2,7 +2,9 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/Pro100-Almaz/trading-chat/bootstrap"
 	"github.com/Pro100-Almaz/trading-chat/domain"
@@ -18,13 +20,14 @@ type LoginController struct {
 
 // Login godoc
 // @Summary Login user
-// @Description Authenticate user with email and password
+// @Description Authenticate user with email and password. With two-factor authentication on, the response carries a challenge token to redeem at /login/2fa along with a code instead of the tokens.
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Param request body domain.LoginRequest true "Login credentials"
 // @Success 200 {object} domain.LoginResponse "Successfully logged in"
 // @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 429 {object} domain.ErrorResponse "Too many failed attempts, retry after the Retry-After header"
 // @Router /login [post]
 func (lc *LoginController) Login(w http.ResponseWriter, r *http.Request) {
 	var request domain.LoginRequest
@@ -36,17 +39,27 @@ func (lc *LoginController) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accessToken, refreshToken, err := lc.LoginUseCase.Login(ctx, request, lc.Env)
+	response, err := lc.LoginUseCase.Login(ctx, request, sessionClient(r), lc.Env)
 	if err != nil {
 		log.Error(err)
+		if tooManyAttempts(w, err) {
+			return
+		}
 		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
 		return
 	}
 
-	response := domain.LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+	utils.JSON(w, http.StatusOK, response)
+}
+
+// tooManyAttempts answers with 429 when err says to wait before trying again
+func tooManyAttempts(w http.ResponseWriter, err error) bool {
+	var tooMany *domain.TooManyAttemptsError
+	if !errors.As(err, &tooMany) {
+		return false
 	}
 
-	utils.JSON(w, http.StatusOK, response)
+	w.Header().Set("Retry-After", strconv.Itoa(tooMany.RetryAfterSeconds()))
+	utils.JSON(w, http.StatusTooManyRequests, domain.ErrorResponse{Message: err.Error()})
+	return true
 }
@@ -18,12 +18,12 @@ type LogoutController struct {
 
 // Logout godoc
 // @Summary Logout user
-// @Description Logout the currently authenticated user by blacklisting their tokens
+// @Description Logout the currently authenticated user by ending the session of their access token, and that of their refresh token if it differs
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param request body domain.LogoutRequest false "Optional refresh token to also blacklist"
+// @Param request body domain.LogoutRequest false "Optional refresh token whose session to also end"
 // @Success 200 {object} domain.LogoutResponse "Successfully logged out"
 // @Failure 400 {object} domain.ErrorResponse "Bad request"
 // @Failure 401 {object} domain.ErrorResponse "Unauthorized"
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/utils"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+type NotificationController struct {
+	NotificationUseCase domain.NotificationUseCase
+	Env                 *bootstrap.Env
+}
+
+// GetNotifications godoc
+// @Summary Get notifications
+// @Description Get the current user's notifications, newest first. Follows, likes, comments and mentions of one post are grouped, listing the latest actors and how many there are.
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Cursor from next_cursor of the previous page, takes precedence over offset"
+// @Success 200 {object} domain.NotificationsResponse "Paginated notification groups with the unread count"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /notifications [get]
+func (nc *NotificationController) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	page, err := getPageParams(r)
+	if err != nil {
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	notifications, err := nc.NotificationUseCase.GetNotifications(r.Context(), userId, page)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, notifications)
+}
+
+// GetUnreadCount godoc
+// @Summary Get unread notifications count
+// @Description Count the current user's notification groups with something unread
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} domain.UnreadCountResponse "Unread count"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /notifications/unread-count [get]
+func (nc *NotificationController) GetUnreadCount(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	count, err := nc.NotificationUseCase.GetUnreadCount(r.Context(), userId)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, domain.UnreadCountResponse{UnreadCount: count})
+}
+
+// MarkRead godoc
+// @Summary Mark a notification read
+// @Description Mark a notification group read, along with every notification in it
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Notification group ID"
+// @Success 200 {string} string "Success"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /notifications/{id}/read [post]
+func (nc *NotificationController) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	vars := mux.Vars(r)
+	notificationId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: "invalid notification id"})
+		return
+	}
+
+	err = nc.NotificationUseCase.MarkRead(r.Context(), userId, notificationId)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, "Success")
+}
+
+// MarkAllRead godoc
+// @Summary Mark all notifications read
+// @Description Mark every notification of the current user read
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {string} string "Success"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /notifications/read-all [post]
+func (nc *NotificationController) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	err := nc.NotificationUseCase.MarkAllRead(r.Context(), userId)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, "Success")
+}
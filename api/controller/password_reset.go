@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type PasswordResetController struct {
+	PasswordResetUseCase domain.PasswordResetUseCase
+}
+
+// ForgotPassword godoc
+// @Summary Ask for a password reset
+// @Description Email a single-use reset token, valid for 30 minutes, to the account with the email. At most 3 are sent per hour to an account, and an address may ask 20 times per hour. The response is the same whether or not there is such an account.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body domain.ForgotPasswordRequest true "User email"
+// @Success 200 {object} domain.PasswordResetResponse "Reset token sent if the account exists"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 429 {object} domain.ErrorResponse "Too many requests, retry after the Retry-After header"
+// @Router /password/forgot [post]
+func (pc *PasswordResetController) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request domain.ForgotPasswordRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	err := pc.PasswordResetUseCase.ForgotPassword(ctx, request.Email, sessionClient(r).Ip)
+	if err != nil {
+		log.Error(err)
+		if tooManyAttempts(w, err) {
+			return
+		}
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, domain.PasswordResetResponse{Message: "If an account with that email exists, a password reset token was sent to it"})
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password with a reset token from the email. This logs the user out on every device.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body domain.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} domain.PasswordResetResponse "Password reset successfully"
+// @Failure 400 {object} domain.ErrorResponse "Bad request or invalid token"
+// @Router /password/reset [post]
+func (pc *PasswordResetController) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request domain.ResetPasswordRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	err := pc.PasswordResetUseCase.ResetPassword(ctx, request.Token, request.Password)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, domain.PasswordResetResponse{Message: "Password reset successfully"})
+}
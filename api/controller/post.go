@@ -27,6 +27,7 @@ type PostController struct {
 // @Security BearerAuth
 // @Param limit query int false "Limit" default(10)
 // @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Cursor from next_cursor of the previous page, takes precedence over offset"
 // @Success 200 {object} domain.PaginatedResponse "Paginated posts"
 // @Failure 400 {object} domain.ErrorResponse "Bad request"
 // @Failure 401 {object} domain.ErrorResponse "Unauthorized"
@@ -34,9 +35,13 @@ type PostController struct {
 func (pc *PostController) GetGlobalFeed(w http.ResponseWriter, r *http.Request) {
 	userId := getUserIdFromContext(r)
 
-	limit, offset := getPaginationParams(r)
+	page, err := getPageParams(r)
+	if err != nil {
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
 
-	feed, err := pc.PostUseCase.GetGlobalFeed(r.Context(), userId, limit, offset)
+	feed, err := pc.PostUseCase.GetGlobalFeed(r.Context(), userId, page)
 	if err != nil {
 		log.Error(err)
 		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
@@ -54,6 +59,7 @@ func (pc *PostController) GetGlobalFeed(w http.ResponseWriter, r *http.Request)
 // @Security BearerAuth
 // @Param limit query int false "Limit" default(10)
 // @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Cursor from next_cursor of the previous page, takes precedence over offset"
 // @Success 200 {object} domain.PaginatedResponse "Paginated posts"
 // @Failure 400 {object} domain.ErrorResponse "Bad request"
 // @Failure 401 {object} domain.ErrorResponse "Unauthorized"
@@ -61,9 +67,13 @@ func (pc *PostController) GetGlobalFeed(w http.ResponseWriter, r *http.Request)
 func (pc *PostController) GetFollowingFeed(w http.ResponseWriter, r *http.Request) {
 	userId := getUserIdFromContext(r)
 
-	limit, offset := getPaginationParams(r)
+	page, err := getPageParams(r)
+	if err != nil {
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
 
-	feed, err := pc.PostUseCase.GetFollowingFeed(r.Context(), userId, limit, offset)
+	feed, err := pc.PostUseCase.GetFollowingFeed(r.Context(), userId, page)
 	if err != nil {
 		log.Error(err)
 		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
@@ -114,6 +124,7 @@ func (pc *PostController) GetPost(w http.ResponseWriter, r *http.Request) {
 // @Param id path int true "User ID"
 // @Param limit query int false "Limit" default(10)
 // @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Cursor from next_cursor of the previous page, takes precedence over offset"
 // @Success 200 {object} domain.PaginatedResponse "Paginated posts"
 // @Failure 400 {object} domain.ErrorResponse "Bad request"
 // @Failure 401 {object} domain.ErrorResponse "Unauthorized"
@@ -129,9 +140,46 @@ func (pc *PostController) GetUserPosts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	limit, offset := getPaginationParams(r)
+	page, err := getPageParams(r)
+	if err != nil {
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	posts, err := pc.PostUseCase.GetUserPosts(r.Context(), userId, targetUserId, page)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, posts)
+}
+
+// GetTickerPosts godoc
+// @Summary Get ticker's posts
+// @Description Get posts about a specific ticker, posted on it or mentioning it as a cashtag
+// @Tags Posts
+// @Produce json
+// @Security BearerAuth
+// @Param symbol path string true "Ticker symbol" example(AAPL)
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Cursor from next_cursor of the previous page, takes precedence over offset"
+// @Success 200 {object} domain.PaginatedResponse "Paginated posts"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /tickers/{symbol}/posts [get]
+func (pc *PostController) GetTickerPosts(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	page, err := getPageParams(r)
+	if err != nil {
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
 
-	posts, err := pc.PostUseCase.GetUserPosts(r.Context(), userId, targetUserId, limit, offset)
+	posts, err := pc.PostUseCase.GetTickerPosts(r.Context(), userId, mux.Vars(r)["symbol"], page)
 	if err != nil {
 		log.Error(err)
 		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
@@ -173,6 +221,77 @@ func (pc *PostController) CreatePost(w http.ResponseWriter, r *http.Request) {
 	utils.JSON(w, http.StatusCreated, post)
 }
 
+// UpdatePost godoc
+// @Summary Edit a post
+// @Description Edit the body and sentiment of a post (only owner can edit), its ticker, price and prediction stay as posted. The version it replaces is kept in the post's history and the post shows edited_at.
+// @Tags Posts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Param request body domain.UpdatePostRequest true "Edited post"
+// @Success 200 {object} domain.PostResponse "Edited post"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /posts/{id} [put]
+func (pc *PostController) UpdatePost(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	vars := mux.Vars(r)
+	postId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: "invalid post id"})
+		return
+	}
+
+	var request domain.UpdatePostRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	post, err := pc.PostUseCase.UpdatePost(r.Context(), userId, postId, &request)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, post)
+}
+
+// GetPostRevisions godoc
+// @Summary Get the history of a post
+// @Description Get the earlier versions of an edited post, oldest first. Each lists when it was written and when it was edited away.
+// @Tags Posts
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Success 200 {array} domain.PostRevision "Earlier versions"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /posts/{id}/revisions [get]
+func (pc *PostController) GetPostRevisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	postId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: "invalid post id"})
+		return
+	}
+
+	revisions, err := pc.PostUseCase.GetPostRevisions(r.Context(), postId)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, revisions)
+}
+
 // DeletePost godoc
 // @Summary Delete a post
 // @Description Delete a post (only owner can delete)
@@ -230,18 +349,37 @@ func getPaginationParams(r *http.Request) (limit, offset int) {
 	return limit, offset
 }
 
+// getPageParams reads limit and offset like getPaginationParams, plus the
+// cursor of endpoints that support keyset pagination
+func getPageParams(r *http.Request) (domain.PaginationParams, error) {
+	limit, offset := getPaginationParams(r)
+	page := domain.PaginationParams{Limit: limit, Offset: offset}
+
+	if c := r.URL.Query().Get("cursor"); c != "" {
+		cursor, err := domain.DecodeCursor(c)
+		if err != nil {
+			return page, err
+		}
+		page.Cursor = cursor
+	}
+
+	return page, nil
+}
+
 // TrackBatchViews godoc
 // @Summary Track batch post views
 // @Description Track views for multiple posts in a single request
 // @Tags Posts
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param request body domain.BatchViewRequest true "Post IDs to track"
 // @Success 200 {object} domain.BatchViewResponse "Views tracked successfully"
 // @Failure 400 {object} domain.ErrorResponse "Bad request"
 // @Router /posts/views/batch [post]
 func (pc *PostController) TrackBatchViews(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	userId := getUserIdFromContext(r)
 	var request domain.BatchViewRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
@@ -261,7 +399,7 @@ func (pc *PostController) TrackBatchViews(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err := pc.PostUseCase.TrackBatchViews(ctx, request.PostIds)
+	err := pc.PostUseCase.TrackBatchViews(ctx, userId, request.PostIds)
 	if err != nil {
 		log.Error("Failed to track batch views: ", err)
 		utils.JSON(w, http.StatusInternalServerError, domain.ErrorResponse{Message: "Failed to track views"})
@@ -276,3 +414,42 @@ func (pc *PostController) TrackBatchViews(w http.ResponseWriter, r *http.Request
 	utils.JSON(w, http.StatusOK, response)
 }
 
+// GetPostViews godoc
+// @Summary Get post views history
+// @Description Get the daily unique viewers and impressions of your own post
+// @Tags Posts
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Param days query int false "Number of days, including today" default(30)
+// @Success 200 {array} domain.PostViewsDay "Daily views, oldest first"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /posts/{id}/views [get]
+func (pc *PostController) GetPostViews(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	vars := mux.Vars(r)
+	postId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: "invalid post id"})
+		return
+	}
+
+	days := 30
+	if d := r.URL.Query().Get("days"); d != "" {
+		if parsed, err := strconv.Atoi(d); err == nil && parsed > 0 && parsed <= 90 {
+			days = parsed
+		}
+	}
+
+	history, err := pc.PostUseCase.GetPostViewsHistory(r.Context(), userId, postId, days)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, history)
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMalformedCursorIsBadRequest(t *testing.T) {
+	cursors := []string{
+		"not a cursor!",
+		base64.RawURLEncoding.EncodeToString([]byte("1700000000000000")),
+		base64.RawURLEncoding.EncodeToString([]byte("1700000000000000:abc")),
+	}
+
+	// The cursor is refused before the use case is reached
+	pc := &PostController{}
+	for _, cursor := range cursors {
+		r := httptest.NewRequest(http.MethodGet, "/posts?cursor="+url.QueryEscape(cursor), nil)
+		w := httptest.NewRecorder()
+		pc.GetGlobalFeed(w, r)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, cursor)
+		var response domain.ErrorResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		assert.Equal(t, domain.ErrInvalidCursor.Error(), response.Message)
+	}
+}
@@ -36,7 +36,7 @@ func (rtc *RefreshTokenController) RefreshToken(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	accessToken, refreshToken, err := rtc.RefreshTokenUseCase.RefreshToken(ctx, request, rtc.Env)
+	accessToken, refreshToken, err := rtc.RefreshTokenUseCase.RefreshToken(ctx, request, sessionClient(r), rtc.Env)
 	if err != nil {
 		log.Error(err)
 		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
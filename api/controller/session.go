@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/utils"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+type SessionController struct {
+	SessionUseCase domain.SessionUseCase
+	Env            *bootstrap.Env
+}
+
+// GetSessions godoc
+// @Summary Get sessions
+// @Description Get the devices the current user is logged in on, most recently seen first. The session of the access token making the request is marked current.
+// @Tags Sessions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} domain.SessionResponse "Sessions"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /sessions [get]
+func (sc *SessionController) GetSessions(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	sessions, err := sc.SessionUseCase.GetSessions(r.Context(), userId, getSessionIdFromContext(r))
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, sessions)
+}
+
+// RevokeSession godoc
+// @Summary Log out a session
+// @Description End a session of the current user. Its access and refresh tokens stop working at once.
+// @Tags Sessions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Session ID"
+// @Success 200 {string} string "Success"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /sessions/{id} [delete]
+func (sc *SessionController) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	sessionId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: "invalid session id"})
+		return
+	}
+
+	err = sc.SessionUseCase.RevokeSession(r.Context(), userId, sessionId)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, "Success")
+}
+
+// RevokeOtherSessions godoc
+// @Summary Log out everywhere else
+// @Description End every session of the current user except the one making the request
+// @Tags Sessions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} domain.RevokeSessionsResponse "Number of sessions ended"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /sessions/others [delete]
+func (sc *SessionController) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	revoked, err := sc.SessionUseCase.RevokeOtherSessions(r.Context(), userId, getSessionIdFromContext(r))
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, domain.RevokeSessionsResponse{Revoked: revoked})
+}
+
+// getSessionIdFromContext returns the session of the access token, set by the JWT middleware
+func getSessionIdFromContext(r *http.Request) int {
+	id := fmt.Sprintf("%v", r.Context().Value("session_id"))
+	sessionId, _ := strconv.Atoi(id)
+	return sessionId
+}
+
+// sessionClient describes the device a request came from. The address is the
+// one nginx in front of the API saw, it overwrites X-Real-IP while clients can
+// put anything in X-Forwarded-For. Attempt limits per address rely on this.
+func sessionClient(r *http.Request) domain.SessionClient {
+	ip := strings.TrimSpace(r.Header.Get("X-Real-IP"))
+	if ip == "" {
+		ip = r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			ip = host
+		}
+	}
+
+	return domain.SessionClient{
+		UserAgent: r.UserAgent(),
+		Ip:        ip,
+	}
+}
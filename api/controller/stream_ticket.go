@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type StreamTicketController struct {
+	StreamTicketUseCase domain.StreamTicketUseCase
+}
+
+// IssueTicket godoc
+// @Summary Get a stream ticket
+// @Description Get a ticket to open a chat WebSocket or the feed stream with, for clients that cannot set the Authorization header on those. Pass it in the ticket query parameter. It works once, within 30 seconds, for the session of the access token making the request.
+// @Tags Authentication
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} domain.StreamTicketResponse "Ticket"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /stream-ticket [post]
+func (sc *StreamTicketController) IssueTicket(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+	familyId := fmt.Sprintf("%v", r.Context().Value("family_id"))
+
+	ticket, err := sc.StreamTicketUseCase.IssueTicket(r.Context(), userId, familyId)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, ticket)
+}
@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/utils"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// Largest accepted ticker import, well above any realistic symbol list
+const maxTickerImportSize = 10 << 20
+
+type TickerController struct {
+	TickerUseCase         domain.TickerUseCase
+	TickerFollowerUseCase domain.TickerFollowerUseCase
+	Env                   *bootstrap.Env
+}
+
+// SearchTickers godoc
+// @Summary Search tickers
+// @Description Autocomplete active tickers by symbol prefix or name
+// @Tags Tickers
+// @Produce json
+// @Security BearerAuth
+// @Param q query string true "Symbol or name to search for" example(aap)
+// @Param limit query int false "Limit" default(10)
+// @Success 200 {array} domain.Ticker "Matching tickers"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /tickers [get]
+func (tc *TickerController) SearchTickers(w http.ResponseWriter, r *http.Request) {
+	limit := 10
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 50 {
+			limit = parsed
+		}
+	}
+
+	tickers, err := tc.TickerUseCase.Search(r.Context(), r.URL.Query().Get("q"), limit)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, tickers)
+}
+
+// GetTicker godoc
+// @Summary Get a ticker
+// @Description Get a ticker with its post count, distinct authors and bullish/bearish tagged posts of the last 24h, and followers count
+// @Tags Tickers
+// @Produce json
+// @Security BearerAuth
+// @Param symbol path string true "Ticker symbol" example(AAPL)
+// @Success 200 {object} domain.TickerResponse "Ticker"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /tickers/{symbol} [get]
+func (tc *TickerController) GetTicker(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	ticker, err := tc.TickerUseCase.GetTicker(r.Context(), userId, mux.Vars(r)["symbol"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, ticker)
+}
+
+// GetTickerSentiment godoc
+// @Summary Get ticker sentiment
+// @Description Get the bullish, bearish and neutral split of the posts on a ticker over the last 1h, 24h and 7d
+// @Tags Tickers
+// @Produce json
+// @Security BearerAuth
+// @Param symbol path string true "Ticker symbol" example(AAPL)
+// @Success 200 {object} domain.TickerSentimentResponse "Sentiment split"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /tickers/{symbol}/sentiment [get]
+func (tc *TickerController) GetTickerSentiment(w http.ResponseWriter, r *http.Request) {
+	sentiment, err := tc.TickerUseCase.GetSentiment(r.Context(), mux.Vars(r)["symbol"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, sentiment)
+}
+
+// GetTickerSentimentSeries godoc
+// @Summary Get ticker sentiment time series
+// @Description Get the sentiment split of the posts on a ticker per hour over the last 24h or per day over the last 30 days, oldest first
+// @Tags Tickers
+// @Produce json
+// @Security BearerAuth
+// @Param symbol path string true "Ticker symbol" example(AAPL)
+// @Param interval query string false "Bucket size, hour or day" default(hour)
+// @Success 200 {object} domain.SentimentSeriesResponse "Sentiment series"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /tickers/{symbol}/sentiment/series [get]
+func (tc *TickerController) GetTickerSentimentSeries(w http.ResponseWriter, r *http.Request) {
+	series, err := tc.TickerUseCase.GetSentimentSeries(r.Context(), mux.Vars(r)["symbol"], r.URL.Query().Get("interval"))
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, series)
+}
+
+// FollowTicker godoc
+// @Summary Follow a ticker
+// @Description Follow a ticker, its posts show up in the following feed
+// @Tags Tickers
+// @Produce json
+// @Security BearerAuth
+// @Param symbol path string true "Ticker symbol" example(AAPL)
+// @Success 200 {string} string "Success"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /tickers/{symbol}/follow [post]
+func (tc *TickerController) FollowTicker(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	err := tc.TickerFollowerUseCase.Follow(r.Context(), userId, mux.Vars(r)["symbol"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, "Success")
+}
+
+// UnfollowTicker godoc
+// @Summary Unfollow a ticker
+// @Description Unfollow a ticker
+// @Tags Tickers
+// @Produce json
+// @Security BearerAuth
+// @Param symbol path string true "Ticker symbol" example(AAPL)
+// @Success 200 {string} string "Success"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /tickers/{symbol}/follow [delete]
+func (tc *TickerController) UnfollowTicker(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	err := tc.TickerFollowerUseCase.Unfollow(r.Context(), userId, mux.Vars(r)["symbol"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, "Success")
+}
+
+// GetTickerFollowers godoc
+// @Summary Get ticker followers
+// @Description Get the users following a ticker
+// @Tags Tickers
+// @Produce json
+// @Security BearerAuth
+// @Param symbol path string true "Ticker symbol" example(AAPL)
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Cursor from next_cursor of the previous page, takes precedence over offset"
+// @Success 200 {object} domain.PaginatedResponse "Paginated followers"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /tickers/{symbol}/followers [get]
+func (tc *TickerController) GetTickerFollowers(w http.ResponseWriter, r *http.Request) {
+	page, err := getPageParams(r)
+	if err != nil {
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	followers, err := tc.TickerFollowerUseCase.GetFollowers(r.Context(), mux.Vars(r)["symbol"], page)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, followers)
+}
+
+// ImportTickers godoc
+// @Summary Import tickers
+// @Description Create or update tickers from a CSV with a symbol,name,exchange,asset_class[,active] header
+// @Tags Admin
+// @Accept text/csv
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param file body string true "Ticker CSV"
+// @Success 200 {object} domain.TickerImportResponse "Tickers imported"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 403 {object} domain.ErrorResponse "Forbidden"
+// @Router /admin/tickers/import [post]
+func (tc *TickerController) ImportTickers(w http.ResponseWriter, r *http.Request) {
+	body := http.MaxBytesReader(w, r.Body, maxTickerImportSize)
+
+	imported, err := tc.TickerUseCase.Import(r.Context(), body)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, domain.TickerImportResponse{
+		Message:  "Tickers imported successfully",
+		Imported: imported,
+	})
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type TrendingController struct {
+	TrendingUseCase domain.TrendingUseCase
+	Env             *bootstrap.Env
+}
+
+// GetTrendingTickers godoc
+// @Summary Get trending tickers
+// @Description Get the tickers with the most time-decayed mentions and engagement over the last 24h, hottest first
+// @Tags Trending
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit" default(10)
+// @Success 200 {array} domain.TrendingTicker "Trending tickers"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /trending/tickers [get]
+func (tc *TrendingController) GetTrendingTickers(w http.ResponseWriter, r *http.Request) {
+	tickers, err := tc.TrendingUseCase.GetTrendingTickers(r.Context(), getTrendingLimit(r))
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, tickers)
+}
+
+// GetTrendingPosts godoc
+// @Summary Get trending posts
+// @Description Get the posts with the most time-decayed likes, comments and view velocity over the last 24h, hottest first
+// @Tags Trending
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit" default(10)
+// @Success 200 {array} domain.PostResponse "Trending posts"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /trending/posts [get]
+func (tc *TrendingController) GetTrendingPosts(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	posts, err := tc.TrendingUseCase.GetTrendingPosts(r.Context(), userId, getTrendingLimit(r))
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, posts)
+}
+
+func getTrendingLimit(r *http.Request) int {
+	limit := 10
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 50 {
+			limit = parsed
+		}
+	}
+	return limit
+}
@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type TwoFactorController struct {
+	TwoFactorUseCase domain.TwoFactorUseCase
+	Env              *bootstrap.Env
+}
+
+// Enroll godoc
+// @Summary Start two-factor enrollment
+// @Description Generate a TOTP secret for an authenticator app, shown as a QR code of the provisioning URI. Two-factor authentication is on once a code of it is confirmed. Enrolling again starts over with a new secret.
+// @Tags Two-Factor Authentication
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} domain.TwoFactorEnrollResponse "Secret and provisioning URI"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /2fa/enroll [post]
+func (tc *TwoFactorController) Enroll(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	enrollment, err := tc.TwoFactorUseCase.Enroll(r.Context(), userId)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, enrollment)
+}
+
+// Confirm godoc
+// @Summary Confirm two-factor enrollment
+// @Description Turn two-factor authentication on with a code from the authenticator app. Returns recovery codes, each usable once in place of a code, that are not shown again.
+// @Tags Two-Factor Authentication
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body domain.TwoFactorCodeRequest true "Code from the authenticator app"
+// @Success 200 {object} domain.RecoveryCodesResponse "Recovery codes"
+// @Failure 400 {object} domain.ErrorResponse "Bad request or invalid code"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Failure 429 {object} domain.ErrorResponse "Too many failed attempts, retry after the Retry-After header"
+// @Router /2fa/confirm [post]
+func (tc *TwoFactorController) Confirm(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	var request domain.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	recoveryCodes, err := tc.TwoFactorUseCase.Confirm(r.Context(), userId, request.Code, sessionClient(r).Ip)
+	if err != nil {
+		log.Error(err)
+		if tooManyAttempts(w, err) {
+			return
+		}
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, recoveryCodes)
+}
+
+// Disable godoc
+// @Summary Disable two-factor authentication
+// @Description Turn two-factor authentication off with the current password and a code from the authenticator app or a recovery code. Accounts that log in with Google give the code only.
+// @Tags Two-Factor Authentication
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body domain.TwoFactorDisableRequest true "Password and code or recovery code"
+// @Success 200 {string} string "Success"
+// @Failure 400 {object} domain.ErrorResponse "Bad request, invalid password or code"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Failure 429 {object} domain.ErrorResponse "Too many failed attempts, retry after the Retry-After header"
+// @Router /2fa/disable [post]
+func (tc *TwoFactorController) Disable(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	var request domain.TwoFactorDisableRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	err := tc.TwoFactorUseCase.Disable(r.Context(), userId, request, sessionClient(r).Ip)
+	if err != nil {
+		log.Error(err)
+		if tooManyAttempts(w, err) {
+			return
+		}
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, "Success")
+}
+
+// VerifyLogin godoc
+// @Summary Complete a two-factor login
+// @Description Redeem the challenge token of a login for the access and refresh tokens with a code from the authenticator app or a recovery code. A challenge lasts 5 minutes and allows 5 attempts. Failed codes also count per user across challenges, locking the second factor out for a while.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body domain.TwoFactorLoginRequest true "Challenge token and code"
+// @Success 200 {object} domain.LoginResponse "Successfully logged in"
+// @Failure 400 {object} domain.ErrorResponse "Bad request, invalid code or challenge"
+// @Failure 429 {object} domain.ErrorResponse "Too many failed attempts, retry after the Retry-After header"
+// @Router /login/2fa [post]
+func (tc *TwoFactorController) VerifyLogin(w http.ResponseWriter, r *http.Request) {
+	var request domain.TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	response, err := tc.TwoFactorUseCase.VerifyLogin(r.Context(), request, sessionClient(r), tc.Env)
+	if err != nil {
+		log.Error(err)
+		if tooManyAttempts(w, err) {
+			return
+		}
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, response)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/Pro100-Almaz/trading-chat/domain"
 	"github.com/Pro100-Almaz/trading-chat/utils"
 
+	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -146,3 +147,32 @@ func (uc *UserController) DeleteUser(w http.ResponseWriter, r *http.Request) {
 
 	utils.JSON(w, http.StatusOK, "Success")
 }
+
+// GetPredictionRecord godoc
+// @Summary Get a user's prediction record
+// @Description Get how many of a user's price predictions hit, missed or are pending, and their accuracy
+// @Tags Users
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} domain.PredictionRecord "Prediction record"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /users/{id}/prediction-record [get]
+func (uc *UserController) GetPredictionRecord(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: "invalid user id"})
+		return
+	}
+
+	record, err := uc.UserUseCase.GetPredictionRecord(r.Context(), id)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, record)
+}
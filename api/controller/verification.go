@@ -23,6 +23,7 @@ type VerificationController struct {
 // @Param request body domain.VerifyEmailRequest true "Email and verification code"
 // @Success 200 {object} domain.VerificationResponse "Email verified successfully"
 // @Failure 400 {object} domain.ErrorResponse "Bad request or invalid code"
+// @Failure 429 {object} domain.ErrorResponse "Too many failed attempts, retry after the Retry-After header"
 // @Router /verify-email [post]
 func (vc *VerificationController) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -34,9 +35,12 @@ func (vc *VerificationController) VerifyEmail(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	err := vc.VerificationUseCase.VerifyEmail(ctx, request.Email, request.Code)
+	err := vc.VerificationUseCase.VerifyEmail(ctx, request.Email, request.Code, sessionClient(r).Ip)
 	if err != nil {
 		log.Error(err)
+		if tooManyAttempts(w, err) {
+			return
+		}
 		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
 		return
 	}
@@ -0,0 +1,321 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/utils"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+type WatchlistController struct {
+	WatchlistUseCase domain.WatchlistUseCase
+	Env              *bootstrap.Env
+}
+
+// GetWatchlists godoc
+// @Summary Get watchlists
+// @Description Get the current user's watchlists with their tickers and alert rules, oldest first
+// @Tags Watchlists
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} domain.WatchlistResponse "Watchlists"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /watchlists [get]
+func (wc *WatchlistController) GetWatchlists(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	watchlists, err := wc.WatchlistUseCase.GetWatchlists(r.Context(), userId)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, watchlists)
+}
+
+// CreateWatchlist godoc
+// @Summary Create a watchlist
+// @Description Create a named watchlist, optionally with its tickers. A user keeps up to 20 watchlists of up to 100 tickers each.
+// @Tags Watchlists
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body domain.WatchlistRequest true "Watchlist data"
+// @Success 201 {object} domain.WatchlistResponse "Created watchlist"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /watchlists [post]
+func (wc *WatchlistController) CreateWatchlist(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	var request domain.WatchlistRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	watchlist, err := wc.WatchlistUseCase.CreateWatchlist(r.Context(), userId, &request)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, watchlist)
+}
+
+// GetWatchlist godoc
+// @Summary Get a watchlist
+// @Description Get a watchlist of the current user with its tickers and alert rules
+// @Tags Watchlists
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Watchlist ID"
+// @Success 200 {object} domain.WatchlistResponse "Watchlist"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /watchlists/{id} [get]
+func (wc *WatchlistController) GetWatchlist(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	watchlistId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: "invalid watchlist id"})
+		return
+	}
+
+	watchlist, err := wc.WatchlistUseCase.GetWatchlist(r.Context(), userId, watchlistId)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, watchlist)
+}
+
+// UpdateWatchlist godoc
+// @Summary Update a watchlist
+// @Description Rename a watchlist and, when symbols are given, replace its tickers. Alert rules on the tickers taken off are deleted.
+// @Tags Watchlists
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Watchlist ID"
+// @Param request body domain.WatchlistRequest true "Watchlist data"
+// @Success 200 {object} domain.WatchlistResponse "Updated watchlist"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /watchlists/{id} [put]
+func (wc *WatchlistController) UpdateWatchlist(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	watchlistId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: "invalid watchlist id"})
+		return
+	}
+
+	var request domain.WatchlistRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	watchlist, err := wc.WatchlistUseCase.UpdateWatchlist(r.Context(), userId, watchlistId, &request)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, watchlist)
+}
+
+// DeleteWatchlist godoc
+// @Summary Delete a watchlist
+// @Description Delete a watchlist along with its alert rules
+// @Tags Watchlists
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Watchlist ID"
+// @Success 200 {string} string "Success"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /watchlists/{id} [delete]
+func (wc *WatchlistController) DeleteWatchlist(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	watchlistId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: "invalid watchlist id"})
+		return
+	}
+
+	err = wc.WatchlistUseCase.DeleteWatchlist(r.Context(), userId, watchlistId)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, "Success")
+}
+
+// AddSymbol godoc
+// @Summary Add a ticker to a watchlist
+// @Description Add an active ticker to a watchlist, adding one it holds does nothing
+// @Tags Watchlists
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Watchlist ID"
+// @Param symbol path string true "Ticker symbol" example(AAPL)
+// @Success 200 {string} string "Success"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /watchlists/{id}/symbols/{symbol} [post]
+func (wc *WatchlistController) AddSymbol(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	vars := mux.Vars(r)
+	watchlistId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: "invalid watchlist id"})
+		return
+	}
+
+	err = wc.WatchlistUseCase.AddSymbol(r.Context(), userId, watchlistId, vars["symbol"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, "Success")
+}
+
+// RemoveSymbol godoc
+// @Summary Remove a ticker from a watchlist
+// @Description Remove a ticker from a watchlist along with its alert rules
+// @Tags Watchlists
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Watchlist ID"
+// @Param symbol path string true "Ticker symbol" example(AAPL)
+// @Success 200 {string} string "Success"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /watchlists/{id}/symbols/{symbol} [delete]
+func (wc *WatchlistController) RemoveSymbol(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	vars := mux.Vars(r)
+	watchlistId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: "invalid watchlist id"})
+		return
+	}
+
+	err = wc.WatchlistUseCase.RemoveSymbol(r.Context(), userId, watchlistId, vars["symbol"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, "Success")
+}
+
+// CreateAlertRule godoc
+// @Summary Create an alert rule
+// @Description Attach an alert rule to a ticker of a watchlist. price_cross fires when the price crosses level either way, percent_move when the price moved percent or more since the start of the UTC day, at most once a day, and sentiment_flip when the bullish or bearish majority of the last day's posts turns. Fired alerts arrive as notifications. A user keeps up to 50 rules.
+// @Tags Watchlists
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Watchlist ID"
+// @Param request body domain.AlertRuleRequest true "Alert rule"
+// @Success 201 {object} domain.AlertRule "Created alert rule"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /watchlists/{id}/alerts [post]
+func (wc *WatchlistController) CreateAlertRule(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	watchlistId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: "invalid watchlist id"})
+		return
+	}
+
+	var request domain.AlertRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	rule, err := wc.WatchlistUseCase.CreateAlertRule(r.Context(), userId, watchlistId, &request)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, rule)
+}
+
+// DeleteAlertRule godoc
+// @Summary Delete an alert rule
+// @Description Delete an alert rule of a watchlist
+// @Tags Watchlists
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Watchlist ID"
+// @Param ruleId path int true "Alert rule ID"
+// @Success 200 {string} string "Success"
+// @Failure 400 {object} domain.ErrorResponse "Bad request"
+// @Failure 401 {object} domain.ErrorResponse "Unauthorized"
+// @Router /watchlists/{id}/alerts/{ruleId} [delete]
+func (wc *WatchlistController) DeleteAlertRule(w http.ResponseWriter, r *http.Request) {
+	userId := getUserIdFromContext(r)
+
+	vars := mux.Vars(r)
+	watchlistId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: "invalid watchlist id"})
+		return
+	}
+	ruleId, err := strconv.Atoi(vars["ruleId"])
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: "invalid alert rule id"})
+		return
+	}
+
+	err = wc.WatchlistUseCase.DeleteAlertRule(r.Context(), userId, watchlistId, ruleId)
+	if err != nil {
+		log.Error(err)
+		utils.JSON(w, http.StatusBadRequest, domain.ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, "Success")
+}
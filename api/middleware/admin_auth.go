@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/utils"
+)
+
+// AdminKeyMiddleware lets through requests carrying the admin API key in the
+// X-Admin-Key header. Admin endpoints are disabled when no key is configured.
+func AdminKeyMiddleware(adminKey string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				key := r.Header.Get("X-Admin-Key")
+				if adminKey == "" || subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) != 1 {
+					utils.JSON(w, http.StatusForbidden, domain.ErrorResponse{Message: domain.ErrUserNotAllowed.Error()})
+					return
+				}
+				next.ServeHTTP(w, r)
+			})
+	}
+}
@@ -2,23 +2,118 @@ package middleware
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/Pro100-Almaz/trading-chat/domain"
 	"github.com/Pro100-Almaz/trading-chat/internal/tokenutil"
 	"github.com/Pro100-Almaz/trading-chat/repository"
 	"github.com/Pro100-Almaz/trading-chat/utils"
+
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
 )
 
-func JwtAuthMiddleware(secret string, tokenBlacklistRepo repository.TokenBlacklistRepository) func(next http.Handler) http.Handler {
+// bearerToken returns the token from the Authorization header
+func bearerToken(r *http.Request) string {
+	t := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(t) == 2 {
+		return t[1]
+	}
+	return ""
+}
+
+// Open streams check this often that their session is still live
+const streamSessionCheckPeriod = 30 * time.Second
+
+// isStream tells a WebSocket handshake or an EventSource request, which stay open
+func isStream(r *http.Request) bool {
+	return websocket.IsWebSocketUpgrade(r) || strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// streamTicket returns the ticket query parameter of a WebSocket handshake or
+// an EventSource request. Browsers cannot set headers on those, and unlike an
+// access token a ticket in the URL is spent once it is used and lasts seconds.
+func streamTicket(r *http.Request) string {
+	if isStream(r) {
+		return r.URL.Query().Get("ticket")
+	}
+	return ""
+}
+
+// liveSession tells whether session is a live one of the user
+func liveSession(session *domain.Session, userId int) bool {
+	return session.UserId == userId && session.RevokedAt == nil
+}
+
+// watchSession cancels the context of a stream once its session is revoked,
+// checking every period until the stream ends. Failed checks keep it open.
+func watchSession(ctx context.Context, cancel context.CancelFunc, sessionRepo repository.SessionRepository, userId int, familyId string, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			session, err := sessionRepo.GetSessionByFamilyId(ctx, familyId)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				log.Warn("Failed to check session of stream: ", err)
+				continue
+			}
+			if err != nil || !liveSession(session, userId) {
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// JwtAuthMiddleware lets through requests carrying an access token, or a
+// stream ticket, of a live session. Tokens of a revoked session stop working
+// at once rather than when they expire, and streams opened with them are
+// closed within streamSessionCheckPeriod.
+func JwtAuthMiddleware(secret string, tokenBlacklistRepo repository.TokenBlacklistRepository, sessionRepo repository.SessionRepository, streamTicketRepo repository.StreamTicketRepository) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
+		// serveSession passes the request on for the session familyId of the user, if it is live
+		serveSession := func(w http.ResponseWriter, r *http.Request, userId int, familyId string) {
+			session, err := sessionRepo.GetSessionByFamilyId(r.Context(), familyId)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				utils.JSON(w, 401, domain.ErrorResponse{Message: "Failed to verify token"})
+				return
+			}
+			if err != nil || !liveSession(session, userId) {
+				utils.JSON(w, 401, domain.ErrorResponse{Message: domain.ErrSessionRevoked.Error()})
+				return
+			}
+			if err := sessionRepo.TouchSession(r.Context(), session.Id); err != nil {
+				log.Warn("Failed to update last seen time of session: ", err)
+			}
+
+			// set user and session ids to context
+			ctx := context.WithValue(r.Context(), "user_id", userId)
+			ctx = context.WithValue(ctx, "session_id", session.Id)
+			ctx = context.WithValue(ctx, "family_id", session.FamilyId)
+			if isStream(r) {
+				// The stream handlers close the connection once the context is done
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				defer cancel()
+				go watchSession(ctx, cancel, sessionRepo, userId, session.FamilyId, streamSessionCheckPeriod)
+			}
+			r = r.WithContext(ctx)
+			next.ServeHTTP(w, r)
+		}
+
 		return http.HandlerFunc(
 			func(w http.ResponseWriter, r *http.Request) {
-				authHeader := r.Header.Get("Authorization")
-				t := strings.Split(authHeader, " ")
-				if len(t) == 2 {
-					authToken := t[1]
+				authToken := bearerToken(r)
+				if authToken != "" {
 
 					// Check if token is blacklisted
 					if tokenBlacklistRepo != nil {
@@ -33,24 +128,28 @@ func JwtAuthMiddleware(secret string, tokenBlacklistRepo repository.TokenBlackli
 						}
 					}
 
-					authorized, err := tokenutil.IsAuthorized(authToken, secret)
+					claims, err := tokenutil.ParseAccessToken(authToken, secret)
 					if err != nil {
 						utils.JSON(w, 401, domain.ErrorResponse{Message: err.Error()})
 						return
 					}
-					if authorized {
-						userID, err := tokenutil.ExtractIDFromToken(authToken, secret)
-						if err != nil {
-							utils.JSON(w, 401, domain.ErrorResponse{Message: err.Error()})
-							return
+
+					// Check that the session of the token is still live
+					serveSession(w, r, claims.ID, claims.FamilyId)
+					return
+				}
+
+				if ticket := streamTicket(r); ticket != "" {
+					userId, familyId, err := streamTicketRepo.RedeemTicket(r.Context(), ticket)
+					if err != nil {
+						if !errors.Is(err, redis.Nil) {
+							log.Error("Failed to redeem stream ticket: ", err)
 						}
-						// set user id to context
-						ctx := context.WithValue(r.Context(), "user_id", userID)
-						r = r.WithContext(ctx)
-						next.ServeHTTP(w, r)
+						utils.JSON(w, 401, domain.ErrorResponse{Message: domain.ErrInvalidStreamTicket.Error()})
 						return
 					}
-					utils.JSON(w, 401, domain.ErrorResponse{Message: domain.ErrUnauthorized.Error()})
+
+					serveSession(w, r, userId, familyId)
 					return
 				}
 				utils.JSON(w, 401, domain.ErrorResponse{Message: domain.ErrUnauthorized.Error()})
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/internal/tokenutil"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "test-secret"
+
+// fakeSessionRepo holds sessions by family id, failing lookups while err is set
+type fakeSessionRepo struct {
+	repository.SessionRepository
+	mu       sync.Mutex
+	sessions map[string]*domain.Session
+	err      error
+}
+
+func (f *fakeSessionRepo) GetSessionByFamilyId(ctx context.Context, familyId string) (*domain.Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	session, ok := f.sessions[familyId]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (f *fakeSessionRepo) TouchSession(ctx context.Context, id int) error {
+	return nil
+}
+
+func (f *fakeSessionRepo) revoke(familyId string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	f.sessions[familyId].RevokedAt = &now
+}
+
+func (f *fakeSessionRepo) fail(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+func TestJwtAuthNeedsLiveSessionOfTheUser(t *testing.T) {
+	revokedAt := time.Now()
+	sessions := &fakeSessionRepo{sessions: map[string]*domain.Session{
+		"live":    {Id: 1, UserId: 1, FamilyId: "live"},
+		"revoked": {Id: 2, UserId: 1, FamilyId: "revoked", RevokedAt: &revokedAt},
+		"other":   {Id: 3, UserId: 2, FamilyId: "other"},
+	}}
+	handler := JwtAuthMiddleware(testSecret, nil, sessions, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, 1, r.Context().Value("session_id"))
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := map[string]int{
+		"live":    http.StatusOK,
+		"revoked": http.StatusUnauthorized,
+		// A session of another user
+		"other": http.StatusUnauthorized,
+		// Tokens issued before sessions carry no family id
+		"": http.StatusUnauthorized,
+	}
+	for familyId, want := range tests {
+		token, err := tokenutil.CreateAccessToken(&domain.User{Id: 1, Email: "jane@example.com"}, familyId, testSecret, 1)
+		require.NoError(t, err)
+
+		r := httptest.NewRequest(http.MethodGet, "/profile", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, want, w.Code, familyId)
+	}
+}
+
+func TestWatchSessionClosesRevokedStream(t *testing.T) {
+	sessions := &fakeSessionRepo{sessions: map[string]*domain.Session{
+		"live": {Id: 1, UserId: 1, FamilyId: "live"},
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watchSession(ctx, cancel, sessions, 1, "live", time.Millisecond)
+
+	// A failed check keeps the stream open
+	sessions.fail(errors.New("connection refused"))
+	select {
+	case <-ctx.Done():
+		t.Fatal("stream closed on a failed check")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	sessions.fail(nil)
+	sessions.revoke("live")
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("stream of a revoked session left open")
+	}
+}
+
+func TestWatchSessionStopsWithStream(t *testing.T) {
+	sessions := &fakeSessionRepo{sessions: map[string]*domain.Session{
+		"live": {Id: 1, UserId: 1, FamilyId: "live"},
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		watchSession(ctx, cancel, sessions, 1, "live", time.Millisecond)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watcher outlived the stream")
+	}
+}
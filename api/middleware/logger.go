@@ -6,9 +6,11 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// LoggerMiddleware logs the method and path of requests. The query string is
+// left out, it may carry secrets such as a stream ticket.
 func LoggerMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Info(r.Method, " ", r.RequestURI)
+		log.Info(r.Method, " ", r.URL.Path)
 		next.ServeHTTP(w, r)
 	})
 }
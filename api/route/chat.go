@@ -0,0 +1,28 @@
+package route
+
+import (
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/api/controller"
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+	"github.com/Pro100-Almaz/trading-chat/usecase"
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+)
+
+func NewChatRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, redisClient *redis.Client, r *mux.Router) {
+	chatMessageRepo := repository.NewChatMessageRepository(db)
+	chatPubSubRepo := repository.NewChatPubSubRepository(redisClient)
+	userRepo := repository.NewUserRepository(db)
+
+	chatController := &controller.ChatController{
+		ChatUseCase: usecase.NewChatUseCase(chatMessageRepo, chatPubSubRepo, userRepo, timeout),
+		Env:         env,
+	}
+
+	chatGroup := r.PathPrefix("/chat").Subrouter()
+	chatGroup.HandleFunc("/{ticker}", chatController.JoinRoom).Methods("GET")
+	chatGroup.HandleFunc("/{ticker}/messages", chatController.GetChatHistory).Methods("GET")
+}
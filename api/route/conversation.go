@@ -0,0 +1,33 @@
+package route
+
+import (
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/api/controller"
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+	"github.com/Pro100-Almaz/trading-chat/usecase"
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+)
+
+func NewConversationRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, r *mux.Router) {
+	conversationRepo := repository.NewConversationRepository(db)
+	conversationMemberRepo := repository.NewConversationMemberRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	userRepo := repository.NewUserRepository(db)
+
+	conversationUseCase := usecase.NewConversationUseCase(conversationRepo, conversationMemberRepo, messageRepo, userRepo, timeout)
+
+	conversationController := &controller.ConversationController{
+		ConversationUseCase: conversationUseCase,
+		Env:                 env,
+	}
+
+	conversationsGroup := r.PathPrefix("/conversations").Subrouter()
+	conversationsGroup.HandleFunc("", conversationController.GetInbox).Methods("GET")
+	conversationsGroup.HandleFunc("", conversationController.StartConversation).Methods("POST")
+	conversationsGroup.HandleFunc("/{id}/messages", conversationController.GetMessages).Methods("GET")
+	conversationsGroup.HandleFunc("/{id}/messages", conversationController.SendMessage).Methods("POST")
+	conversationsGroup.HandleFunc("/{id}/read", conversationController.MarkAsRead).Methods("POST")
+}
@@ -0,0 +1,26 @@
+package route
+
+import (
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/api/controller"
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+	"github.com/Pro100-Almaz/trading-chat/usecase"
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+)
+
+func NewFeedStreamRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, redisClient *redis.Client, r *mux.Router) {
+	feedEventRepo := repository.NewFeedEventRepository(redisClient)
+	followerRepo := repository.NewFollowerRepository(db)
+	tickerFollowerRepo := repository.NewTickerFollowerRepository(db)
+
+	feedStreamController := &controller.FeedStreamController{
+		FeedStreamUseCase: usecase.NewFeedStreamUseCase(feedEventRepo, followerRepo, tickerFollowerRepo, timeout),
+		Env:               env,
+	}
+
+	r.HandleFunc("/feed/stream", feedStreamController.StreamFeed).Methods("GET")
+}
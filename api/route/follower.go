@@ -9,13 +9,20 @@ import (
 	"github.com/Pro100-Almaz/trading-chat/usecase"
 	"github.com/gorilla/mux"
 	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
 )
 
-func NewFollowerRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, r *mux.Router) {
+func NewFollowerRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, redisClient *redis.Client, r *mux.Router) {
 	followerRepo := repository.NewFollowerRepository(db)
 	userRepo := repository.NewUserRepository(db)
+	postRepo := repository.NewPostRepository(db)
+	timelineRepo := repository.NewTimelineRepository(redisClient)
+	tickerFollowerRepo := repository.NewTickerFollowerRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
 
-	followerUseCase := usecase.NewFollowerUseCase(followerRepo, userRepo, timeout)
+	timelineUseCase := usecase.NewTimelineUseCase(timelineRepo, postRepo, followerRepo, tickerFollowerRepo, timeout)
+	notificationUseCase := usecase.NewNotificationUseCase(notificationRepo, userRepo, timeout)
+	followerUseCase := usecase.NewFollowerUseCase(followerRepo, userRepo, timelineUseCase, notificationUseCase, timeout)
 
 	followerController := &controller.FollowerController{
 		FollowerUseCase: followerUseCase,
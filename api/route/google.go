@@ -13,8 +13,10 @@ import (
 
 func NewGoogleRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, r *mux.Router) {
 	ur := repository.NewUserRepository(db)
+	sr := repository.NewSessionRepository(db)
+	tfr := repository.NewTwoFactorRepository(db)
 	gc := &controller.GoogleController{
-		GoogleUseCase: usecase.NewGoogleUseCase(ur, timeout),
+		GoogleUseCase: usecase.NewGoogleUseCase(ur, sr, tfr, timeout),
 		Env:           env,
 	}
 
@@ -5,16 +5,22 @@ import (
 
 	"github.com/Pro100-Almaz/trading-chat/api/controller"
 	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/internal/email"
 	"github.com/Pro100-Almaz/trading-chat/repository"
 	"github.com/Pro100-Almaz/trading-chat/usecase"
 	"github.com/gorilla/mux"
 	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
 )
 
-func NewLoginRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, r *mux.Router) {
+func NewLoginRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, redisClient *redis.Client, r *mux.Router) {
 	ur := repository.NewUserRepository(db)
+	sr := repository.NewSessionRepository(db)
+	tfr := repository.NewTwoFactorRepository(db)
+	lar := repository.NewLoginAttemptRedisRepository(redisClient)
+	es := email.NewEmailService(env)
 	lc := &controller.LoginController{
-		LoginUseCase: usecase.NewLoginUseCase(ur, timeout),
+		LoginUseCase: usecase.NewLoginUseCase(ur, sr, tfr, lar, es, timeout),
 		Env:          env,
 	}
 
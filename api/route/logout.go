@@ -13,8 +13,9 @@ import (
 
 func NewLogoutRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, r *mux.Router) {
 	tbr := repository.NewTokenBlacklistRepository(db)
+	sr := repository.NewSessionRepository(db)
 	lc := &controller.LogoutController{
-		LogoutUseCase: usecase.NewLogoutUseCase(tbr, timeout),
+		LogoutUseCase: usecase.NewLogoutUseCase(tbr, sr, timeout),
 	}
 
 	r.HandleFunc("/logout", lc.Logout).Methods("POST")
@@ -0,0 +1,30 @@
+package route
+
+import (
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/api/controller"
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+	"github.com/Pro100-Almaz/trading-chat/usecase"
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+)
+
+func NewNotificationRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, r *mux.Router) {
+	notificationRepo := repository.NewNotificationRepository(db)
+	userRepo := repository.NewUserRepository(db)
+
+	notificationUseCase := usecase.NewNotificationUseCase(notificationRepo, userRepo, timeout)
+
+	notificationController := &controller.NotificationController{
+		NotificationUseCase: notificationUseCase,
+		Env:                 env,
+	}
+
+	notificationsGroup := r.PathPrefix("/notifications").Subrouter()
+	notificationsGroup.HandleFunc("", notificationController.GetNotifications).Methods("GET")
+	notificationsGroup.HandleFunc("/unread-count", notificationController.GetUnreadCount).Methods("GET")
+	notificationsGroup.HandleFunc("/read-all", notificationController.MarkAllRead).Methods("POST")
+	notificationsGroup.HandleFunc("/{id}/read", notificationController.MarkRead).Methods("POST")
+}
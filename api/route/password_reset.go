@@ -0,0 +1,28 @@
+package route
+
+import (
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/api/controller"
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/internal/email"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+	"github.com/Pro100-Almaz/trading-chat/usecase"
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+)
+
+func NewPasswordResetRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, redisClient *redis.Client, r *mux.Router) {
+	ur := repository.NewUserRepository(db)
+	prr := repository.NewPasswordResetRepository(db)
+	lar := repository.NewLoginAttemptRedisRepository(redisClient)
+	es := email.NewEmailService(env)
+
+	pc := &controller.PasswordResetController{
+		PasswordResetUseCase: usecase.NewPasswordResetUseCase(ur, prr, lar, es, timeout),
+	}
+
+	r.HandleFunc("/password/forgot", pc.ForgotPassword).Methods("POST")
+	r.HandleFunc("/password/reset", pc.ResetPassword).Methods("POST")
+}
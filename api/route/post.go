@@ -5,6 +5,7 @@ import (
 
 	"github.com/Pro100-Almaz/trading-chat/api/controller"
 	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/internal/marketdata"
 	"github.com/Pro100-Almaz/trading-chat/repository"
 	"github.com/Pro100-Almaz/trading-chat/usecase"
 	"github.com/gorilla/mux"
@@ -12,17 +13,32 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-func NewPostRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, redisClient *redis.Client, r *mux.Router) {
+func NewPostRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, redisClient *redis.Client, marketData marketdata.Provider, r *mux.Router) {
 	postRepo := repository.NewPostRepository(db)
 	userRepo := repository.NewUserRepository(db)
 	likeRepo := repository.NewLikeRepository(db)
 	commentRepo := repository.NewCommentRepository(db)
 	viewsRedisRepo := repository.NewPostViewsRedisRepository(redisClient)
 	viewsDBRepo := repository.NewPostViewsDBRepository(db)
+	feedEventRepo := repository.NewFeedEventRepository(redisClient)
+	followerRepo := repository.NewFollowerRepository(db)
+	timelineRepo := repository.NewTimelineRepository(redisClient)
+	trendingRedisRepo := repository.NewTrendingRedisRepository(redisClient)
+	predictionRepo := repository.NewPredictionRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
 
-	postUseCase := usecase.NewPostUseCase(postRepo, userRepo, likeRepo, commentRepo, viewsRedisRepo, viewsDBRepo, timeout)
-	likeUseCase := usecase.NewLikeUseCase(likeRepo, postRepo, timeout)
-	commentUseCase := usecase.NewCommentUseCase(commentRepo, postRepo, userRepo, timeout)
+	tickerRepo := repository.NewTickerRepository(db)
+	tickerFollowerRepo := repository.NewTickerFollowerRepository(db)
+
+	timelineUseCase := usecase.NewTimelineUseCase(timelineRepo, postRepo, followerRepo, tickerFollowerRepo, timeout)
+	tickerUseCase := usecase.NewTickerUseCase(tickerRepo, tickerFollowerRepo, timeout)
+
+	notificationUseCase := usecase.NewNotificationUseCase(notificationRepo, userRepo, timeout)
+
+	postUseCase := usecase.NewPostUseCase(postRepo, userRepo, likeRepo, commentRepo, viewsRedisRepo, viewsDBRepo, feedEventRepo, timelineUseCase, tickerUseCase, predictionRepo, marketData, notificationUseCase, timeout)
+	likeUseCase := usecase.NewLikeUseCase(likeRepo, postRepo, feedEventRepo, notificationUseCase, timeout)
+	commentUseCase := usecase.NewCommentUseCase(commentRepo, postRepo, userRepo, feedEventRepo, tickerUseCase, notificationUseCase, timeout)
+	trendingUseCase := usecase.NewTrendingUseCase(trendingRedisRepo, tickerRepo, postUseCase, timeout)
 
 	postController := &controller.PostController{
 		PostUseCase: postUseCase,
@@ -39,6 +55,11 @@ func NewPostRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, redis
 		Env:            env,
 	}
 
+	trendingController := &controller.TrendingController{
+		TrendingUseCase: trendingUseCase,
+		Env:             env,
+	}
+
 	// Posts routes
 	postsGroup := r.PathPrefix("/posts").Subrouter()
 	postsGroup.HandleFunc("", postController.GetGlobalFeed).Methods("GET")
@@ -46,8 +67,11 @@ func NewPostRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, redis
 	postsGroup.HandleFunc("/following", postController.GetFollowingFeed).Methods("GET")
 	postsGroup.HandleFunc("/user/{id}", postController.GetUserPosts).Methods("GET")
 	postsGroup.HandleFunc("/{id}", postController.GetPost).Methods("GET")
+	postsGroup.HandleFunc("/{id}", postController.UpdatePost).Methods("PUT")
 	postsGroup.HandleFunc("/{id}", postController.DeletePost).Methods("DELETE")
+	postsGroup.HandleFunc("/{id}/revisions", postController.GetPostRevisions).Methods("GET")
 	postsGroup.HandleFunc("/views/batch", postController.TrackBatchViews).Methods("POST")
+	postsGroup.HandleFunc("/{id}/views", postController.GetPostViews).Methods("GET")
 
 	// Likes routes
 	postsGroup.HandleFunc("/{id}/like", likeController.LikePost).Methods("POST")
@@ -57,7 +81,17 @@ func NewPostRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, redis
 	postsGroup.HandleFunc("/{id}/comments", commentController.GetComments).Methods("GET")
 	postsGroup.HandleFunc("/{id}/comments", commentController.CreateComment).Methods("POST")
 
-	// Delete comment route (under /comments prefix)
+	// Ticker feed route
+	r.HandleFunc("/tickers/{symbol}/posts", postController.GetTickerPosts).Methods("GET")
+
+	// Trending routes
+	r.HandleFunc("/trending/tickers", trendingController.GetTrendingTickers).Methods("GET")
+	r.HandleFunc("/trending/posts", trendingController.GetTrendingPosts).Methods("GET")
+
+	// Comment routes (under /comments prefix)
 	commentsGroup := r.PathPrefix("/comments").Subrouter()
+	commentsGroup.HandleFunc("/{id}", commentController.UpdateComment).Methods("PUT")
 	commentsGroup.HandleFunc("/{id}", commentController.DeleteComment).Methods("DELETE")
+	commentsGroup.HandleFunc("/{id}/revisions", commentController.GetCommentRevisions).Methods("GET")
+	commentsGroup.HandleFunc("/{id}/replies", commentController.GetReplies).Methods("GET")
 }
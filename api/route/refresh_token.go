@@ -13,8 +13,9 @@ import (
 
 func NewRefreshTokenRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, r *mux.Router) {
 	ur := repository.NewUserRepository(db)
+	sr := repository.NewSessionRepository(db)
 	rtc := &controller.RefreshTokenController{
-		RefreshTokenUseCase: usecase.NewRefreshTokenUseCase(ur, timeout),
+		RefreshTokenUseCase: usecase.NewRefreshTokenUseCase(ur, sr, timeout),
 		Env:                 env,
 	}
 
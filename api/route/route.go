@@ -5,6 +5,7 @@ import (
 
 	"github.com/Pro100-Almaz/trading-chat/api/middleware"
 	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/internal/marketdata"
 	"github.com/Pro100-Almaz/trading-chat/repository"
 
 	"github.com/gorilla/mux"
@@ -12,27 +13,42 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-func Setup(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, redisClient *redis.Client, r *mux.Router) {
+func Setup(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, redisClient *redis.Client, marketData marketdata.Provider, r *mux.Router) {
 	public := r.PathPrefix("/api").Subrouter()
 	protectedRouter := r.PathPrefix("/api").Subrouter()
+	adminRouter := r.PathPrefix("/api/admin").Subrouter()
 
-	// Initialize token blacklist repository
+	// Initialize token blacklist, session and stream ticket repositories
 	tokenBlacklistRepo := repository.NewTokenBlacklistRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	streamTicketRepo := repository.NewStreamTicketRepository(redisClient)
 
 	// Middleware to verify AccessToken
 	// pass env to middleware
 	public.Use(middleware.LoggerMiddleware)
-	protectedRouter.Use(middleware.JwtAuthMiddleware(env.AccessTokenSecret, tokenBlacklistRepo))
+	protectedRouter.Use(middleware.JwtAuthMiddleware(env.AccessTokenSecret, tokenBlacklistRepo, sessionRepo, streamTicketRepo))
 	protectedRouter.Use(middleware.LoggerMiddleware)
+	adminRouter.Use(middleware.AdminKeyMiddleware(env.AdminApiKey))
+	adminRouter.Use(middleware.LoggerMiddleware)
 
 	NewEmojiRouter(public)
 	NewGoogleRouter(env, timeout, db, public)
-	NewSignupRouter(env, timeout, db, public)
-	NewLoginRouter(env, timeout, db, public)
+	NewSignupRouter(env, timeout, db, redisClient, public)
+	NewLoginRouter(env, timeout, db, redisClient, public)
 	NewRefreshTokenRouter(env, timeout, db, public)
 	NewLogoutRouter(env, timeout, db, protectedRouter)
+	NewSessionRouter(env, timeout, db, protectedRouter)
+	NewStreamTicketRouter(timeout, redisClient, protectedRouter)
+	NewTwoFactorRouter(env, timeout, db, redisClient, public, protectedRouter)
 	NewUserRouter(env, timeout, db, protectedRouter)
-	NewVerificationRouter(env, timeout, db, public)
-	NewPostRouter(env, timeout, db, redisClient, protectedRouter)
-	NewFollowerRouter(env, timeout, db, protectedRouter)
+	NewVerificationRouter(env, timeout, db, redisClient, public)
+	NewPasswordResetRouter(env, timeout, db, redisClient, public)
+	NewPostRouter(env, timeout, db, redisClient, marketData, protectedRouter)
+	NewFollowerRouter(env, timeout, db, redisClient, protectedRouter)
+	NewChatRouter(env, timeout, db, redisClient, protectedRouter)
+	NewConversationRouter(env, timeout, db, protectedRouter)
+	NewNotificationRouter(env, timeout, db, protectedRouter)
+	NewWatchlistRouter(env, timeout, db, protectedRouter)
+	NewFeedStreamRouter(env, timeout, db, redisClient, protectedRouter)
+	NewTickerRouter(env, timeout, db, redisClient, protectedRouter, adminRouter)
 }
@@ -0,0 +1,26 @@
+package route
+
+import (
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/api/controller"
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+	"github.com/Pro100-Almaz/trading-chat/usecase"
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+)
+
+func NewSessionRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, r *mux.Router) {
+	sessionRepo := repository.NewSessionRepository(db)
+
+	sessionController := &controller.SessionController{
+		SessionUseCase: usecase.NewSessionUseCase(sessionRepo, timeout),
+		Env:            env,
+	}
+
+	sessionsGroup := r.PathPrefix("/sessions").Subrouter()
+	sessionsGroup.HandleFunc("", sessionController.GetSessions).Methods("GET")
+	sessionsGroup.HandleFunc("/others", sessionController.RevokeOtherSessions).Methods("DELETE")
+	sessionsGroup.HandleFunc("/{id}", sessionController.RevokeSession).Methods("DELETE")
+}
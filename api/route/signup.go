@@ -10,14 +10,16 @@ import (
 	"github.com/Pro100-Almaz/trading-chat/usecase"
 	"github.com/gorilla/mux"
 	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
 )
 
-func NewSignupRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, r *mux.Router) {
+func NewSignupRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, redisClient *redis.Client, r *mux.Router) {
 	ur := repository.NewUserRepository(db)
 	vr := repository.NewVerificationRepository(db)
+	lar := repository.NewLoginAttemptRedisRepository(redisClient)
 	es := email.NewEmailService(env)
 
-	vu := usecase.NewVerificationUseCase(ur, vr, es, timeout)
+	vu := usecase.NewVerificationUseCase(ur, vr, lar, es, timeout)
 
 	sc := controller.SignupController{
 		SignupUseCase: usecase.NewSignupUseCase(ur, vu, timeout),
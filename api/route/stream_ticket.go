@@ -0,0 +1,19 @@
+package route
+
+import (
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/api/controller"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+	"github.com/Pro100-Almaz/trading-chat/usecase"
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+)
+
+func NewStreamTicketRouter(timeout time.Duration, redisClient *redis.Client, r *mux.Router) {
+	streamTicketController := &controller.StreamTicketController{
+		StreamTicketUseCase: usecase.NewStreamTicketUseCase(repository.NewStreamTicketRepository(redisClient), timeout),
+	}
+
+	r.HandleFunc("/stream-ticket", streamTicketController.IssueTicket).Methods("POST")
+}
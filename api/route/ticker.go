@@ -0,0 +1,41 @@
+package route
+
+import (
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/api/controller"
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+	"github.com/Pro100-Almaz/trading-chat/usecase"
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+)
+
+func NewTickerRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, redisClient *redis.Client, r *mux.Router, admin *mux.Router) {
+	tickerRepo := repository.NewTickerRepository(db)
+	tickerFollowerRepo := repository.NewTickerFollowerRepository(db)
+	postRepo := repository.NewPostRepository(db)
+	followerRepo := repository.NewFollowerRepository(db)
+	timelineRepo := repository.NewTimelineRepository(redisClient)
+
+	timelineUseCase := usecase.NewTimelineUseCase(timelineRepo, postRepo, followerRepo, tickerFollowerRepo, timeout)
+	tickerUseCase := usecase.NewTickerUseCase(tickerRepo, tickerFollowerRepo, timeout)
+	tickerFollowerUseCase := usecase.NewTickerFollowerUseCase(tickerFollowerRepo, tickerUseCase, timelineUseCase, timeout)
+
+	tickerController := &controller.TickerController{
+		TickerUseCase:         tickerUseCase,
+		TickerFollowerUseCase: tickerFollowerUseCase,
+		Env:                   env,
+	}
+
+	r.HandleFunc("/tickers", tickerController.SearchTickers).Methods("GET")
+	r.HandleFunc("/tickers/{symbol}", tickerController.GetTicker).Methods("GET")
+	r.HandleFunc("/tickers/{symbol}/sentiment", tickerController.GetTickerSentiment).Methods("GET")
+	r.HandleFunc("/tickers/{symbol}/sentiment/series", tickerController.GetTickerSentimentSeries).Methods("GET")
+	r.HandleFunc("/tickers/{symbol}/follow", tickerController.FollowTicker).Methods("POST")
+	r.HandleFunc("/tickers/{symbol}/follow", tickerController.UnfollowTicker).Methods("DELETE")
+	r.HandleFunc("/tickers/{symbol}/followers", tickerController.GetTickerFollowers).Methods("GET")
+
+	admin.HandleFunc("/tickers/import", tickerController.ImportTickers).Methods("POST")
+}
@@ -0,0 +1,34 @@
+package route
+
+import (
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/api/controller"
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/internal/email"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+	"github.com/Pro100-Almaz/trading-chat/usecase"
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+)
+
+func NewTwoFactorRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, redisClient *redis.Client, public *mux.Router, protected *mux.Router) {
+	userRepo := repository.NewUserRepository(db)
+	twoFactorRepo := repository.NewTwoFactorRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	loginAttemptRepo := repository.NewLoginAttemptRedisRepository(redisClient)
+	emailService := email.NewEmailService(env)
+
+	twoFactorController := &controller.TwoFactorController{
+		TwoFactorUseCase: usecase.NewTwoFactorUseCase(userRepo, twoFactorRepo, sessionRepo, loginAttemptRepo, emailService, timeout),
+		Env:              env,
+	}
+
+	public.HandleFunc("/login/2fa", twoFactorController.VerifyLogin).Methods("POST")
+
+	twoFactorGroup := protected.PathPrefix("/2fa").Subrouter()
+	twoFactorGroup.HandleFunc("/enroll", twoFactorController.Enroll).Methods("POST")
+	twoFactorGroup.HandleFunc("/confirm", twoFactorController.Confirm).Methods("POST")
+	twoFactorGroup.HandleFunc("/disable", twoFactorController.Disable).Methods("POST")
+}
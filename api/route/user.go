@@ -13,8 +13,9 @@ import (
 
 func NewUserRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, r *mux.Router) {
 	ur := repository.NewUserRepository(db)
+	pr := repository.NewPredictionRepository(db)
 	uc := &controller.UserController{
-		UserUseCase: usecase.NewUserUseCase(ur, timeout),
+		UserUseCase: usecase.NewUserUseCase(ur, pr, timeout),
 		Env:         env,
 	}
 
@@ -24,4 +25,6 @@ func NewUserRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, r *mu
 	group.HandleFunc("", uc.GetUserById).Methods("GET")
 	group.HandleFunc("", uc.UpdateUser).Methods("PUT")
 	group.HandleFunc("", uc.DeleteUser).Methods("DELETE")
+
+	r.HandleFunc("/users/{id}/prediction-record", uc.GetPredictionRecord).Methods("GET")
 }
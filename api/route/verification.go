@@ -5,6 +5,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
 	"github.com/Pro100-Almaz/trading-chat/api/controller"
 	"github.com/Pro100-Almaz/trading-chat/bootstrap"
 	"github.com/Pro100-Almaz/trading-chat/internal/email"
@@ -12,13 +13,14 @@ import (
 	"github.com/Pro100-Almaz/trading-chat/usecase"
 )
 
-func NewVerificationRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, r *mux.Router) {
+func NewVerificationRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, redisClient *redis.Client, r *mux.Router) {
 	ur := repository.NewUserRepository(db)
 	vr := repository.NewVerificationRepository(db)
+	lar := repository.NewLoginAttemptRedisRepository(redisClient)
 	es := email.NewEmailService(env)
 
 	vc := &controller.VerificationController{
-		VerificationUseCase: usecase.NewVerificationUseCase(ur, vr, es, timeout),
+		VerificationUseCase: usecase.NewVerificationUseCase(ur, vr, lar, es, timeout),
 	}
 
 	r.HandleFunc("/verify-email", vc.VerifyEmail).Methods("POST")
@@ -0,0 +1,38 @@
+package route
+
+import (
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/api/controller"
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+	"github.com/Pro100-Almaz/trading-chat/usecase"
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+)
+
+func NewWatchlistRouter(env *bootstrap.Env, timeout time.Duration, db *sqlx.DB, r *mux.Router) {
+	watchlistRepo := repository.NewWatchlistRepository(db)
+	alertRuleRepo := repository.NewAlertRuleRepository(db)
+	tickerRepo := repository.NewTickerRepository(db)
+	tickerFollowerRepo := repository.NewTickerFollowerRepository(db)
+
+	tickerUseCase := usecase.NewTickerUseCase(tickerRepo, tickerFollowerRepo, timeout)
+	watchlistUseCase := usecase.NewWatchlistUseCase(watchlistRepo, alertRuleRepo, tickerUseCase, timeout)
+
+	watchlistController := &controller.WatchlistController{
+		WatchlistUseCase: watchlistUseCase,
+		Env:              env,
+	}
+
+	watchlistsGroup := r.PathPrefix("/watchlists").Subrouter()
+	watchlistsGroup.HandleFunc("", watchlistController.GetWatchlists).Methods("GET")
+	watchlistsGroup.HandleFunc("", watchlistController.CreateWatchlist).Methods("POST")
+	watchlistsGroup.HandleFunc("/{id}", watchlistController.GetWatchlist).Methods("GET")
+	watchlistsGroup.HandleFunc("/{id}", watchlistController.UpdateWatchlist).Methods("PUT")
+	watchlistsGroup.HandleFunc("/{id}", watchlistController.DeleteWatchlist).Methods("DELETE")
+	watchlistsGroup.HandleFunc("/{id}/symbols/{symbol}", watchlistController.AddSymbol).Methods("POST")
+	watchlistsGroup.HandleFunc("/{id}/symbols/{symbol}", watchlistController.RemoveSymbol).Methods("DELETE")
+	watchlistsGroup.HandleFunc("/{id}/alerts", watchlistController.CreateAlertRule).Methods("POST")
+	watchlistsGroup.HandleFunc("/{id}/alerts/{ruleId}", watchlistController.DeleteAlertRule).Methods("DELETE")
+}
@@ -8,19 +8,19 @@ import (
 )
 
 type Env struct {
-	AppEnv                 string `mapstructure:"APP_ENV"`
-	ServerAddress          string `mapstructure:"SERVER_ADDRESS"`
-	ContextTimeout         int    `mapstructure:"CONTEXT_TIMEOUT"`
-	DBHost                 string `mapstructure:"DB_HOST"`
-	DBPort                 string `mapstructure:"DB_PORT"`
-	DBUser                 string `mapstructure:"DB_USER"`
-	DBPass                 string `mapstructure:"DB_PASS"`
-	DBName                 string `mapstructure:"DB_NAME"`
+	AppEnv         string `mapstructure:"APP_ENV"`
+	ServerAddress  string `mapstructure:"SERVER_ADDRESS"`
+	ContextTimeout int    `mapstructure:"CONTEXT_TIMEOUT"`
+	DBHost         string `mapstructure:"DB_HOST"`
+	DBPort         string `mapstructure:"DB_PORT"`
+	DBUser         string `mapstructure:"DB_USER"`
+	DBPass         string `mapstructure:"DB_PASS"`
+	DBName         string `mapstructure:"DB_NAME"`
 	// Redis Configuration
-	RedisHost     string `mapstructure:"REDIS_HOST"`
-	RedisPort     string `mapstructure:"REDIS_PORT"`
-	RedisPassword string `mapstructure:"REDIS_PASSWORD"`
-	RedisDB       int    `mapstructure:"REDIS_DB"`
+	RedisHost              string `mapstructure:"REDIS_HOST"`
+	RedisPort              string `mapstructure:"REDIS_PORT"`
+	RedisPassword          string `mapstructure:"REDIS_PASSWORD"`
+	RedisDB                int    `mapstructure:"REDIS_DB"`
 	AccessTokenExpiryHour  int    `mapstructure:"ACCESS_TOKEN_EXPIRY_HOUR"`
 	RefreshTokenExpiryHour int    `mapstructure:"REFRESH_TOKEN_EXPIRY_HOUR"`
 	AccessTokenSecret      string `mapstructure:"ACCESS_TOKEN_SECRET"`
@@ -33,6 +33,12 @@ type Env struct {
 	SMTPUser     string `mapstructure:"SMTP_USER"`
 	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
 	SMTPFrom     string `mapstructure:"SMTP_FROM"`
+	// Admin API key, admin endpoints are disabled when empty
+	AdminApiKey string `mapstructure:"ADMIN_API_KEY"`
+	// Prices predictions are resolved against, they stay pending when empty
+	MarketDataCSV string `mapstructure:"MARKET_DATA_CSV"`
+	// RFC 3339 time in the market data that replay starts from, prices are served as recorded when empty
+	MarketDataReplayFrom string `mapstructure:"MARKET_DATA_REPLAY_FROM"`
 }
 
 func bindEnvs() {
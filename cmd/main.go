@@ -9,7 +9,9 @@ import (
 
 	"github.com/Pro100-Almaz/trading-chat/api/route"
 	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/internal/marketdata"
 	"github.com/Pro100-Almaz/trading-chat/repository"
+	"github.com/Pro100-Almaz/trading-chat/usecase"
 	"github.com/Pro100-Almaz/trading-chat/utils"
 	"github.com/Pro100-Almaz/trading-chat/worker"
 
@@ -43,6 +45,14 @@ func main() {
 
 	timeout := time.Duration(env.ContextTimeout) * time.Second
 
+	// Seed the ticker registry with the bundled symbols it doesn't have yet
+	tickerUseCase := usecase.NewTickerUseCase(repository.NewTickerRepository(db), repository.NewTickerFollowerRepository(db), timeout)
+	if seeded, err := tickerUseCase.Seed(context.Background()); err != nil {
+		log.Error("Failed to seed tickers: ", err)
+	} else if seeded > 0 {
+		log.Infof("Seeded %d tickers", seeded)
+	}
+
 	// Start post views worker
 	viewsRedisRepo := repository.NewPostViewsRedisRepository(redisClient)
 	viewsDBRepo := repository.NewPostViewsDBRepository(db)
@@ -50,6 +60,37 @@ func main() {
 	go viewsWorker.Start()
 	defer viewsWorker.Stop()
 
+	// Start trending worker
+	trendingWorker := worker.NewTrendingWorker(
+		repository.NewTrendingDBRepository(db),
+		repository.NewTrendingRedisRepository(redisClient),
+		time.Minute,
+	)
+	go trendingWorker.Start()
+	defer trendingWorker.Stop()
+
+	marketData := marketdata.NewProvider(env, redisClient)
+
+	// Start prediction worker, when there is market data to resolve against
+	if env.MarketDataCSV != "" {
+		predictionWorker := worker.NewPredictionWorker(repository.NewPredictionRepository(db), marketData, time.Minute)
+		go predictionWorker.Start()
+		defer predictionWorker.Stop()
+	} else {
+		log.Info("MARKET_DATA_CSV is not set, predictions will not be resolved")
+	}
+
+	// Start alert worker, price alerts stay quiet without market data but sentiment ones still fire
+	alertWorker := worker.NewAlertWorker(
+		repository.NewAlertRuleRepository(db),
+		repository.NewTickerRepository(db),
+		repository.NewNotificationRepository(db),
+		marketData,
+		time.Minute,
+	)
+	go alertWorker.Start()
+	defer alertWorker.Stop()
+
 	r := mux.NewRouter()
 
 	// Swagger documentation route
@@ -58,7 +99,7 @@ func main() {
 		httpSwagger.DeepLinking(true),
 	))
 
-	route.Setup(env, timeout, db, redisClient, r)
+	route.Setup(env, timeout, db, redisClient, marketData, r)
 
 	srv := &http.Server{
 		Addr:         env.ServerAddress,
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type ChatMessage struct {
+	Id        int       `json:"id" db:"id"`
+	Ticker    string    `json:"ticker" db:"ticker"`
+	UserId    int       `json:"user_id" db:"user_id"`
+	Body      string    `json:"body" db:"body"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type ChatMessageResponse struct {
+	Id        int       `json:"id"`
+	Ticker    string    `json:"ticker"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	Author    Author    `json:"author"`
+}
+
+// SendChatMessageRequest is the frame a client writes to the chat WebSocket
+type SendChatMessageRequest struct {
+	Body string `json:"body" example:"Anyone watching the open?"`
+}
+
+type ChatUseCase interface {
+	GetHistory(ctx context.Context, ticker string, limit, offset int) (*PaginatedResponse, error)
+	SendMessage(ctx context.Context, userId int, ticker string, request *SendChatMessageRequest) (*ChatMessageResponse, error)
+	// Subscribe streams messages published to the ticker room until ctx is done
+	Subscribe(ctx context.Context, ticker string) (<-chan *ChatMessageResponse, error)
+}
@@ -2,30 +2,84 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+var (
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrReplyTooDeep    = errors.New("replies can't be nested any deeper")
+)
+
+// Shown instead of the body of a deleted comment that still has replies
+const DeletedCommentBody = "[deleted]"
+
+// Comment is a comment on a post, or a reply to another comment of the post
+// when ParentCommentId is set. Deleting a comment with replies only blanks it
+// and sets DeletedAt so that its replies keep their place in the thread.
 type Comment struct {
-	Id        int       `json:"id" db:"id"`
-	UserId    int       `json:"user_id" db:"user_id"`
-	PostId    int       `json:"post_id" db:"post_id"`
-	Body      string    `json:"body" db:"body"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	Id              int    `json:"id" db:"id"`
+	UserId          int    `json:"user_id" db:"user_id"`
+	PostId          int    `json:"post_id" db:"post_id"`
+	ParentCommentId *int   `json:"parent_comment_id" db:"parent_comment_id"`
+	Body            string `json:"body" db:"body"`
+	// 0 for comments on the post, one more per level of replies
+	Depth     int        `json:"depth" db:"depth"`
+	DeletedAt *time.Time `json:"deleted_at" db:"deleted_at"`
+	EditedAt  *time.Time `json:"edited_at" db:"edited_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	// Parsed from the body, stored alongside the comment
+	Entities []*Entity `json:"-" db:"-"`
 }
 
 type CommentResponse struct {
-	Id        int       `json:"id"`
-	Body      string    `json:"body"`
+	Id              int    `json:"id"`
+	ParentCommentId *int   `json:"parent_comment_id"`
+	Body            string `json:"body"`
+	// A deleted comment kept for its replies, with a placeholder body and no author
+	IsDeleted bool      `json:"is_deleted"`
 	CreatedAt time.Time `json:"created_at"`
-	Author    Author    `json:"author"`
+	// Set once the comment was edited, its earlier versions are in the history
+	EditedAt *time.Time `json:"edited_at"`
+	Author   Author     `json:"author"`
+	Entities []*Entity  `json:"entities"`
+	// Direct replies to the comment
+	RepliesCount int `json:"replies_count"`
+	// The first few replies, oldest first, when listed with the comments of a post
+	Replies []*CommentResponse `json:"replies,omitempty"`
 }
 
 type CreateCommentRequest struct {
 	Body string `json:"body" example:"Great analysis!"`
+	// Comment of the same post to reply to
+	ParentCommentId *int `json:"parent_comment_id,omitempty"`
+}
+
+type UpdateCommentRequest struct {
+	Body string `json:"body" example:"Great analysis, though the target looks high"`
+}
+
+// CommentRevision is an earlier version of an edited comment
+type CommentRevision struct {
+	Id        int    `json:"-" db:"id"`
+	CommentId int    `json:"-" db:"comment_id"`
+	Body      string `json:"body" db:"body"`
+	// When this version was written, the comment's creation or an earlier edit
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	// When it was edited away
+	ReplacedAt time.Time `json:"replaced_at" db:"replaced_at"`
 }
 
 type CommentUseCase interface {
-	GetComments(ctx context.Context, postId, limit, offset int) (*PaginatedResponse, error)
+	// GetComments lists the top-level comments of a post, newest first, each
+	// with its reply count and first replies
+	GetComments(ctx context.Context, postId int, page PaginationParams) (*PaginatedResponse, error)
+	// GetReplies lists the direct replies to a comment, oldest first
+	GetReplies(ctx context.Context, commentId int, page PaginationParams) (*PaginatedResponse, error)
 	CreateComment(ctx context.Context, userId, postId int, request *CreateCommentRequest) (*CommentResponse, error)
+	// UpdateComment edits the body of a comment of the user, keeping the previous version
+	UpdateComment(ctx context.Context, userId, commentId int, request *UpdateCommentRequest) (*CommentResponse, error)
+	// GetCommentRevisions lists the earlier versions of a comment, oldest first
+	GetCommentRevisions(ctx context.Context, commentId int) ([]*CommentRevision, error)
 	DeleteComment(ctx context.Context, userId, commentId int) error
 }
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type Conversation struct {
+	Id            int        `json:"id" db:"id"`
+	IsGroup       bool       `json:"is_group" db:"is_group"`
+	CreatedBy     *int       `json:"created_by" db:"created_by"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	LastMessageAt *time.Time `json:"last_message_at" db:"last_message_at"`
+	// The members of a one-to-one conversation, lower id first. Nil for groups.
+	DirectUserLow  *int `json:"-" db:"direct_user_low"`
+	DirectUserHigh *int `json:"-" db:"direct_user_high"`
+}
+
+type ConversationMember struct {
+	ConversationId    int       `json:"conversation_id" db:"conversation_id"`
+	UserId            int       `json:"user_id" db:"user_id"`
+	LastReadMessageId int       `json:"last_read_message_id" db:"last_read_message_id"`
+	JoinedAt          time.Time `json:"joined_at" db:"joined_at"`
+}
+
+// InboxEntry is a conversation as seen by one of its members
+type InboxEntry struct {
+	Conversation
+	UnreadCount int `db:"unread_count"`
+}
+
+type Message struct {
+	Id             int       `json:"id" db:"id"`
+	ConversationId int       `json:"conversation_id" db:"conversation_id"`
+	UserId         int       `json:"user_id" db:"user_id"`
+	Body           string    `json:"body" db:"body"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+type MessageResponse struct {
+	Id             int       `json:"id"`
+	ConversationId int       `json:"conversation_id"`
+	Body           string    `json:"body"`
+	CreatedAt      time.Time `json:"created_at"`
+	Author         Author    `json:"author"`
+}
+
+type ConversationResponse struct {
+	Id            int              `json:"id"`
+	IsGroup       bool             `json:"is_group"`
+	Members       []Author         `json:"members"`
+	LastMessage   *MessageResponse `json:"last_message"`
+	UnreadCount   int              `json:"unread_count"`
+	CreatedAt     time.Time        `json:"created_at"`
+	LastMessageAt *time.Time       `json:"last_message_at"`
+}
+
+type StartConversationRequest struct {
+	UserIds []int `json:"user_ids" example:"2"`
+}
+
+type SendMessageRequest struct {
+	Body string `json:"body" example:"Saw your call on AAPL, what's your target?"`
+}
+
+type MarkReadRequest struct {
+	// Last message the client has displayed; zero marks the whole conversation read
+	MessageId int `json:"message_id,omitempty" example:"42"`
+}
+
+type ConversationUseCase interface {
+	StartConversation(ctx context.Context, userId int, request *StartConversationRequest) (*ConversationResponse, error)
+	GetInbox(ctx context.Context, userId, limit, offset int) (*PaginatedResponse, error)
+	GetMessages(ctx context.Context, userId, conversationId, limit, offset int) (*PaginatedResponse, error)
+	SendMessage(ctx context.Context, userId, conversationId int, request *SendMessageRequest) (*MessageResponse, error)
+	MarkAsRead(ctx context.Context, userId, conversationId int, request *MarkReadRequest) error
+}
@@ -0,0 +1,20 @@
+package domain
+
+const (
+	EntityCashtag = "cashtag"
+	EntityMention = "mention"
+	EntityHashtag = "hashtag"
+)
+
+// Entity is a cashtag, mention or hashtag in a post or comment body. Offsets
+// count characters (Unicode code points), End is exclusive and the span
+// includes the leading $, @ or #.
+type Entity struct {
+	Type  string `json:"type" db:"type" example:"cashtag"`
+	Start int    `json:"start" db:"start_offset"`
+	End   int    `json:"end" db:"end_offset"`
+	// Ticker symbol, lower-case hashtag or the username as written
+	Text string `json:"text" db:"text" example:"AAPL"`
+	// User a mention links to
+	UserId *int `json:"user_id,omitempty" db:"user_id"`
+}
@@ -22,4 +22,5 @@ var (
 	ErrInvalidVerificationCode   = errors.New("invalid or expired verification code")
 	ErrUserAlreadyVerified       = errors.New("user is already verified")
 	ErrFailedToSendEmail         = errors.New("failed to send verification email")
+	ErrInvalidCursor             = errors.New("invalid cursor")
 )
@@ -0,0 +1,43 @@
+package domain
+
+import "context"
+
+// Feed event types pushed to live feed subscribers
+const (
+	FeedEventPostCreated    = "post_created"
+	FeedEventPostEdited     = "post_edited"
+	FeedEventPostDeleted    = "post_deleted"
+	FeedEventLikesChanged   = "likes_changed"
+	FeedEventCommentCreated = "comment_created"
+	FeedEventCommentEdited  = "comment_edited"
+)
+
+// Feeds a live stream can follow
+const (
+	FeedGlobal    = "global"
+	FeedFollowing = "following"
+	FeedTicker    = "ticker"
+)
+
+type FeedEvent struct {
+	Type   string `json:"type"`
+	PostId int    `json:"post_id"`
+	Ticker string `json:"ticker"`
+	// Every ticker the post is listed under, the primary one included
+	Tickers       []string         `json:"tickers"`
+	PostAuthorId  int              `json:"post_author_id"`
+	Post          *PostResponse    `json:"post,omitempty"`
+	LikesCount    *int             `json:"likes_count,omitempty"`
+	Comment       *CommentResponse `json:"comment,omitempty"`
+	CommentsCount *int             `json:"comments_count,omitempty"`
+}
+
+type FeedFilter struct {
+	Feed   string
+	Ticker string
+}
+
+type FeedStreamUseCase interface {
+	// Subscribe streams feed events matching the filter until ctx is done
+	Subscribe(ctx context.Context, userId int, filter FeedFilter) (<-chan *FeedEvent, error)
+}
@@ -12,6 +12,14 @@ type Follower struct {
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 }
 
+// FollowListEntry is a user in a followers or following list, along with the
+// follow the list is ordered by
+type FollowListEntry struct {
+	User
+	FollowId   int       `db:"follow_id"`
+	FollowedAt time.Time `db:"followed_at"`
+}
+
 type FollowUserResponse struct {
 	Id          int    `json:"id"`
 	Name        string `json:"name"`
@@ -21,7 +29,7 @@ type FollowUserResponse struct {
 type FollowerUseCase interface {
 	Follow(ctx context.Context, followerId, followingId int) error
 	Unfollow(ctx context.Context, followerId, followingId int) error
-	GetFollowers(ctx context.Context, userId, limit, offset int) (*PaginatedResponse, error)
-	GetFollowing(ctx context.Context, userId, limit, offset int) (*PaginatedResponse, error)
+	GetFollowers(ctx context.Context, userId int, page PaginationParams) (*PaginatedResponse, error)
+	GetFollowing(ctx context.Context, userId int, page PaginationParams) (*PaginatedResponse, error)
 	IsFollowing(ctx context.Context, followerId, followingId int) (bool, error)
 }
@@ -21,7 +21,7 @@ type GoogleUser struct {
 }
 
 type GoogleUseCase interface {
-	GoogleLogin(ctx context.Context, data []byte, env *bootstrap.Env) (accessToken string, refreshToken string, err error)
+	GoogleLogin(ctx context.Context, data []byte, client SessionClient, env *bootstrap.Env) (*LoginResponse, error)
 	GetUserDataFromGoogle(googleOauthConfig *oauth2.Config, code, oauthGoogleUrlAPI string) ([]byte, error)
 	GenerateStateOauthCookie(w http.ResponseWriter) string
 }
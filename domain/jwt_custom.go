@@ -4,18 +4,23 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 )
 
+// JwtCustomClaims carries the session the access token belongs to in FamilyId
 type JwtCustomClaims struct {
 	Name     string `json:"name"`
 	ID       int    `json:"id"`
 	Email    string `json:"email"`
 	GoogleId string `json:"google_id"`
+	FamilyId string `json:"fid"`
 	jwt.RegisteredClaims
 }
 
+// JwtCustomRefreshClaims carries the session the refresh token belongs to in
+// FamilyId and the token's own id in the registered jti claim
 type JwtCustomRefreshClaims struct {
 	Name     string `json:"name"`
 	ID       int    `json:"id"`
 	Email    string `json:"email"`
 	GoogleId string `json:"google_id"`
+	FamilyId string `json:"fid"`
 	jwt.RegisteredClaims
 }
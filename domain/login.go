@@ -11,11 +11,16 @@ type LoginRequest struct {
 	Password string `form:"password" binding:"required"`
 }
 
+// LoginResponse carries the tokens of the new session, or a challenge when the
+// user has two-factor authentication on. The challenge is redeemed along with a
+// code at /login/2fa for the tokens.
 type LoginResponse struct {
-	AccessToken  string `json:"accessToken"`
-	RefreshToken string `json:"refreshToken"`
+	AccessToken       string `json:"accessToken,omitempty"`
+	RefreshToken      string `json:"refreshToken,omitempty"`
+	TwoFactorRequired bool   `json:"twoFactorRequired,omitempty"`
+	ChallengeToken    string `json:"challengeToken,omitempty"`
 }
 
 type LoginUseCase interface {
-	Login(ctx context.Context, request LoginRequest, env *bootstrap.Env) (accessToken string, refreshToken string, err error)
+	Login(ctx context.Context, request LoginRequest, client SessionClient, env *bootstrap.Env) (*LoginResponse, error)
 }
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var ErrTooManyAttempts = errors.New("too many failed attempts")
+
+// TooManyAttemptsError is returned while an account or address is backing off
+// after failed attempts, or locked out after too many of them
+type TooManyAttemptsError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	return fmt.Sprintf("%s, try again in %d seconds", ErrTooManyAttempts, e.RetryAfterSeconds())
+}
+
+func (e *TooManyAttemptsError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// RetryAfterSeconds rounds RetryAfter up to whole seconds, as the Retry-After header has it
+func (e *TooManyAttemptsError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
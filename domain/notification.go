@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	NotificationFollow  = "follow"
+	NotificationLike    = "like"
+	NotificationComment = "comment"
+	NotificationMention = "mention"
+	NotificationAlert   = "alert"
+)
+
+// Notification tells a user that another user did something involving them,
+// or that one of their alert rules fired. Those of one type on one post are
+// shown as a group, a user holds one notification per actor in each group.
+// Alerts have no actor and are shown one by one.
+type Notification struct {
+	Id        int    `json:"id" db:"id"`
+	UserId    int    `json:"user_id" db:"user_id"`
+	ActorId   *int   `json:"actor_id" db:"actor_id"`
+	Type      string `json:"type" db:"type"`
+	PostId    *int   `json:"post_id" db:"post_id"`
+	CommentId *int   `json:"comment_id" db:"comment_id"`
+	// Set on alerts
+	Ticker      *string    `json:"ticker" db:"ticker"`
+	AlertRuleId *int       `json:"alert_rule_id" db:"alert_rule_id"`
+	Detail      *string    `json:"detail" db:"detail"`
+	ReadAt      *time.Time `json:"read_at" db:"read_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// NotificationGroup is the notifications of one type on one post, described
+// by the latest of them
+type NotificationGroup struct {
+	// Id of the latest notification
+	Id          int     `db:"id"`
+	Type        string  `db:"type"`
+	PostId      *int    `db:"post_id"`
+	CommentId   *int    `db:"comment_id"`
+	Ticker      *string `db:"ticker"`
+	AlertRuleId *int    `db:"alert_rule_id"`
+	Detail      *string `db:"detail"`
+	// Most recent actors first
+	ActorIds    pq.Int64Array `db:"actor_ids"`
+	ActorsCount int           `db:"actors_count"`
+	Unread      bool          `db:"unread"`
+	CreatedAt   time.Time     `db:"created_at"`
+}
+
+type NotificationResponse struct {
+	// Id of the group, marking it read marks every notification in it
+	Id        int    `json:"id"`
+	Type      string `json:"type" example:"like"`
+	PostId    *int   `json:"post_id,omitempty"`
+	CommentId *int   `json:"comment_id,omitempty"`
+	// Set on alerts
+	Ticker      *string `json:"ticker,omitempty" example:"AAPL"`
+	AlertRuleId *int    `json:"alert_rule_id,omitempty"`
+	Detail      *string `json:"detail,omitempty" example:"AAPL crossed above 200 at 201.35"`
+	// The latest few actors, most recent first
+	Actors []Author `json:"actors"`
+	// Every actor in the group, "X and 5 others" when it is 6
+	ActorsCount int       `json:"actors_count" example:"6"`
+	IsRead      bool      `json:"is_read"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type NotificationsResponse struct {
+	*PaginatedResponse
+	UnreadCount int `json:"unread_count"`
+}
+
+type UnreadCountResponse struct {
+	UnreadCount int `json:"unread_count"`
+}
+
+type NotificationUseCase interface {
+	// Notify stores notifications, leaving out the ones users would get about themselves
+	Notify(ctx context.Context, notifications ...*Notification) error
+	// GetNotifications returns the grouped notifications of a user, newest first
+	GetNotifications(ctx context.Context, userId int, page PaginationParams) (*NotificationsResponse, error)
+	// GetUnreadCount counts the groups with unread notifications
+	GetUnreadCount(ctx context.Context, userId int) (int, error)
+	// MarkRead marks the group of a notification read
+	MarkRead(ctx context.Context, userId, notificationId int) error
+	MarkAllRead(ctx context.Context, userId int) error
+}
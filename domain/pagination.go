@@ -1,24 +1,99 @@
 package domain
 
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
 type PaginationParams struct {
 	Limit  int `json:"limit"`
 	Offset int `json:"offset"`
+	// Cursor continues after the last item of the previous page and takes precedence over Offset
+	Cursor *Cursor `json:"-"`
+}
+
+// Cursor is a position in a list ordered by (created_at, id), newest first
+// unless the list says otherwise
+type Cursor struct {
+	CreatedAt time.Time
+	Id        int
 }
 
 type PaginatedResponse struct {
-	Data    interface{} `json:"data"`
-	Total   int         `json:"total"`
-	Limit   int         `json:"limit"`
-	Offset  int         `json:"offset"`
-	HasMore bool        `json:"has_more"`
+	Data  interface{} `json:"data"`
+	Total *int        `json:"total,omitempty"`
+	Limit int         `json:"limit"`
+	// Offset is only meaningful for offset pagination
+	Offset     int    `json:"offset"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 func NewPaginatedResponse(data interface{}, total, limit, offset int) *PaginatedResponse {
 	return &PaginatedResponse{
 		Data:    data,
-		Total:   total,
+		Total:   &total,
 		Limit:   limit,
 		Offset:  offset,
 		HasMore: offset+limit < total,
 	}
 }
+
+// NewCursorPaginatedResponse builds a keyset paginated page. It has no total,
+// counting every row is what cursor pagination avoids.
+func NewCursorPaginatedResponse(data interface{}, limit int, next *Cursor) *PaginatedResponse {
+	return (&PaginatedResponse{
+		Data:  data,
+		Limit: limit,
+	}).WithNextCursor(next)
+}
+
+// WithNextCursor sets the cursor of the following page, nil on the last page
+func (p *PaginatedResponse) WithNextCursor(next *Cursor) *PaginatedResponse {
+	if next != nil {
+		p.HasMore = true
+		p.NextCursor = next.Encode()
+	}
+	return p
+}
+
+// Encode returns the opaque form of the cursor handed out to clients
+func (c *Cursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d", c.CreatedAt.UnixMicro(), c.Id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a cursor produced by Encode
+func DecodeCursor(encoded string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	rawMicros, rawId, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	micros, err := strconv.ParseInt(rawMicros, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(rawId)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	// Timestamps are stored without a zone and read back as UTC
+	return &Cursor{CreatedAt: time.UnixMicro(micros).UTC(), Id: id}, nil
+}
+
+// Args returns the cursor as query arguments, both NULL without a cursor
+func (c *Cursor) Args() (createdAt *time.Time, id *int) {
+	if c == nil {
+		return nil, nil
+	}
+	return &c.CreatedAt, &c.Id
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{name: "now", cursor: Cursor{CreatedAt: time.Now().UTC().Truncate(time.Microsecond), Id: 42}},
+		{name: "epoch", cursor: Cursor{CreatedAt: time.Unix(0, 0).UTC(), Id: 1}},
+		{name: "before epoch", cursor: Cursor{CreatedAt: time.Date(1969, 7, 20, 20, 17, 0, 0, time.UTC), Id: 7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := DecodeCursor(tt.cursor.Encode())
+			require.NoError(t, err)
+			assert.True(t, tt.cursor.CreatedAt.Equal(decoded.CreatedAt))
+			assert.Equal(t, tt.cursor.Id, decoded.Id)
+		})
+	}
+}
+
+func TestDecodeCursorRejectsMalformed(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{name: "empty", encoded: ""},
+		{name: "not base64", encoded: "not a cursor!"},
+		{name: "padded base64", encoded: base64.URLEncoding.EncodeToString([]byte("1700000000000000:42"))},
+		{name: "no separator", encoded: encode("1700000000000000")},
+		{name: "non-numeric id", encoded: encode("1700000000000000:abc")},
+		{name: "non-numeric time", encoded: encode("yesterday:42")},
+		{name: "trailing garbage", encoded: encode("1700000000000000:42abc")},
+		{name: "missing id", encoded: encode("1700000000000000:")},
+		{name: "id out of range", encoded: encode("1700000000000000:99999999999999999999")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NotPanics(t, func() {
+				cursor, err := DecodeCursor(tt.encoded)
+				assert.ErrorIs(t, err, ErrInvalidCursor)
+				assert.Nil(t, cursor)
+			})
+		})
+	}
+}
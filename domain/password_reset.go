@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+	ErrPasswordTooShort  = errors.New("password must be at least 8 characters")
+)
+
+// PasswordResetToken lets whoever received it by email set a new password,
+// once and before it expires. Only a hash of the token is stored.
+type PasswordResetToken struct {
+	Id        int        `json:"id" db:"id"`
+	UserId    int        `json:"user_id" db:"user_id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required" example:"john@example.com"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required" example:"newpassword123"`
+}
+
+type PasswordResetResponse struct {
+	Message string `json:"message" example:"Password reset successfully"`
+}
+
+type PasswordResetUseCase interface {
+	// ForgotPassword emails a reset token to the user with the email, if there
+	// is one with a password and they didn't ask too often. It says nothing
+	// either way, and takes as long either way, so that it can't tell who has
+	// an account. An address asking too often gets a TooManyAttemptsError.
+	ForgotPassword(ctx context.Context, email, clientIp string) error
+	// ResetPassword redeems a reset token for a new password and logs the user
+	// out everywhere
+	ResetPassword(ctx context.Context, token, password string) error
+}
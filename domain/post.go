@@ -2,28 +2,57 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrInvalidSentiment = errors.New("sentiment must be one of bullish, bearish or neutral")
+)
+
+const (
+	SentimentBullish = "bullish"
+	SentimentBearish = "bearish"
+	SentimentNeutral = "neutral"
 )
 
 type Post struct {
-	Id        int        `json:"id" db:"id"`
-	UserId    int        `json:"user_id" db:"user_id"`
-	Ticker    string     `json:"ticker" db:"ticker"`
-	Body      string     `json:"body" db:"body"`
-	CreatedAt time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at" db:"updated_at"`
+	Id        int     `json:"id" db:"id"`
+	UserId    int     `json:"user_id" db:"user_id"`
+	Ticker    string  `json:"ticker" db:"ticker"`
+	Body      string  `json:"body" db:"body"`
+	Sentiment *string `json:"sentiment" db:"sentiment"`
+	// Ticker followed by the cashtags of the body, the post is listed under each
+	Tickers pq.StringArray `json:"tickers" db:"tickers"`
+	// Price of the ticker when posted, NULL when there was no quote
+	PriceAtPost *float64   `json:"price_at_post" db:"price_at_post"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at" db:"updated_at"`
+	// Parsed from the body, stored alongside the post
+	Entities []*Entity `json:"-" db:"-"`
 }
 
 type PostResponse struct {
-	Id            int       `json:"id"`
-	Ticker        string    `json:"ticker"`
-	Body          string    `json:"body"`
-	CreatedAt     time.Time `json:"created_at"`
-	Author        Author    `json:"author"`
-	LikesCount    int       `json:"likes_count"`
-	CommentsCount int       `json:"comments_count"`
-	ViewsCount    int64     `json:"views_count"`
-	IsLiked       bool      `json:"is_liked"`
+	Id          int      `json:"id"`
+	Ticker      string   `json:"ticker"`
+	Body        string   `json:"body"`
+	Sentiment   *string  `json:"sentiment" example:"bullish"`
+	PriceAtPost *float64 `json:"price_at_post" example:"185.5"`
+	// Percent the price moved since the post, null without both prices
+	ChangeSincePost *float64  `json:"change_since_post" example:"3.25"`
+	CreatedAt       time.Time `json:"created_at"`
+	// Set once the post was edited, its earlier versions are in the history
+	EditedAt      *time.Time `json:"edited_at"`
+	Author        Author     `json:"author"`
+	LikesCount    int        `json:"likes_count"`
+	CommentsCount int        `json:"comments_count"`
+	ViewsCount    int64      `json:"views_count"`
+	IsLiked       bool       `json:"is_liked"`
+	Entities      []*Entity  `json:"entities"`
+	// Set when the post carries a price prediction
+	Prediction *PredictionResponse `json:"prediction,omitempty"`
 }
 
 type Author struct {
@@ -35,6 +64,28 @@ type Author struct {
 type CreatePostRequest struct {
 	Ticker string `json:"ticker" example:"AAPL"`
 	Body   string `json:"body" example:"I think this stock is going up!"`
+	// Optional, one of bullish, bearish or neutral
+	Sentiment string `json:"sentiment,omitempty" example:"bullish"`
+	// Optional price prediction on the post's ticker
+	Prediction *PredictionRequest `json:"prediction,omitempty"`
+}
+
+type UpdatePostRequest struct {
+	Body string `json:"body" example:"I think this stock is going up, target raised"`
+	// Optional, one of bullish, bearish or neutral. Left as it is when omitted, an empty string clears it.
+	Sentiment *string `json:"sentiment,omitempty" example:"bullish"`
+}
+
+// PostRevision is an earlier version of an edited post
+type PostRevision struct {
+	Id        int     `json:"-" db:"id"`
+	PostId    int     `json:"-" db:"post_id"`
+	Body      string  `json:"body" db:"body"`
+	Sentiment *string `json:"sentiment" db:"sentiment" example:"bullish"`
+	// When this version was written, the post's creation or an earlier edit
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	// When it was edited away
+	ReplacedAt time.Time `json:"replaced_at" db:"replaced_at"`
 }
 
 type BatchViewRequest struct {
@@ -46,12 +97,27 @@ type BatchViewResponse struct {
 	Count   int    `json:"count" example:"5"`
 }
 
+// PostViewsDay is one day of a post's view history, days are in UTC
+type PostViewsDay struct {
+	PostId        int    `json:"-" db:"post_id"`
+	Date          string `json:"date" db:"date" example:"2024-01-31"`
+	UniqueViewers int64  `json:"unique_viewers" db:"unique_viewers"`
+	Impressions   int64  `json:"impressions" db:"impressions"`
+}
+
 type PostUseCase interface {
-	GetGlobalFeed(ctx context.Context, userId, limit, offset int) (*PaginatedResponse, error)
-	GetFollowingFeed(ctx context.Context, userId, limit, offset int) (*PaginatedResponse, error)
-	GetUserPosts(ctx context.Context, currentUserId, targetUserId, limit, offset int) (*PaginatedResponse, error)
+	GetGlobalFeed(ctx context.Context, userId int, page PaginationParams) (*PaginatedResponse, error)
+	GetFollowingFeed(ctx context.Context, userId int, page PaginationParams) (*PaginatedResponse, error)
+	GetUserPosts(ctx context.Context, currentUserId, targetUserId int, page PaginationParams) (*PaginatedResponse, error)
+	GetTickerPosts(ctx context.Context, userId int, symbol string, page PaginationParams) (*PaginatedResponse, error)
 	GetPostById(ctx context.Context, userId, postId int) (*PostResponse, error)
+	GetPostsByIds(ctx context.Context, userId int, postIds []int) ([]*PostResponse, error)
 	CreatePost(ctx context.Context, userId int, request *CreatePostRequest) (*PostResponse, error)
+	// UpdatePost edits the body and sentiment of a post of the user, keeping the previous version
+	UpdatePost(ctx context.Context, userId, postId int, request *UpdatePostRequest) (*PostResponse, error)
+	// GetPostRevisions lists the earlier versions of a post, oldest first
+	GetPostRevisions(ctx context.Context, postId int) ([]*PostRevision, error)
 	DeletePost(ctx context.Context, userId, postId int) error
-	TrackBatchViews(ctx context.Context, postIds []int) error
+	TrackBatchViews(ctx context.Context, userId int, postIds []int) error
+	GetPostViewsHistory(ctx context.Context, userId, postId, days int) ([]*PostViewsDay, error)
 }
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNoPrice = errors.New("no price available")
+)
+
+const (
+	PredictionUp   = "up"
+	PredictionDown = "down"
+
+	PredictionPending = "pending"
+	PredictionHit     = "hit"
+	PredictionMiss    = "miss"
+	// No price could be found for the expiry, the prediction doesn't count either way
+	PredictionVoid = "void"
+)
+
+// Prediction is a post's call that its ticker will be at or beyond a target
+// price, above it when up and below it when down, at the expiry
+type Prediction struct {
+	Id            int        `db:"id"`
+	PostId        int        `db:"post_id"`
+	UserId        int        `db:"user_id"`
+	Ticker        string     `db:"ticker"`
+	Direction     string     `db:"direction"`
+	TargetPrice   float64    `db:"target_price"`
+	ExpiresAt     time.Time  `db:"expires_at"`
+	Status        string     `db:"status"`
+	ResolvedPrice *float64   `db:"resolved_price"`
+	ResolvedAt    *time.Time `db:"resolved_at"`
+	CreatedAt     time.Time  `db:"created_at"`
+}
+
+type PredictionRequest struct {
+	Direction   string    `json:"direction" example:"up"`
+	TargetPrice float64   `json:"target_price" example:"250.5"`
+	ExpiresAt   time.Time `json:"expires_at" example:"2025-06-30T20:00:00Z"`
+}
+
+type PredictionResponse struct {
+	Direction     string     `json:"direction" example:"up"`
+	TargetPrice   float64    `json:"target_price" example:"250.5"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	Status        string     `json:"status" example:"hit"`
+	ResolvedPrice *float64   `json:"resolved_price" example:"253.1"`
+	ResolvedAt    *time.Time `json:"resolved_at"`
+}
+
+// PredictionRecord is a user's track record, void predictions are left out
+type PredictionRecord struct {
+	Hits    int `json:"hits" db:"hits" example:"7"`
+	Misses  int `json:"misses" db:"misses" example:"3"`
+	Pending int `json:"pending" db:"pending" example:"2"`
+	// Share of the resolved predictions that hit, null before any resolved
+	Accuracy *float64 `json:"accuracy" db:"-" example:"0.7"`
+}
+
+// MarketDataProvider gives the prices predictions are resolved against
+type MarketDataProvider interface {
+	// PriceAt returns the last price of a symbol at or before the given time,
+	// ErrNoPrice when it has none that recent
+	PriceAt(ctx context.Context, symbol string, at time.Time) (float64, error)
+}
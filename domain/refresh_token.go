@@ -16,5 +16,5 @@ type RefreshTokenResponse struct {
 }
 
 type RefreshTokenUseCase interface {
-	RefreshToken(ctx context.Context, request RefreshTokenRequest, env *bootstrap.Env) (accessToken string, refreshToken string, err error)
+	RefreshToken(ctx context.Context, request RefreshTokenRequest, client SessionClient, env *bootstrap.Env) (accessToken string, refreshToken string, err error)
 }
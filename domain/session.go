@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrSessionRevoked     = errors.New("session has been revoked, please log in again")
+	ErrRefreshTokenReused = errors.New("refresh token has already been used, the session was revoked")
+	ErrLegacyRefreshToken = errors.New("refresh token predates sessions, please log in again")
+)
+
+// Session is a login of a user, from the password or Google login until it is
+// revoked or its refresh token expires. Every refresh rotates the refresh token
+// of the session. The tokens of a session share FamilyId and only the latest,
+// TokenId, can be redeemed. Presenting an earlier one means it leaked, so the
+// whole session is revoked.
+type Session struct {
+	Id       int    `json:"id" db:"id"`
+	UserId   int    `json:"-" db:"user_id"`
+	FamilyId string `json:"-" db:"family_id"`
+	TokenId  string `json:"-" db:"token_id"`
+	// Where the session was last refreshed from, the device is read off the user agent
+	Device     string     `json:"device" db:"device"`
+	UserAgent  string     `json:"user_agent" db:"user_agent"`
+	Ip         string     `json:"ip" db:"ip"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at" db:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt  *time.Time `json:"-" db:"revoked_at"`
+}
+
+// SessionClient is what a login or refresh request tells about the device it came from
+type SessionClient struct {
+	UserAgent string
+	Ip        string
+}
+
+type SessionResponse struct {
+	Id         int       `json:"id"`
+	Device     string    `json:"device" example:"Chrome on macOS"`
+	UserAgent  string    `json:"user_agent"`
+	Ip         string    `json:"ip" example:"203.0.113.7"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	// The session of the access token making the request
+	Current bool `json:"current"`
+}
+
+type RevokeSessionsResponse struct {
+	Revoked int `json:"revoked" example:"2"`
+}
+
+type SessionUseCase interface {
+	// GetSessions lists the live sessions of the user, most recently seen first
+	GetSessions(ctx context.Context, userId, currentSessionId int) ([]*SessionResponse, error)
+	// RevokeSession ends a session of the user, its tokens stop working at once
+	RevokeSession(ctx context.Context, userId, sessionId int) error
+	// RevokeOtherSessions ends every session of the user but the current one, returning how many it ended
+	RevokeOtherSessions(ctx context.Context, userId, currentSessionId int) (int, error)
+}
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrInvalidStreamTicket = errors.New("invalid or expired stream ticket")
+
+type StreamTicketResponse struct {
+	// Passed in the ticket query parameter of a WebSocket or EventSource URL
+	Ticket string `json:"ticket" example:"bG9uZy1yYW5kb20tdGlja2V0"`
+	// Seconds left to use the ticket in, it works once
+	ExpiresIn int `json:"expires_in" example:"30"`
+}
+
+type StreamTicketUseCase interface {
+	// IssueTicket returns a short-lived single-use ticket for the session
+	// familyId of the user
+	IssueTicket(ctx context.Context, userId int, familyId string) (*StreamTicketResponse, error)
+}
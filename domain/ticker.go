@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	ErrUnknownTicker            = errors.New("unknown ticker")
+	ErrInvalidSentimentInterval = errors.New("interval must be hour or day")
+)
+
+const (
+	AssetClassStock   = "stock"
+	AssetClassCrypto  = "crypto"
+	AssetClassForex   = "forex"
+	AssetClassFutures = "futures"
+)
+
+type Ticker struct {
+	Symbol     string     `json:"symbol" db:"symbol"`
+	Name       string     `json:"name" db:"name"`
+	Exchange   string     `json:"exchange" db:"exchange"`
+	AssetClass string     `json:"asset_class" db:"asset_class"`
+	IsActive   bool       `json:"is_active" db:"is_active"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TickerStats summarizes the posts on a ticker, the recent counts cover the
+// window the stats were asked for
+type TickerStats struct {
+	PostCount     int `db:"post_count"`
+	RecentAuthors int `db:"recent_authors"`
+	RecentBullish int `db:"recent_bullish"`
+	RecentBearish int `db:"recent_bearish"`
+}
+
+type TickerResponse struct {
+	Ticker
+	PostCount          int `json:"post_count" example:"152"`
+	DistinctAuthors24h int `json:"distinct_authors_24h" example:"17"`
+	Bullish24h         int `json:"bullish_24h" example:"9"`
+	Bearish24h         int `json:"bearish_24h" example:"3"`
+	// Bullish share of the bullish and bearish posts of the last 24h, null without any
+	BullishRatio   *float64 `json:"bullish_ratio" example:"0.75"`
+	FollowersCount int      `json:"followers_count" example:"240"`
+	IsFollowing    bool     `json:"is_following"`
+}
+
+// SentimentCounts splits the posts tagged with a sentiment
+type SentimentCounts struct {
+	Bullish int `json:"bullish" db:"bullish" example:"12"`
+	Bearish int `json:"bearish" db:"bearish" example:"4"`
+	Neutral int `json:"neutral" db:"neutral" example:"3"`
+	// Bullish share of the bullish and bearish posts, null without any
+	BullishRatio *float64 `json:"bullish_ratio" db:"-" example:"0.75"`
+}
+
+type TickerSentimentResponse struct {
+	Symbol string           `json:"symbol" example:"AAPL"`
+	Hour   *SentimentCounts `json:"1h"`
+	Day    *SentimentCounts `json:"24h"`
+	Week   *SentimentCounts `json:"7d"`
+}
+
+// SentimentBucket is one period of a sentiment time series
+type SentimentBucket struct {
+	BucketsAgo int       `json:"-" db:"buckets_ago"`
+	Start      time.Time `json:"start" db:"-"`
+	SentimentCounts
+}
+
+type SentimentSeriesResponse struct {
+	Symbol   string             `json:"symbol" example:"AAPL"`
+	Interval string             `json:"interval" example:"hour"`
+	Points   []*SentimentBucket `json:"points"`
+}
+
+type TickerImportResponse struct {
+	Message  string `json:"message" example:"Tickers imported successfully"`
+	Imported int    `json:"imported" example:"120"`
+}
+
+type TickerUseCase interface {
+	Search(ctx context.Context, query string, limit int) ([]*Ticker, error)
+	// Resolve normalizes user input such as "$aapl " to a known, active symbol
+	Resolve(ctx context.Context, input string) (*Ticker, error)
+	// ResolveSymbols keeps the symbols that are known and active, in the given order
+	ResolveSymbols(ctx context.Context, symbols []string) ([]string, error)
+	// Seed adds the bundled tickers that are missing, leaving imported ones as they are
+	Seed(ctx context.Context) (int, error)
+	// Import creates or updates tickers from a CSV with a symbol,name,exchange,asset_class[,active] header
+	Import(ctx context.Context, csv io.Reader) (int, error)
+	// GetTicker returns a ticker with the stats of its posts and followers
+	GetTicker(ctx context.Context, userId int, symbol string) (*TickerResponse, error)
+	// GetSentiment splits the tagged posts on a ticker over the last hour, day and week
+	GetSentiment(ctx context.Context, symbol string) (*TickerSentimentResponse, error)
+	// GetSentimentSeries splits the tagged posts on a ticker per hour over the
+	// last day or per day over the last 30 days, oldest first
+	GetSentimentSeries(ctx context.Context, symbol, interval string) (*SentimentSeriesResponse, error)
+}
+
+type TickerFollowerUseCase interface {
+	Follow(ctx context.Context, userId int, symbol string) error
+	Unfollow(ctx context.Context, userId int, symbol string) error
+	GetFollowers(ctx context.Context, symbol string, page PaginationParams) (*PaginatedResponse, error)
+}
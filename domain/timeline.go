@@ -0,0 +1,16 @@
+package domain
+
+import "context"
+
+// TimelineUseCase maintains the home timelines the following feed is read from.
+// Posts are pushed to followers when written, except for very large accounts
+// whose posts are merged in when a timeline is read.
+type TimelineUseCase interface {
+	FanOutPost(ctx context.Context, post *Post) error
+	RemovePost(ctx context.Context, post *Post) error
+	Follow(ctx context.Context, followerId, followingId int) error
+	Unfollow(ctx context.Context, followerId, followingId int) error
+	FollowTicker(ctx context.Context, userId int, symbol string) error
+	UnfollowTicker(ctx context.Context, userId int, symbol string) error
+	GetTimeline(ctx context.Context, userId int, page PaginationParams) ([]*Post, error)
+}
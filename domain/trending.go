@@ -0,0 +1,33 @@
+package domain
+
+import "context"
+
+// PostSignals is the activity of a post within the trending window. Mention,
+// Likes and Comments are sums of time-decayed weights, recent activity counting
+// close to 1 and older activity less.
+type PostSignals struct {
+	PostId     int     `db:"post_id"`
+	Ticker     string  `db:"ticker"`
+	AgeSeconds float64 `db:"age_seconds"`
+	Mention    float64 `db:"mention"`
+	Likes      float64 `db:"likes"`
+	Comments   float64 `db:"comments"`
+	Viewers    int64   `db:"viewers"`
+}
+
+// TrendingScore is a scored member of a trending ranking, a post id or a ticker symbol
+type TrendingScore struct {
+	Member string
+	Score  float64
+}
+
+type TrendingTicker struct {
+	Symbol string  `json:"symbol" example:"NVDA"`
+	Name   string  `json:"name" example:"NVIDIA Corporation"`
+	Score  float64 `json:"score" example:"42.7"`
+}
+
+type TrendingUseCase interface {
+	GetTrendingTickers(ctx context.Context, limit int) ([]*TrendingTicker, error)
+	GetTrendingPosts(ctx context.Context, userId, limit int) ([]*PostResponse, error)
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+)
+
+var (
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled = errors.New("start two-factor enrollment first")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication is not enabled")
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	ErrInvalidChallenge     = errors.New("invalid or expired two-factor challenge, please log in again")
+)
+
+// TwoFactor is the TOTP secret of a user. It only guards logins once EnabledAt
+// is set, after the user confirmed a code of it. LastUsedStep is the period of
+// the last code accepted, no code of it or an earlier one is accepted again.
+type TwoFactor struct {
+	UserId       int        `db:"user_id"`
+	Secret       string     `db:"secret"`
+	EnabledAt    *time.Time `db:"enabled_at"`
+	LastUsedStep int64      `db:"last_used_step"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
+type TwoFactorEnrollResponse struct {
+	// For typing into an authenticator app by hand
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	// otpauth URI to show as a QR code
+	ProvisioningUri string `json:"provisioning_uri" example:"otpauth://totp/Trading%20Chat:john@example.com?algorithm=SHA1&digits=6&issuer=Trading+Chat&period=30&secret=JBSWY3DPEHPK3PXP"`
+}
+
+type TwoFactorCodeRequest struct {
+	// A code from the authenticator app, or a recovery code where accepted
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+type TwoFactorDisableRequest struct {
+	// The current password, not asked of accounts that log in with Google
+	Password string `json:"password" example:"password123"`
+	// A code from the authenticator app or a recovery code
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+type RecoveryCodesResponse struct {
+	// Each works once in place of a code from the app. They are shown only now.
+	RecoveryCodes []string `json:"recovery_codes" example:"k3x9d-7qp2m"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code" binding:"required" example:"123456"`
+}
+
+type TwoFactorUseCase interface {
+	// Enroll starts over the enrollment of the user with a new secret
+	Enroll(ctx context.Context, userId int) (*TwoFactorEnrollResponse, error)
+	// Confirm enables two-factor authentication with a code of the enrolled
+	// secret and returns new recovery codes
+	Confirm(ctx context.Context, userId int, code, clientIp string) (*RecoveryCodesResponse, error)
+	// Disable turns two-factor authentication off with the password and a code
+	// or a recovery code
+	Disable(ctx context.Context, userId int, request TwoFactorDisableRequest, clientIp string) error
+	// VerifyLogin completes a login that returned a challenge with a code or a
+	// recovery code, opening the session. Failed codes of a user count across
+	// challenges, Confirm and Disable, and lock them out as a TooManyAttemptsError.
+	VerifyLogin(ctx context.Context, request TwoFactorLoginRequest, client SessionClient, env *bootstrap.Env) (*LoginResponse, error)
+}
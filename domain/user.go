@@ -27,6 +27,8 @@ type UserResponse struct {
 	Email       string    `json:"email" db:"email"`
 	IsVerified  bool      `json:"is_verified" db:"is_verified"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	// Only on the profile
+	PredictionRecord *PredictionRecord `json:"prediction_record,omitempty" db:"-"`
 }
 
 // UserUpdateRequest represents the request body for updating user profile
@@ -40,6 +42,7 @@ type UserUpdateRequest struct {
 
 type UserUseCase interface {
 	GetUserById(c context.Context, id int) (*UserResponse, error)
+	GetPredictionRecord(c context.Context, id int) (*PredictionRecord, error)
 	GetUsers(c context.Context) ([]*UserResponse, error)
 	UpdateUser(c context.Context, user *User) error
 	DeleteUser(c context.Context, id int) error
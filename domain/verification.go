@@ -32,7 +32,7 @@ type VerificationResponse struct {
 }
 
 type VerificationUseCase interface {
-	VerifyEmail(ctx context.Context, email, code string) error
+	VerifyEmail(ctx context.Context, email, code, clientIp string) error
 	ResendVerificationCode(ctx context.Context, email string) error
 	SendVerificationCode(ctx context.Context, userId int, email string) error
 }
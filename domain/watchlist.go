@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrWatchlistNotFound = errors.New("watchlist not found")
+	ErrAlertRuleNotFound = errors.New("alert rule not found")
+	ErrInvalidAlertRule  = errors.New("alert rule must be a price_cross with a level, a percent_move with a percent or a sentiment_flip")
+	ErrNotInWatchlist    = errors.New("ticker is not in the watchlist")
+	ErrWatchlistExists   = errors.New("a watchlist with that name already exists")
+	ErrWatchlistName     = errors.New("watchlist name is required and must be at most 100 characters")
+	ErrWatchlistLimit    = errors.New("too many watchlists")
+	ErrWatchlistFull     = errors.New("too many tickers in the watchlist")
+	ErrAlertRuleLimit    = errors.New("too many alert rules")
+)
+
+const (
+	// Fires when the price crosses Level, either way
+	AlertPriceCross = "price_cross"
+	// Fires when the price moved Percent or more either way since the start
+	// of the UTC day, at most once a day
+	AlertPercentMove = "percent_move"
+	// Fires when the ticker's posts over the last day turn from mostly
+	// bullish to mostly bearish, or the other way
+	AlertSentimentFlip = "sentiment_flip"
+)
+
+type Watchlist struct {
+	Id        int        `json:"id" db:"id"`
+	UserId    int        `json:"user_id" db:"user_id"`
+	Name      string     `json:"name" db:"name"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AlertRule watches a ticker of a watchlist. The last observed price,
+// sentiment and firing are kept to tell when it fires again.
+type AlertRule struct {
+	Id          int      `json:"id" db:"id"`
+	WatchlistId int      `json:"watchlist_id" db:"watchlist_id"`
+	Symbol      string   `json:"symbol" db:"symbol"`
+	Type        string   `json:"type" db:"type" example:"price_cross"`
+	Level       *float64 `json:"level,omitempty" db:"level" example:"200"`
+	Percent     *float64 `json:"percent,omitempty" db:"percent" example:"5"`
+	// Owner of the watchlist, read along with the rule for the worker
+	UserId        int        `json:"-" db:"user_id"`
+	LastPrice     *float64   `json:"last_price" db:"last_price"`
+	LastSentiment *string    `json:"last_sentiment" db:"last_sentiment"`
+	TriggeredAt   *time.Time `json:"triggered_at" db:"triggered_at"`
+	// Bumped on every state change so that replicas don't both fire a rule
+	Revision  int       `json:"-" db:"revision"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type WatchlistResponse struct {
+	Id        int          `json:"id"`
+	Name      string       `json:"name" example:"Semis"`
+	Symbols   []string     `json:"symbols" example:"NVDA,AMD"`
+	Rules     []*AlertRule `json:"rules"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type WatchlistRequest struct {
+	Name string `json:"name" example:"Semis"`
+	// Replaces the symbols of the watchlist, left as they are when omitted on update
+	Symbols []string `json:"symbols,omitempty" example:"NVDA,AMD"`
+}
+
+type AlertRuleRequest struct {
+	Symbol string `json:"symbol" example:"NVDA"`
+	// One of price_cross, percent_move or sentiment_flip
+	Type string `json:"type" example:"price_cross"`
+	// Required by price_cross
+	Level *float64 `json:"level,omitempty" example:"200"`
+	// Required by percent_move
+	Percent *float64 `json:"percent,omitempty" example:"5"`
+}
+
+type WatchlistUseCase interface {
+	GetWatchlists(ctx context.Context, userId int) ([]*WatchlistResponse, error)
+	GetWatchlist(ctx context.Context, userId, watchlistId int) (*WatchlistResponse, error)
+	CreateWatchlist(ctx context.Context, userId int, request *WatchlistRequest) (*WatchlistResponse, error)
+	UpdateWatchlist(ctx context.Context, userId, watchlistId int, request *WatchlistRequest) (*WatchlistResponse, error)
+	DeleteWatchlist(ctx context.Context, userId, watchlistId int) error
+	AddSymbol(ctx context.Context, userId, watchlistId int, symbol string) error
+	// RemoveSymbol takes a ticker off a watchlist along with its alert rules
+	RemoveSymbol(ctx context.Context, userId, watchlistId int, symbol string) error
+	// CreateAlertRule attaches a rule to a ticker of the watchlist
+	CreateAlertRule(ctx context.Context, userId, watchlistId int, request *AlertRuleRequest) (*AlertRule, error)
+	DeleteAlertRule(ctx context.Context, userId, watchlistId, ruleId int) error
+}
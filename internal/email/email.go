@@ -2,7 +2,9 @@ package email
 
 import (
 	"fmt"
+	"math"
 	"net/smtp"
+	"time"
 
 	"github.com/Pro100-Almaz/trading-chat/bootstrap"
 	log "github.com/sirupsen/logrus"
@@ -41,6 +43,66 @@ Best regards,
 Trading Chat Team
 `, code)
 
+	if err := e.send(to, subject, body); err != nil {
+		return err
+	}
+
+	log.Info("Verification email sent to: ", to)
+	return nil
+}
+
+func (e *EmailService) SendPasswordResetToken(to, token string) error {
+	subject := "Password Reset"
+	body := fmt.Sprintf(`
+Hello,
+
+Someone asked to reset the password of your Trading Chat account. To choose a
+new one, use this reset token:
+
+%s
+
+This token will expire in 30 minutes and works only once. Resetting your
+password logs you out on every device.
+
+If you didn't ask for this, please ignore this email, your password stays as it is.
+
+Best regards,
+Trading Chat Team
+`, token)
+
+	if err := e.send(to, subject, body); err != nil {
+		return err
+	}
+
+	log.Info("Password reset email sent to: ", to)
+	return nil
+}
+
+func (e *EmailService) SendLockoutNotice(to string, duration time.Duration) error {
+	subject := "Account Temporarily Locked"
+	body := fmt.Sprintf(`
+Hello,
+
+There were too many failed attempts to log in to your Trading Chat account,
+verify its email or enter its two-factor code, so it is locked for %s.
+
+If that was you, wait and try again. If not, someone may be guessing your
+password. Once the lock is over, consider resetting your password and turning
+on two-factor authentication.
+
+Best regards,
+Trading Chat Team
+`, formatDuration(duration))
+
+	if err := e.send(to, subject, body); err != nil {
+		return err
+	}
+
+	log.Info("Lockout email sent to: ", to)
+	return nil
+}
+
+func (e *EmailService) send(to, subject, body string) error {
 	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s",
 		e.from, to, subject, body)
 
@@ -56,7 +118,20 @@ Trading Chat Team
 		log.Error("Failed to send email: ", err)
 		return err
 	}
-
-	log.Info("Verification email sent to: ", to)
 	return nil
 }
+
+// formatDuration spells out a duration in whole hours or minutes, e.g. "2 hours"
+func formatDuration(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		if hours := int(d / time.Hour); hours != 1 {
+			return fmt.Sprintf("%d hours", hours)
+		}
+		return "1 hour"
+	}
+	minutes := int(math.Ceil(d.Minutes()))
+	if minutes == 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
@@ -0,0 +1,138 @@
+package marketdata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	quoteCacheTTL   = 15 * time.Second
+	historyCacheTTL = time.Minute
+	lookupCacheTTL  = time.Hour
+	// Past prices don't change, but a late feed may still fill them in
+	priceAtCacheTTL = time.Hour
+)
+
+// cachedProvider keeps the answers of another provider in Redis, so every
+// replica shares them. Errors, missing prices included, are never cached,
+// and Redis failures fall through to the provider.
+type cachedProvider struct {
+	next  Provider
+	redis *redis.Client
+}
+
+func NewCachedProvider(next Provider, redis *redis.Client) Provider {
+	return &cachedProvider{
+		next:  next,
+		redis: redis,
+	}
+}
+
+func (c *cachedProvider) PriceAt(ctx context.Context, symbol string, at time.Time) (float64, error) {
+	key := fmt.Sprintf("marketdata:price:%s:%d", strings.ToUpper(symbol), at.Unix())
+	return cached(ctx, c.redis, key, priceAtCacheTTL, func() (float64, error) {
+		return c.next.PriceAt(ctx, symbol, at)
+	})
+}
+
+func (c *cachedProvider) Quote(ctx context.Context, symbol string) (*Quote, error) {
+	return cached(ctx, c.redis, quoteCacheKey(symbol), quoteCacheTTL, func() (*Quote, error) {
+		return c.next.Quote(ctx, symbol)
+	})
+}
+
+// Quotes reads the cached quotes in one round trip and asks the provider for
+// the rest in one call
+func (c *cachedProvider) Quotes(ctx context.Context, symbols []string) (map[string]*Quote, error) {
+	quotes := make(map[string]*Quote, len(symbols))
+	if len(symbols) == 0 {
+		return quotes, nil
+	}
+
+	keys := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		keys = append(keys, quoteCacheKey(symbol))
+	}
+	values, err := c.redis.MGet(ctx, keys...).Result()
+	if err != nil {
+		log.Warnf("Failed to read market data cache of %d quotes: %v", len(keys), err)
+		values = make([]interface{}, len(keys))
+	}
+
+	missing := make([]string, 0)
+	for i, symbol := range symbols {
+		var quote Quote
+		if data, ok := values[i].(string); ok && json.Unmarshal([]byte(data), &quote) == nil {
+			quotes[strings.ToUpper(symbol)] = &quote
+			continue
+		}
+		missing = append(missing, symbol)
+	}
+	if len(missing) == 0 {
+		return quotes, nil
+	}
+
+	loaded, err := c.next.Quotes(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	pipe := c.redis.Pipeline()
+	for symbol, quote := range loaded {
+		quotes[symbol] = quote
+		if data, err := json.Marshal(quote); err == nil {
+			pipe.Set(ctx, quoteCacheKey(symbol), data, quoteCacheTTL)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Warnf("Failed to write market data cache of %d quotes: %v", len(loaded), err)
+	}
+	return quotes, nil
+}
+
+func (c *cachedProvider) History(ctx context.Context, symbol string, from, to time.Time, interval time.Duration) ([]*Candle, error) {
+	key := fmt.Sprintf("marketdata:history:%s:%d:%d:%d", strings.ToUpper(symbol), from.Unix(), to.Unix(), int64(interval.Seconds()))
+	return cached(ctx, c.redis, key, historyCacheTTL, func() ([]*Candle, error) {
+		return c.next.History(ctx, symbol, from, to, interval)
+	})
+}
+
+func (c *cachedProvider) Lookup(ctx context.Context, query string, limit int) ([]string, error) {
+	key := fmt.Sprintf("marketdata:lookup:%d:%s", limit, strings.ToUpper(strings.TrimSpace(query)))
+	return cached(ctx, c.redis, key, lookupCacheTTL, func() ([]string, error) {
+		return c.next.Lookup(ctx, query, limit)
+	})
+}
+
+func quoteCacheKey(symbol string) string {
+	return fmt.Sprintf("marketdata:quote:%s", strings.ToUpper(symbol))
+}
+
+// cached returns the value stored at key, or loads and stores it for ttl
+func cached[T any](ctx context.Context, client *redis.Client, key string, ttl time.Duration, load func() (T, error)) (T, error) {
+	var value T
+	data, err := client.Get(ctx, key).Bytes()
+	if err == nil && json.Unmarshal(data, &value) == nil {
+		return value, nil
+	}
+	if err != nil && err != redis.Nil {
+		log.Warnf("Failed to read market data cache %s: %v", key, err)
+	}
+
+	value, err = load()
+	if err != nil {
+		return value, err
+	}
+
+	if data, err := json.Marshal(value); err == nil {
+		if err := client.Set(ctx, key, data, ttl).Err(); err != nil {
+			log.Warnf("Failed to write market data cache %s: %v", key, err)
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,92 @@
+package marketdata
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingProvider counts the quotes that reach the wrapped provider
+type countingProvider struct {
+	Provider
+	quotes int
+}
+
+func (p *countingProvider) Quote(ctx context.Context, symbol string) (*Quote, error) {
+	p.quotes++
+	return p.Provider.Quote(ctx, symbol)
+}
+
+func (p *countingProvider) Quotes(ctx context.Context, symbols []string) (map[string]*Quote, error) {
+	p.quotes += len(symbols)
+	return p.Provider.Quotes(ctx, symbols)
+}
+
+func TestCachedProviderQuote(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	memory := NewMemoryProvider()
+	memory.AddPrice("AAPL", time.Now().Add(-time.Minute), 185)
+	next := &countingProvider{Provider: memory}
+	provider := NewCachedProvider(next, client)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		quote, err := provider.Quote(ctx, "aapl")
+		require.NoError(t, err)
+		assert.Equal(t, 185.0, quote.Price)
+	}
+	assert.Equal(t, 1, next.quotes)
+
+	// Expired quotes are fetched again
+	server.FastForward(quoteCacheTTL)
+	_, err := provider.Quote(ctx, "AAPL")
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.quotes)
+
+	// Missing prices aren't cached
+	for i := 0; i < 2; i++ {
+		_, err := provider.Quote(ctx, "MSFT")
+		assert.ErrorIs(t, err, domain.ErrNoPrice)
+	}
+	assert.Equal(t, 4, next.quotes)
+}
+
+func TestCachedProviderQuotes(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	memory := NewMemoryProvider()
+	memory.AddPrice("AAPL", time.Now().Add(-time.Minute), 185)
+	memory.AddPrice("TSLA", time.Now().Add(-time.Minute), 240)
+	next := &countingProvider{Provider: memory}
+	provider := NewCachedProvider(next, client)
+	ctx := context.Background()
+
+	// One cached already
+	_, err := provider.Quote(ctx, "AAPL")
+	require.NoError(t, err)
+
+	quotes, err := provider.Quotes(ctx, []string{"aapl", "TSLA", "MSFT"})
+	require.NoError(t, err)
+	require.Len(t, quotes, 2)
+	assert.Equal(t, 185.0, quotes["AAPL"].Price)
+	assert.Equal(t, 240.0, quotes["TSLA"].Price)
+	// AAPL came from the cache, MSFT has no price
+	assert.Equal(t, 3, next.quotes)
+
+	quotes, err = provider.Quotes(ctx, []string{"AAPL", "TSLA"})
+	require.NoError(t, err)
+	assert.Len(t, quotes, 2)
+	assert.Equal(t, 3, next.quotes)
+}
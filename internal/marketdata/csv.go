@@ -0,0 +1,88 @@
+package marketdata
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The CSV files hold a header row with symbol, time and price columns. Times
+// are RFC 3339 or dates, taken as midnight UTC. Loaded into a MemoryProvider
+// they resolve predictions offline and in tests, or replay a past market.
+
+// LoadCSVProvider reads the prices of the CSV file at path
+func LoadCSVProvider(path string) (*MemoryProvider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewCSVProvider(f)
+}
+
+// NewCSVProvider reads the prices of a CSV file
+func NewCSVProvider(r io.Reader) (*MemoryProvider, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("csv is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"symbol", "time", "price"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("csv is missing the %s column", required)
+		}
+	}
+
+	provider := NewMemoryProvider()
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		symbol := strings.ToUpper(strings.TrimSpace(record[columns["symbol"]]))
+		if symbol == "" {
+			return nil, fmt.Errorf("line %d: symbol is required", line)
+		}
+		at, err := parsePriceTime(strings.TrimSpace(record[columns["time"]]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		price, err := strconv.ParseFloat(strings.TrimSpace(record[columns["price"]]), 64)
+		if err != nil || price <= 0 {
+			return nil, fmt.Errorf("line %d: invalid price", line)
+		}
+
+		provider.AddPrice(symbol, at, price)
+	}
+
+	return provider, nil
+}
+
+func parsePriceTime(value string) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+	if at, err := time.Parse("2006-01-02", value); err == nil {
+		return at, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
@@ -0,0 +1,51 @@
+package marketdata
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVProviderPriceAt(t *testing.T) {
+	provider, err := NewCSVProvider(strings.NewReader(
+		"symbol,time,price\n" +
+			"AAPL,2024-01-03,185.5\n" +
+			"aapl,2024-01-02,184.0\n" +
+			"AAPL,2024-01-03T21:00:00Z,186.25\n"))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	price, err := provider.PriceAt(ctx, "AAPL", time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 184.0, price)
+
+	price, err = provider.PriceAt(ctx, "AAPL", time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 186.25, price)
+
+	// Before the first price, too long after the last one and unknown symbols
+	_, err = provider.PriceAt(ctx, "AAPL", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, domain.ErrNoPrice)
+	_, err = provider.PriceAt(ctx, "AAPL", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, domain.ErrNoPrice)
+	_, err = provider.PriceAt(ctx, "MSFT", time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, domain.ErrNoPrice)
+}
+
+func TestCSVProviderRejectsInvalidRows(t *testing.T) {
+	cases := map[string]string{
+		"missing column": "symbol,price\nAAPL,1\n",
+		"time":           "symbol,time,price\nAAPL,yesterday,1\n",
+		"price":          "symbol,time,price\nAAPL,2024-01-02,-1\n",
+	}
+	for name, input := range cases {
+		_, err := NewCSVProvider(strings.NewReader(input))
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,35 @@
+package marketdata
+
+import (
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+// NewProvider serves the prices of MARKET_DATA_CSV, replayed from
+// MARKET_DATA_REPLAY_FROM when set, behind the Redis cache. Without a file it
+// has no prices and posts are stored without one.
+func NewProvider(env *bootstrap.Env, redisClient *redis.Client) Provider {
+	provider := NewMemoryProvider()
+	if env.MarketDataCSV != "" {
+		loaded, err := LoadCSVProvider(env.MarketDataCSV)
+		if err != nil {
+			log.Fatal("Failed to load market data: ", err)
+		}
+		provider = loaded
+	}
+
+	if env.MarketDataReplayFrom != "" {
+		start, err := time.Parse(time.RFC3339, env.MarketDataReplayFrom)
+		if err != nil {
+			log.Fatal("Invalid MARKET_DATA_REPLAY_FROM: ", err)
+		}
+		provider.ReplayFrom(start)
+		log.Infof("Replaying market data from %s", start.Format(time.RFC3339))
+	}
+
+	return NewCachedProvider(provider, redisClient)
+}
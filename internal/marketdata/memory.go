@@ -0,0 +1,159 @@
+package marketdata
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+)
+
+// A price older than this before the asked time is too stale to use, long
+// enough to bridge weekends and holidays of daily closes
+const maxPriceAge = 96 * time.Hour
+
+type pricePoint struct {
+	at    time.Time
+	price float64
+}
+
+// MemoryProvider serves prices held in memory, loaded from a CSV file or
+// added in tests. Set to replay, it plays old prices back as if they were
+// happening now.
+type MemoryProvider struct {
+	mu     sync.RWMutex
+	series map[string][]pricePoint
+	// Added to real times to get data times when replaying
+	offset time.Duration
+	now    func() time.Time
+}
+
+func NewMemoryProvider() *MemoryProvider {
+	return &MemoryProvider{
+		series: make(map[string][]pricePoint),
+		now:    time.Now,
+	}
+}
+
+// AddPrice records the price of a symbol at a data time
+func (p *MemoryProvider) AddPrice(symbol string, at time.Time, price float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	symbol = strings.ToUpper(symbol)
+	points := p.series[symbol]
+	i := sort.Search(len(points), func(i int) bool { return points[i].at.After(at) })
+	points = append(points, pricePoint{})
+	copy(points[i+1:], points[i:])
+	points[i] = pricePoint{at: at, price: price}
+	p.series[symbol] = points
+}
+
+// ReplayFrom makes the current moment correspond to start in the data, time
+// then moves on through the data at the real pace
+func (p *MemoryProvider) ReplayFrom(start time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.offset = start.Sub(p.now())
+}
+
+func (p *MemoryProvider) PriceAt(ctx context.Context, symbol string, at time.Time) (float64, error) {
+	point, err := p.pointAt(symbol, at)
+	if err != nil {
+		return 0, err
+	}
+	return point.price, nil
+}
+
+func (p *MemoryProvider) Quote(ctx context.Context, symbol string) (*Quote, error) {
+	point, err := p.pointAt(symbol, p.now())
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return &Quote{Symbol: strings.ToUpper(symbol), Price: point.price, At: point.at.Add(-p.offset)}, nil
+}
+
+func (p *MemoryProvider) Quotes(ctx context.Context, symbols []string) (map[string]*Quote, error) {
+	quotes := make(map[string]*Quote, len(symbols))
+	for _, symbol := range symbols {
+		quote, err := p.Quote(ctx, symbol)
+		if err != nil {
+			continue
+		}
+		quotes[quote.Symbol] = quote
+	}
+	return quotes, nil
+}
+
+func (p *MemoryProvider) History(ctx context.Context, symbol string, from, to time.Time, interval time.Duration) ([]*Candle, error) {
+	if interval <= 0 || !to.After(from) {
+		return nil, ErrInvalidRange
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	points := p.series[strings.ToUpper(symbol)]
+	dataFrom, dataTo := from.Add(p.offset), to.Add(p.offset)
+	start := sort.Search(len(points), func(i int) bool { return !points[i].at.Before(dataFrom) })
+
+	candles := make([]*Candle, 0)
+	var candle *Candle
+	for _, point := range points[start:] {
+		if !point.at.Before(dataTo) {
+			break
+		}
+		bucket := from.Add(point.at.Sub(dataFrom) / interval * interval)
+		if candle == nil || !candle.Time.Equal(bucket) {
+			candle = &Candle{Time: bucket, Open: point.price, High: point.price, Low: point.price}
+			candles = append(candles, candle)
+		}
+		candle.High = max(candle.High, point.price)
+		candle.Low = min(candle.Low, point.price)
+		candle.Close = point.price
+	}
+	return candles, nil
+}
+
+func (p *MemoryProvider) Lookup(ctx context.Context, query string, limit int) ([]string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	query = strings.ToUpper(strings.TrimSpace(query))
+	symbols := make([]string, 0)
+	for symbol := range p.series {
+		if strings.HasPrefix(symbol, query) {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+	if len(symbols) > limit {
+		symbols = symbols[:limit]
+	}
+	return symbols, nil
+}
+
+// pointAt finds the latest price at or before a real time
+func (p *MemoryProvider) pointAt(symbol string, at time.Time) (pricePoint, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	points := p.series[strings.ToUpper(symbol)]
+	at = at.Add(p.offset)
+
+	// Index of the first point after at, the one before it is the latest at or before at
+	i := sort.Search(len(points), func(i int) bool { return points[i].at.After(at) })
+	if i == 0 {
+		return pricePoint{}, domain.ErrNoPrice
+	}
+	point := points[i-1]
+	if at.Sub(point.at) > maxPriceAge {
+		return pricePoint{}, domain.ErrNoPrice
+	}
+	return point, nil
+}
@@ -0,0 +1,65 @@
+package marketdata
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryProviderHistory(t *testing.T) {
+	provider := NewMemoryProvider()
+	start := time.Date(2024, 1, 2, 14, 0, 0, 0, time.UTC)
+	for i, price := range []float64{10, 12, 9, 11, 20} {
+		provider.AddPrice("aapl", start.Add(time.Duration(i)*20*time.Minute), price)
+	}
+
+	candles, err := provider.History(context.Background(), "AAPL", start, start.Add(2*time.Hour), time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []*Candle{
+		{Time: start, Open: 10, High: 12, Low: 9, Close: 9},
+		{Time: start.Add(time.Hour), Open: 11, High: 20, Low: 11, Close: 20},
+	}, candles)
+
+	_, err = provider.History(context.Background(), "AAPL", start, start, time.Hour)
+	assert.ErrorIs(t, err, ErrInvalidRange)
+}
+
+func TestMemoryProviderReplay(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	provider := NewMemoryProvider()
+	provider.now = func() time.Time { return now }
+
+	recorded := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	provider.AddPrice("AAPL", recorded, 185)
+	provider.AddPrice("AAPL", recorded.Add(time.Hour), 187)
+	provider.ReplayFrom(recorded.Add(30 * time.Minute))
+
+	quote, err := provider.Quote(context.Background(), "AAPL")
+	require.NoError(t, err)
+	assert.Equal(t, 185.0, quote.Price)
+	assert.Equal(t, now.Add(-30*time.Minute), quote.At)
+
+	// An hour later in real time the next recorded price is out
+	price, err := provider.PriceAt(context.Background(), "AAPL", now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 187.0, price)
+
+	_, err = provider.Quote(context.Background(), "MSFT")
+	assert.ErrorIs(t, err, domain.ErrNoPrice)
+}
+
+func TestMemoryProviderLookup(t *testing.T) {
+	provider := NewMemoryProvider()
+	for _, symbol := range []string{"AMZN", "AAPL", "AMD", "TSLA"} {
+		provider.AddPrice(symbol, time.Now(), 1)
+	}
+
+	symbols, err := provider.Lookup(context.Background(), " a", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AAPL", "AMD"}, symbols)
+}
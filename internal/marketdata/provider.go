@@ -0,0 +1,43 @@
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+)
+
+var (
+	ErrInvalidRange = errors.New("invalid history range")
+)
+
+// Provider gives market prices. Times in and out are real times, a provider
+// replaying old data maps them onto its data.
+type Provider interface {
+	domain.MarketDataProvider
+	// Quote returns the latest price of a symbol, domain.ErrNoPrice when it has none recent
+	Quote(ctx context.Context, symbol string) (*Quote, error)
+	// Quotes returns the latest prices of several symbols at once, keyed by
+	// upper case symbol. Symbols without a recent price are left out.
+	Quotes(ctx context.Context, symbols []string) (map[string]*Quote, error)
+	// History returns the candles of a symbol in [from, to), one per interval
+	// that had prices, oldest first
+	History(ctx context.Context, symbol string, from, to time.Time, interval time.Duration) ([]*Candle, error)
+	// Lookup returns the symbols starting with query that the provider has prices for
+	Lookup(ctx context.Context, query string, limit int) ([]string, error)
+}
+
+type Quote struct {
+	Symbol string    `json:"symbol"`
+	Price  float64   `json:"price"`
+	At     time.Time `json:"at"`
+}
+
+type Candle struct {
+	Time  time.Time `json:"time"`
+	Open  float64   `json:"open"`
+	High  float64   `json:"high"`
+	Low   float64   `json:"low"`
+	Close float64   `json:"close"`
+}
@@ -0,0 +1,16 @@
+// Package tickers bundles the ticker list the registry is seeded with
+package tickers
+
+import (
+	"bytes"
+	_ "embed"
+	"io"
+)
+
+//go:embed tickers.csv
+var bundled []byte
+
+// Bundled returns the bundled ticker CSV
+func Bundled() io.Reader {
+	return bytes.NewReader(bundled)
+}
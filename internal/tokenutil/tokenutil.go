@@ -8,13 +8,15 @@ import (
 	"github.com/Pro100-Almaz/trading-chat/domain"
 )
 
-func CreateAccessToken(user *domain.User, secret string, expiry int) (accessToken string, err error) {
+// CreateAccessToken issues an access token of the session familyId
+func CreateAccessToken(user *domain.User, familyId string, secret string, expiry int) (accessToken string, err error) {
 	exp := time.Now().Add(time.Hour * time.Duration(expiry))
 	claims := &domain.JwtCustomClaims{
 		Name:     user.Name,
 		GoogleId: user.GoogleId,
 		Email:    user.Email,
 		ID:       user.Id,
+		FamilyId: familyId,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(exp),
 		},
@@ -27,13 +29,16 @@ func CreateAccessToken(user *domain.User, secret string, expiry int) (accessToke
 	return t, err
 }
 
-func CreateRefreshToken(user *domain.User, secret string, expiry int) (refreshToken string, err error) {
+// CreateRefreshToken issues the refresh token tokenId of the session familyId
+func CreateRefreshToken(user *domain.User, familyId, tokenId string, secret string, expiry int) (refreshToken string, err error) {
 	claimsRefresh := &domain.JwtCustomRefreshClaims{
 		ID:       user.Id,
 		Name:     user.Name,
 		GoogleId: user.GoogleId,
 		Email:    user.Email,
+		FamilyId: familyId,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenId,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * time.Duration(expiry))),
 		},
 	}
@@ -80,3 +85,39 @@ func ExtractIDFromToken(requestToken string, secret string) (int, error) {
 	idInt := int(id)
 	return idInt, nil
 }
+
+// ParseAccessToken verifies an access token and returns its claims
+func ParseAccessToken(requestToken string, secret string) (*domain.JwtCustomClaims, error) {
+	claims := &domain.JwtCustomClaims{}
+	token, err := jwt.ParseWithClaims(requestToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, domain.ErrUnexpectedSigningMethod
+		}
+		return []byte(secret), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, domain.ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// ParseRefreshToken verifies a refresh token and returns its claims
+func ParseRefreshToken(requestToken string, secret string) (*domain.JwtCustomRefreshClaims, error) {
+	claims := &domain.JwtCustomRefreshClaims{}
+	token, err := jwt.ParseWithClaims(requestToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, domain.ErrUnexpectedSigningMethod
+		}
+		return []byte(secret), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, domain.ErrInvalidToken
+	}
+	return claims, nil
+}
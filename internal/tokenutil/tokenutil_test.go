@@ -21,7 +21,7 @@ func TestCreateAccessToken(t *testing.T) {
 	expiry := 1 // 1 hour
 
 	// Create an access token
-	accessToken, err := tokenutil.CreateAccessToken(user, secret, expiry)
+	accessToken, err := tokenutil.CreateAccessToken(user, "family123", secret, expiry)
 
 	// Assertions
 	assert.NoError(t, err, "Error occurred while creating access token")
@@ -41,13 +41,62 @@ func TestCreateRefreshToken(t *testing.T) {
 	expiry := 24 * 7 // 1 week
 
 	// Create a refresh token
-	refreshToken, err := tokenutil.CreateRefreshToken(user, secret, expiry)
+	refreshToken, err := tokenutil.CreateRefreshToken(user, "family123", "token123", secret, expiry)
 
 	// Assertions
 	assert.NoError(t, err, "Error occurred while creating refresh token")
 	assert.NotEmpty(t, refreshToken, "Refresh token should not be empty")
 }
 
+func TestParseAccessToken(t *testing.T) {
+	secret := "testAccessTokenSecret"
+	expiry := 1 // 1 hour
+
+	// Create a test token
+	user := &domain.User{
+		Name:     "John Doe",
+		GoogleId: "google123",
+		Email:    "john@example.com",
+		Id:       123,
+	}
+	accessToken, _ := tokenutil.CreateAccessToken(user, "family123", secret, expiry)
+
+	// Parse the token
+	claims, err := tokenutil.ParseAccessToken(accessToken, secret)
+
+	// Assertions
+	assert.NoError(t, err, "Error occurred while parsing access token")
+	assert.Equal(t, user.Id, claims.ID, "Parsed ID should match user's ID")
+	assert.Equal(t, "family123", claims.FamilyId, "Parsed family should match the session's")
+}
+
+func TestParseRefreshToken(t *testing.T) {
+	secret := "testRefreshTokenSecret"
+	expiry := 24 * 7 // 1 week
+
+	// Create a test token
+	user := &domain.User{
+		Name:     "John Doe",
+		GoogleId: "google123",
+		Email:    "john@example.com",
+		Id:       123,
+	}
+	refreshToken, _ := tokenutil.CreateRefreshToken(user, "family123", "token123", secret, expiry)
+
+	// Parse the token
+	claims, err := tokenutil.ParseRefreshToken(refreshToken, secret)
+
+	// Assertions
+	assert.NoError(t, err, "Error occurred while parsing refresh token")
+	assert.Equal(t, user.Id, claims.ID, "Parsed ID should match user's ID")
+	assert.Equal(t, "family123", claims.FamilyId, "Parsed family should match the session's")
+	assert.Equal(t, "token123", claims.RegisteredClaims.ID, "Parsed token ID should match")
+
+	// A token signed with another secret is rejected
+	_, err = tokenutil.ParseRefreshToken(refreshToken, "otherSecret")
+	assert.Error(t, err, "Token signed with another secret should be rejected")
+}
+
 func TestIsAuthorized(t *testing.T) {
 	secret := "testAccessTokenSecret"
 	expiry := 1 // 1 hour
@@ -59,7 +108,7 @@ func TestIsAuthorized(t *testing.T) {
 		Email:    "john@example.com",
 		Id:       123,
 	}
-	accessToken, _ := tokenutil.CreateAccessToken(user, secret, expiry)
+	accessToken, _ := tokenutil.CreateAccessToken(user, "family123", secret, expiry)
 
 	// Check if token is authorized
 	authorized, err := tokenutil.IsAuthorized(accessToken, secret)
@@ -80,7 +129,7 @@ func TestExtractIDFromToken(t *testing.T) {
 		Email:    "john@example.com",
 		Id:       123,
 	}
-	accessToken, _ := tokenutil.CreateAccessToken(user, secret, expiry)
+	accessToken, _ := tokenutil.CreateAccessToken(user, "family123", secret, expiry)
 
 	// Extract ID from the token
 	id, err := tokenutil.ExtractIDFromToken(accessToken, secret)
@@ -0,0 +1,65 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The SHA1 test vectors of RFC 6238, appendix B
+func TestHotpRFC6238(t *testing.T) {
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.code, hotp(key, uint64(Step(time.Unix(tt.unix, 0))), 8))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111111, 0)
+
+	code, err := Code(secret, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "050471", code)
+
+	step, ok := Validate(secret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	// A period late is still fine, two are not
+	step, ok = Validate(secret, code, now.Add(Period))
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+	_, ok = Validate(secret, code, now.Add(2*Period))
+	assert.False(t, ok)
+
+	// Lowercase secrets, as some people type them, work as well
+	_, ok = Validate(strings.ToLower(secret), code, now)
+	assert.True(t, ok)
+
+	_, ok = Validate(secret, "000000", now)
+	assert.False(t, ok)
+	_, ok = Validate(secret, "50471", now)
+	assert.False(t, ok)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	assert.Equal(t,
+		"otpauth://totp/Trading%20Chat:john@example.com?algorithm=SHA1&digits=6&issuer=Trading+Chat&period=30&secret=JBSWY3DPEHPK3PXP",
+		ProvisioningURI("Trading Chat", "john@example.com", "JBSWY3DPEHPK3PXP"))
+}
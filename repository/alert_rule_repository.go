@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type AlertRuleRepository interface {
+	CreateAlertRule(ctx context.Context, rule *domain.AlertRule) (int, error)
+	// GetAlertRules lists the rules of several watchlists, oldest first, keyed by watchlist id
+	GetAlertRules(ctx context.Context, watchlistIds []int) (map[int][]*domain.AlertRule, error)
+	// GetAlertRulesCount counts the rules over all watchlists of a user
+	GetAlertRulesCount(ctx context.Context, userId int) (int, error)
+	// DeleteAlertRule deletes a rule of a watchlist, reporting whether there was one
+	DeleteAlertRule(ctx context.Context, watchlistId, ruleId int) (bool, error)
+	// GetAllAlertRules returns every rule along with the owner of its watchlist
+	GetAllAlertRules(ctx context.Context) ([]*domain.AlertRule, error)
+	// UpdateAlertState stores the state a rule was evaluated in, unless it
+	// changed since the rule was read, and reports whether it was stored
+	UpdateAlertState(ctx context.Context, rule *domain.AlertRule) (bool, error)
+}
+
+type alertRuleRepository struct {
+	db *sqlx.DB
+}
+
+func NewAlertRuleRepository(db *sqlx.DB) AlertRuleRepository {
+	return &alertRuleRepository{db: db}
+}
+
+func (r *alertRuleRepository) CreateAlertRule(ctx context.Context, rule *domain.AlertRule) (int, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO alert_rules (watchlist_id, symbol, type, level, percent)
+		 VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		rule.WatchlistId, rule.Symbol, rule.Type, rule.Level, rule.Percent).Scan(&id)
+	return id, err
+}
+
+func (r *alertRuleRepository) GetAlertRules(ctx context.Context, watchlistIds []int) (map[int][]*domain.AlertRule, error) {
+	rules := make(map[int][]*domain.AlertRule, len(watchlistIds))
+	if len(watchlistIds) == 0 {
+		return rules, nil
+	}
+
+	var list []*domain.AlertRule
+	err := r.db.SelectContext(ctx, &list,
+		`SELECT * FROM alert_rules WHERE watchlist_id = ANY($1) ORDER BY created_at, id`,
+		pq.Array(watchlistIds))
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range list {
+		rules[rule.WatchlistId] = append(rules[rule.WatchlistId], rule)
+	}
+	return rules, nil
+}
+
+func (r *alertRuleRepository) GetAlertRulesCount(ctx context.Context, userId int) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count,
+		`SELECT COUNT(*) FROM alert_rules a INNER JOIN watchlists w ON w.id = a.watchlist_id WHERE w.user_id = $1`,
+		userId)
+	return count, err
+}
+
+func (r *alertRuleRepository) DeleteAlertRule(ctx context.Context, watchlistId, ruleId int) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM alert_rules WHERE id = $1 AND watchlist_id = $2`,
+		ruleId, watchlistId)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+func (r *alertRuleRepository) GetAllAlertRules(ctx context.Context) ([]*domain.AlertRule, error) {
+	rules := make([]*domain.AlertRule, 0)
+	err := r.db.SelectContext(ctx, &rules,
+		`SELECT a.*, w.user_id FROM alert_rules a
+		 INNER JOIN watchlists w ON w.id = a.watchlist_id
+		 ORDER BY a.symbol, a.id`)
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *alertRuleRepository) UpdateAlertState(ctx context.Context, rule *domain.AlertRule) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE alert_rules SET last_price = $3, last_sentiment = $4, triggered_at = $5, revision = revision + 1
+		 WHERE id = $1 AND revision = $2`,
+		rule.Id, rule.Revision, rule.LastPrice, rule.LastSentiment, rule.TriggeredAt)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
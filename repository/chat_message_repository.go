@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+type ChatMessageRepository interface {
+	CreateMessage(ctx context.Context, message *domain.ChatMessage) (*domain.ChatMessage, error)
+	GetMessagesByTicker(ctx context.Context, ticker string, limit, offset int) ([]*domain.ChatMessage, error)
+	GetMessagesCount(ctx context.Context, ticker string) (int, error)
+}
+
+type chatMessageRepository struct {
+	db *sqlx.DB
+}
+
+func NewChatMessageRepository(db *sqlx.DB) ChatMessageRepository {
+	return &chatMessageRepository{db: db}
+}
+
+func (r *chatMessageRepository) CreateMessage(ctx context.Context, message *domain.ChatMessage) (*domain.ChatMessage, error) {
+	created := domain.ChatMessage{}
+	err := r.db.GetContext(ctx, &created,
+		`INSERT INTO chat_messages (ticker, user_id, body) VALUES ($1, $2, $3) RETURNING *`,
+		message.Ticker, message.UserId, message.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (r *chatMessageRepository) GetMessagesByTicker(ctx context.Context, ticker string, limit, offset int) ([]*domain.ChatMessage, error) {
+	var messages []*domain.ChatMessage
+	err := r.db.SelectContext(ctx, &messages,
+		`SELECT * FROM chat_messages WHERE ticker = $1 ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3`,
+		ticker, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *chatMessageRepository) GetMessagesCount(ctx context.Context, ticker string) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM chat_messages WHERE ticker = $1`, ticker)
+	return count, err
+}
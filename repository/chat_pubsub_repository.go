@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+type ChatPubSubRepository interface {
+	Publish(ctx context.Context, message *domain.ChatMessageResponse) error
+	Subscribe(ctx context.Context, ticker string) (<-chan *domain.ChatMessageResponse, error)
+}
+
+type chatPubSubRepository struct {
+	redis *redis.Client
+}
+
+func NewChatPubSubRepository(redis *redis.Client) ChatPubSubRepository {
+	return &chatPubSubRepository{
+		redis: redis,
+	}
+}
+
+func chatChannel(ticker string) string {
+	return fmt.Sprintf("chat:room:%s", ticker)
+}
+
+// Publish fans a message out to every replica subscribed to the ticker room
+func (r *chatPubSubRepository) Publish(ctx context.Context, message *domain.ChatMessageResponse) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return r.redis.Publish(ctx, chatChannel(message.Ticker), payload).Err()
+}
+
+// Subscribe listens on the ticker room channel until ctx is cancelled.
+// The returned channel is closed once the subscription ends.
+func (r *chatPubSubRepository) Subscribe(ctx context.Context, ticker string) (<-chan *domain.ChatMessageResponse, error) {
+	pubsub := r.redis.Subscribe(ctx, chatChannel(ticker))
+
+	// Wait for the subscription to be confirmed so no message published
+	// right after joining is missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	out := make(chan *domain.ChatMessageResponse)
+	go func() {
+		defer close(out)
+		defer pubsub.Close()
+
+		in := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-in:
+				if !ok {
+					return
+				}
+				var message domain.ChatMessageResponse
+				if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+					log.Warnf("Dropping malformed chat message on %s: %v", msg.Channel, err)
+					continue
+				}
+				select {
+				case out <- &message:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
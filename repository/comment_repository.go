@@ -2,17 +2,41 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/Pro100-Almaz/trading-chat/domain"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type CommentRepository interface {
-	GetCommentsByPostId(ctx context.Context, postId, limit, offset int) ([]*domain.Comment, error)
+	// GetCommentsByPostId lists the top-level comments of a post, newest first
+	GetCommentsByPostId(ctx context.Context, postId int, page domain.PaginationParams) ([]*domain.Comment, error)
+	GetTopLevelCommentsCount(ctx context.Context, postId int) (int, error)
+	// GetReplies lists the direct replies to a comment, oldest first
+	GetReplies(ctx context.Context, parentId int, page domain.PaginationParams) ([]*domain.Comment, error)
+	// GetFirstReplies returns the oldest few direct replies to several comments, keyed by parent id
+	GetFirstReplies(ctx context.Context, parentIds []int, limit int) (map[int][]*domain.Comment, error)
+	// GetRepliesCounts counts the direct replies to several comments. Comments without replies are absent from the map.
+	GetRepliesCounts(ctx context.Context, parentIds []int) (map[int]int, error)
 	GetCommentById(ctx context.Context, id int) (*domain.Comment, error)
+	// CreateComment stores a comment. A reply whose parent is gone or deleted
+	// by then is not stored, sql.ErrNoRows is returned instead.
 	CreateComment(ctx context.Context, comment *domain.Comment) (*domain.Comment, error)
+	// UpdateComment stores the edited body and entities of a comment, keeping
+	// the version it replaces as a revision. Returns sql.ErrNoRows when the
+	// comment is gone or deleted.
+	UpdateComment(ctx context.Context, comment *domain.Comment) (*domain.Comment, error)
+	// GetCommentRevisions lists the earlier versions of a comment, oldest first
+	GetCommentRevisions(ctx context.Context, commentId int) ([]*domain.CommentRevision, error)
+	// DeleteComment deletes a comment. One with replies is blanked and kept as
+	// a placeholder instead, its revisions stay stored. Placeholders left
+	// without replies go with it. Returns sql.ErrNoRows when the comment is gone.
 	DeleteComment(ctx context.Context, id int) error
+	// GetCommentsCount counts the comments and replies of a post, deleted ones aside
 	GetCommentsCount(ctx context.Context, postId int) (int, error)
+	GetCommentsCounts(ctx context.Context, postIds []int) (map[int]int, error)
+	GetCommentEntities(ctx context.Context, commentIds []int) (map[int][]*domain.Entity, error)
 }
 
 type commentRepository struct {
@@ -23,17 +47,89 @@ func NewCommentRepository(db *sqlx.DB) CommentRepository {
 	return &commentRepository{db: db}
 }
 
-func (r *commentRepository) GetCommentsByPostId(ctx context.Context, postId, limit, offset int) ([]*domain.Comment, error) {
+func (r *commentRepository) GetCommentsByPostId(ctx context.Context, postId int, page domain.PaginationParams) ([]*domain.Comment, error) {
 	var comments []*domain.Comment
+	createdAt, id, offset := pageArgs(page)
 	err := r.db.SelectContext(ctx, &comments,
-		`SELECT * FROM comments WHERE post_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`,
-		postId, limit, offset)
+		`SELECT * FROM comments
+		 WHERE post_id = $1 AND parent_comment_id IS NULL AND `+keysetCondition("created_at", "id", 2, 3)+`
+		 ORDER BY created_at DESC, id DESC LIMIT $4 OFFSET $5`,
+		postId, createdAt, id, page.Limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	return comments, nil
 }
 
+func (r *commentRepository) GetTopLevelCommentsCount(ctx context.Context, postId int) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count,
+		`SELECT COUNT(*) FROM comments WHERE post_id = $1 AND parent_comment_id IS NULL`,
+		postId)
+	return count, err
+}
+
+func (r *commentRepository) GetReplies(ctx context.Context, parentId int, page domain.PaginationParams) ([]*domain.Comment, error) {
+	var comments []*domain.Comment
+	createdAt, id, offset := pageArgs(page)
+	err := r.db.SelectContext(ctx, &comments,
+		`SELECT * FROM comments WHERE parent_comment_id = $1 AND `+keysetAscCondition("created_at", "id", 2, 3)+`
+		 ORDER BY created_at, id LIMIT $4 OFFSET $5`,
+		parentId, createdAt, id, page.Limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (r *commentRepository) GetFirstReplies(ctx context.Context, parentIds []int, limit int) (map[int][]*domain.Comment, error) {
+	replies := make(map[int][]*domain.Comment, len(parentIds))
+	if len(parentIds) == 0 {
+		return replies, nil
+	}
+
+	var comments []*domain.Comment
+	err := r.db.SelectContext(ctx, &comments,
+		`SELECT * FROM comments WHERE id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY parent_comment_id ORDER BY created_at, id) AS position
+				FROM comments WHERE parent_comment_id = ANY($1)
+			) r WHERE position <= $2
+		 )
+		 ORDER BY created_at, id`,
+		pq.Array(parentIds), limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, comment := range comments {
+		replies[*comment.ParentCommentId] = append(replies[*comment.ParentCommentId], comment)
+	}
+	return replies, nil
+}
+
+func (r *commentRepository) GetRepliesCounts(ctx context.Context, parentIds []int) (map[int]int, error) {
+	counts := make(map[int]int, len(parentIds))
+	if len(parentIds) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		ParentId int `db:"parent_comment_id"`
+		Count    int `db:"count"`
+	}
+	err := r.db.SelectContext(ctx, &rows,
+		`SELECT parent_comment_id, COUNT(*) AS count FROM comments
+		 WHERE parent_comment_id = ANY($1) GROUP BY parent_comment_id`,
+		pq.Array(parentIds))
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.ParentId] = row.Count
+	}
+	return counts, nil
+}
+
 func (r *commentRepository) GetCommentById(ctx context.Context, id int) (*domain.Comment, error) {
 	comment := domain.Comment{}
 	err := r.db.GetContext(ctx, &comment, `SELECT * FROM comments WHERE id = $1`, id)
@@ -43,24 +139,211 @@ func (r *commentRepository) GetCommentById(ctx context.Context, id int) (*domain
 	return &comment, nil
 }
 
+// CreateComment stores a comment with the entities of its body
 func (r *commentRepository) CreateComment(ctx context.Context, comment *domain.Comment) (*domain.Comment, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Held until the reply is in, so that a delete of the parent waits and
+	// then sees it, rather than hard-deleting the parent and the reply with it
+	if comment.ParentCommentId != nil {
+		var parentId int
+		err = tx.GetContext(ctx, &parentId,
+			`SELECT id FROM comments WHERE id = $1 AND deleted_at IS NULL FOR SHARE`,
+			*comment.ParentCommentId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var id int
-	err := r.db.QueryRowContext(ctx,
-		`INSERT INTO comments (user_id, post_id, body) VALUES ($1, $2, $3) RETURNING id`,
-		comment.UserId, comment.PostId, comment.Body).Scan(&id)
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO comments (user_id, post_id, parent_comment_id, depth, body) VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		comment.UserId, comment.PostId, comment.ParentCommentId, comment.Depth, comment.Body).Scan(&id)
 	if err != nil {
 		return nil, err
 	}
+
+	if err := insertEntities(ctx, tx, "comment_entities", "comment_id", id, comment.Entities); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 	return r.GetCommentById(ctx, id)
 }
 
+func (r *commentRepository) UpdateComment(ctx context.Context, comment *domain.Comment) (*domain.Comment, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Locked so that concurrent edits each keep the version they replace. A
+	// comment deleted meanwhile keeps no revision and gets no body back.
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO comment_revisions (comment_id, body, created_at)
+		 SELECT id, body, COALESCE(edited_at, created_at) FROM comments WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`,
+		comment.Id)
+	if err != nil {
+		return nil, err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if rows == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	result, err = tx.ExecContext(ctx,
+		`UPDATE comments SET body = $2, edited_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL`,
+		comment.Id, comment.Body)
+	if err != nil {
+		return nil, err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if rows == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM comment_entities WHERE comment_id = $1`, comment.Id); err != nil {
+		return nil, err
+	}
+	if err := insertEntities(ctx, tx, "comment_entities", "comment_id", comment.Id, comment.Entities); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetCommentById(ctx, comment.Id)
+}
+
+func (r *commentRepository) GetCommentRevisions(ctx context.Context, commentId int) ([]*domain.CommentRevision, error) {
+	revisions := make([]*domain.CommentRevision, 0)
+	err := r.db.SelectContext(ctx, &revisions,
+		`SELECT * FROM comment_revisions WHERE comment_id = $1 ORDER BY created_at, id`,
+		commentId)
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
 func (r *commentRepository) DeleteComment(ctx context.Context, id int) error {
-	_, err := r.db.ExecContext(ctx, `DELETE FROM comments WHERE id = $1`, id)
-	return err
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Locked before looking for replies, a reply being created holds the
+	// parent until it is in
+	if err := lockComment(ctx, tx, id); err != nil {
+		return err
+	}
+
+	var hasReplies bool
+	err = tx.GetContext(ctx, &hasReplies, `SELECT EXISTS(SELECT 1 FROM comments WHERE parent_comment_id = $1)`, id)
+	if err != nil {
+		return err
+	}
+
+	if hasReplies {
+		_, err = tx.ExecContext(ctx, `UPDATE comments SET body = '', deleted_at = CURRENT_TIMESTAMP WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM comment_entities WHERE comment_id = $1`, id); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	// Walk up the thread, dropping the placeholders this leaves without replies
+	for {
+		var parentId sql.NullInt64
+		err = tx.QueryRowContext(ctx, `DELETE FROM comments WHERE id = $1 RETURNING parent_comment_id`, id).Scan(&parentId)
+		if err == sql.ErrNoRows {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if !parentId.Valid {
+			break
+		}
+
+		err = lockComment(ctx, tx, int(parentId.Int64))
+		if err == sql.ErrNoRows {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		var orphaned bool
+		err = tx.GetContext(ctx, &orphaned,
+			`SELECT deleted_at IS NOT NULL AND NOT EXISTS(SELECT 1 FROM comments WHERE parent_comment_id = $1)
+			 FROM comments WHERE id = $1`,
+			parentId.Int64)
+		if err != nil {
+			return err
+		}
+		if !orphaned {
+			break
+		}
+		id = int(parentId.Int64)
+	}
+	return tx.Commit()
+}
+
+// lockComment locks a comment for the rest of the transaction, sql.ErrNoRows when it is gone
+func lockComment(ctx context.Context, tx *sqlx.Tx, id int) error {
+	var lockedId int
+	return tx.GetContext(ctx, &lockedId, `SELECT id FROM comments WHERE id = $1 FOR UPDATE`, id)
 }
 
 func (r *commentRepository) GetCommentsCount(ctx context.Context, postId int) (int, error) {
 	var count int
-	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM comments WHERE post_id = $1`, postId)
+	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM comments WHERE post_id = $1 AND deleted_at IS NULL`, postId)
 	return count, err
 }
+
+// GetCommentsCounts returns comment counts for several posts in one query. Posts without comments are absent from the map.
+func (r *commentRepository) GetCommentsCounts(ctx context.Context, postIds []int) (map[int]int, error) {
+	counts := make(map[int]int, len(postIds))
+	if len(postIds) == 0 {
+		return counts, nil
+	}
+
+	query, args, err := sqlx.In(
+		`SELECT post_id, COUNT(*) FROM comments WHERE post_id IN (?) AND deleted_at IS NULL GROUP BY post_id`,
+		postIds)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postId, count int
+		if err := rows.Scan(&postId, &count); err != nil {
+			return nil, err
+		}
+		counts[postId] = count
+	}
+	return counts, rows.Err()
+}
+
+func (r *commentRepository) GetCommentEntities(ctx context.Context, commentIds []int) (map[int][]*domain.Entity, error) {
+	return selectEntities(ctx, r.db, "comment_entities", "comment_id", commentIds)
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+type ConversationMemberRepository interface {
+	IsMember(ctx context.Context, conversationId, userId int) (bool, error)
+	GetMembers(ctx context.Context, conversationIds []int) ([]*domain.ConversationMember, error)
+	MarkAsRead(ctx context.Context, conversationId, userId, messageId int) error
+}
+
+type conversationMemberRepository struct {
+	db *sqlx.DB
+}
+
+func NewConversationMemberRepository(db *sqlx.DB) ConversationMemberRepository {
+	return &conversationMemberRepository{db: db}
+}
+
+func (r *conversationMemberRepository) IsMember(ctx context.Context, conversationId, userId int) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists,
+		`SELECT EXISTS(SELECT 1 FROM conversation_members WHERE conversation_id = $1 AND user_id = $2)`,
+		conversationId, userId)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+	return exists, nil
+}
+
+// GetMembers returns the members of all given conversations
+func (r *conversationMemberRepository) GetMembers(ctx context.Context, conversationIds []int) ([]*domain.ConversationMember, error) {
+	var members []*domain.ConversationMember
+	if len(conversationIds) == 0 {
+		return members, nil
+	}
+
+	query, args, err := sqlx.In(
+		`SELECT * FROM conversation_members WHERE conversation_id IN (?) ORDER BY joined_at, user_id`,
+		conversationIds)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.SelectContext(ctx, &members, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// MarkAsRead moves the member's read marker forward; it never moves backwards
+func (r *conversationMemberRepository) MarkAsRead(ctx context.Context, conversationId, userId, messageId int) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE conversation_members
+		 SET last_read_message_id = GREATEST(last_read_message_id, $3)
+		 WHERE conversation_id = $1 AND user_id = $2`,
+		conversationId, userId, messageId)
+	return err
+}
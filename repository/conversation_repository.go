@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+type ConversationRepository interface {
+	CreateConversation(ctx context.Context, conversation *domain.Conversation, memberIds []int) (*domain.Conversation, error)
+	// CreateDirectConversation returns the one-to-one conversation between two
+	// users, creating it unless there is one. Concurrent calls for the same
+	// pair end up with the same conversation.
+	CreateDirectConversation(ctx context.Context, userId, otherUserId int) (*domain.Conversation, error)
+	GetConversationById(ctx context.Context, id int) (*domain.Conversation, error)
+	FindDirectConversation(ctx context.Context, userId, otherUserId int) (*domain.Conversation, error)
+	GetInbox(ctx context.Context, userId, limit, offset int) ([]*domain.InboxEntry, error)
+	GetInboxCount(ctx context.Context, userId int) (int, error)
+}
+
+type conversationRepository struct {
+	db *sqlx.DB
+}
+
+func NewConversationRepository(db *sqlx.DB) ConversationRepository {
+	return &conversationRepository{db: db}
+}
+
+// CreateConversation inserts the conversation and all of its members in one transaction
+func (r *conversationRepository) CreateConversation(ctx context.Context, conversation *domain.Conversation, memberIds []int) (*domain.Conversation, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	created := domain.Conversation{}
+	err = tx.GetContext(ctx, &created,
+		`INSERT INTO conversations (is_group, created_by) VALUES ($1, $2) RETURNING *`,
+		conversation.IsGroup, conversation.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, memberId := range memberIds {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO conversation_members (conversation_id, user_id) VALUES ($1, $2)`,
+			created.Id, memberId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (r *conversationRepository) CreateDirectConversation(ctx context.Context, userId, otherUserId int) (*domain.Conversation, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// A concurrent insert of the pair makes this one wait for it, then do nothing
+	created := domain.Conversation{}
+	err = tx.GetContext(ctx, &created,
+		`INSERT INTO conversations (is_group, created_by, direct_user_low, direct_user_high) VALUES (FALSE, $1, $2, $3)
+		 ON CONFLICT (direct_user_low, direct_user_high) DO NOTHING
+		 RETURNING *`,
+		userId, min(userId, otherUserId), max(userId, otherUserId))
+	if err == sql.ErrNoRows {
+		return r.FindDirectConversation(ctx, userId, otherUserId)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, memberId := range []int{userId, otherUserId} {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO conversation_members (conversation_id, user_id) VALUES ($1, $2)`,
+			created.Id, memberId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (r *conversationRepository) GetConversationById(ctx context.Context, id int) (*domain.Conversation, error) {
+	conversation := domain.Conversation{}
+	err := r.db.GetContext(ctx, &conversation, `SELECT * FROM conversations WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// FindDirectConversation returns the one-to-one conversation between two users, or sql.ErrNoRows
+func (r *conversationRepository) FindDirectConversation(ctx context.Context, userId, otherUserId int) (*domain.Conversation, error) {
+	conversation := domain.Conversation{}
+	err := r.db.GetContext(ctx, &conversation,
+		`SELECT * FROM conversations WHERE direct_user_low = $1 AND direct_user_high = $2`,
+		min(userId, otherUserId), max(userId, otherUserId))
+	if err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+func (r *conversationRepository) GetInbox(ctx context.Context, userId, limit, offset int) ([]*domain.InboxEntry, error) {
+	var entries []*domain.InboxEntry
+	err := r.db.SelectContext(ctx, &entries,
+		`SELECT c.*,
+		        (SELECT COUNT(*) FROM messages m
+		         WHERE m.conversation_id = c.id
+		           AND m.id > cm.last_read_message_id
+		           AND m.user_id != cm.user_id) AS unread_count
+		 FROM conversations c
+		 INNER JOIN conversation_members cm ON cm.conversation_id = c.id
+		 WHERE cm.user_id = $1
+		 ORDER BY COALESCE(c.last_message_at, c.created_at) DESC, c.id DESC
+		 LIMIT $2 OFFSET $3`,
+		userId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *conversationRepository) GetInboxCount(ctx context.Context, userId int) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count,
+		`SELECT COUNT(*) FROM conversation_members WHERE user_id = $1`,
+		userId)
+	return count, err
+}
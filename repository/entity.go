@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// insertEntities stores the entities of a post or comment body in table,
+// keyed by ownerColumn
+func insertEntities(ctx context.Context, tx *sqlx.Tx, table, ownerColumn string, ownerId int, entities []*domain.Entity) error {
+	for _, entity := range entities {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO `+table+` (`+ownerColumn+`, type, text, start_offset, end_offset, user_id)
+			 VALUES ($1, $2, $3, $4, $5, $6)`,
+			ownerId, entity.Type, entity.Text, entity.Start, entity.End, entity.UserId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectEntities loads the entities of many posts or comments, each in body order
+func selectEntities(ctx context.Context, db *sqlx.DB, table, ownerColumn string, ownerIds []int) (map[int][]*domain.Entity, error) {
+	entities := make(map[int][]*domain.Entity, len(ownerIds))
+	if len(ownerIds) == 0 {
+		return entities, nil
+	}
+
+	var rows []struct {
+		OwnerId int `db:"owner_id"`
+		domain.Entity
+	}
+	err := db.SelectContext(ctx, &rows,
+		`SELECT `+ownerColumn+` AS owner_id, type, text, start_offset, end_offset, user_id FROM `+table+`
+		 WHERE `+ownerColumn+` = ANY($1) ORDER BY start_offset`,
+		pq.Array(ownerIds))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		entity := row.Entity
+		entities[row.OwnerId] = append(entities[row.OwnerId], &entity)
+	}
+	return entities, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+const feedEventsChannel = "feed:events"
+
+type FeedEventRepository interface {
+	Publish(ctx context.Context, event *domain.FeedEvent) error
+	Subscribe(ctx context.Context) (<-chan *domain.FeedEvent, error)
+}
+
+type feedEventRepository struct {
+	redis *redis.Client
+}
+
+func NewFeedEventRepository(redis *redis.Client) FeedEventRepository {
+	return &feedEventRepository{
+		redis: redis,
+	}
+}
+
+// Publish broadcasts a feed event to the live streams of every replica
+func (r *feedEventRepository) Publish(ctx context.Context, event *domain.FeedEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return r.redis.Publish(ctx, feedEventsChannel, payload).Err()
+}
+
+// Subscribe listens for feed events until ctx is cancelled.
+// The returned channel is closed once the subscription ends.
+func (r *feedEventRepository) Subscribe(ctx context.Context) (<-chan *domain.FeedEvent, error) {
+	pubsub := r.redis.Subscribe(ctx, feedEventsChannel)
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	out := make(chan *domain.FeedEvent)
+	go func() {
+		defer close(out)
+		defer pubsub.Close()
+
+		in := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-in:
+				if !ok {
+					return
+				}
+				var event domain.FeedEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					log.Warnf("Dropping malformed feed event: %v", err)
+					continue
+				}
+				select {
+				case out <- &event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
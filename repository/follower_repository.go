@@ -12,10 +12,13 @@ type FollowerRepository interface {
 	Follow(ctx context.Context, followerId, followingId int) error
 	Unfollow(ctx context.Context, followerId, followingId int) error
 	IsFollowing(ctx context.Context, followerId, followingId int) (bool, error)
-	GetFollowers(ctx context.Context, userId, limit, offset int) ([]*domain.User, error)
-	GetFollowing(ctx context.Context, userId, limit, offset int) ([]*domain.User, error)
+	GetFollowers(ctx context.Context, userId int, page domain.PaginationParams) ([]*domain.FollowListEntry, error)
+	GetFollowing(ctx context.Context, userId int, page domain.PaginationParams) ([]*domain.FollowListEntry, error)
 	GetFollowersCount(ctx context.Context, userId int) (int, error)
 	GetFollowingCount(ctx context.Context, userId int) (int, error)
+	GetFollowingIds(ctx context.Context, userId int) ([]int, error)
+	GetFollowerIds(ctx context.Context, userId int) ([]int, error)
+	GetLargeFollowingIds(ctx context.Context, userId, minFollowers int) ([]int, error)
 }
 
 type followerRepository struct {
@@ -51,30 +54,32 @@ func (r *followerRepository) IsFollowing(ctx context.Context, followerId, follow
 	return exists, nil
 }
 
-func (r *followerRepository) GetFollowers(ctx context.Context, userId, limit, offset int) ([]*domain.User, error) {
-	var users []*domain.User
+func (r *followerRepository) GetFollowers(ctx context.Context, userId int, page domain.PaginationParams) ([]*domain.FollowListEntry, error) {
+	var users []*domain.FollowListEntry
+	createdAt, id, offset := pageArgs(page)
 	err := r.db.SelectContext(ctx, &users,
-		`SELECT u.* FROM users u
+		`SELECT u.*, f.id AS follow_id, f.created_at AS followed_at FROM users u
 		 INNER JOIN followers f ON u.id = f.follower_id
-		 WHERE f.following_id = $1
-		 ORDER BY f.created_at DESC
-		 LIMIT $2 OFFSET $3`,
-		userId, limit, offset)
+		 WHERE f.following_id = $1 AND `+keysetCondition("f.created_at", "f.id", 2, 3)+`
+		 ORDER BY f.created_at DESC, f.id DESC
+		 LIMIT $4 OFFSET $5`,
+		userId, createdAt, id, page.Limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	return users, nil
 }
 
-func (r *followerRepository) GetFollowing(ctx context.Context, userId, limit, offset int) ([]*domain.User, error) {
-	var users []*domain.User
+func (r *followerRepository) GetFollowing(ctx context.Context, userId int, page domain.PaginationParams) ([]*domain.FollowListEntry, error) {
+	var users []*domain.FollowListEntry
+	createdAt, id, offset := pageArgs(page)
 	err := r.db.SelectContext(ctx, &users,
-		`SELECT u.* FROM users u
+		`SELECT u.*, f.id AS follow_id, f.created_at AS followed_at FROM users u
 		 INNER JOIN followers f ON u.id = f.following_id
-		 WHERE f.follower_id = $1
-		 ORDER BY f.created_at DESC
-		 LIMIT $2 OFFSET $3`,
-		userId, limit, offset)
+		 WHERE f.follower_id = $1 AND `+keysetCondition("f.created_at", "f.id", 2, 3)+`
+		 ORDER BY f.created_at DESC, f.id DESC
+		 LIMIT $4 OFFSET $5`,
+		userId, createdAt, id, page.Limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -96,3 +101,39 @@ func (r *followerRepository) GetFollowingCount(ctx context.Context, userId int)
 		userId)
 	return count, err
 }
+
+func (r *followerRepository) GetFollowingIds(ctx context.Context, userId int) ([]int, error) {
+	var ids []int
+	err := r.db.SelectContext(ctx, &ids,
+		`SELECT following_id FROM followers WHERE follower_id = $1`,
+		userId)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *followerRepository) GetFollowerIds(ctx context.Context, userId int) ([]int, error) {
+	var ids []int
+	err := r.db.SelectContext(ctx, &ids,
+		`SELECT follower_id FROM followers WHERE following_id = $1`,
+		userId)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetLargeFollowingIds returns the followed users that have at least minFollowers followers
+func (r *followerRepository) GetLargeFollowingIds(ctx context.Context, userId, minFollowers int) ([]int, error) {
+	var ids []int
+	err := r.db.SelectContext(ctx, &ids,
+		`SELECT f.following_id FROM followers f
+		 WHERE f.follower_id = $1
+		 AND (SELECT COUNT(*) FROM followers c WHERE c.following_id = f.following_id) >= $2`,
+		userId, minFollowers)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -12,6 +12,8 @@ type LikeRepository interface {
 	UnlikePost(ctx context.Context, userId, postId int) error
 	IsLiked(ctx context.Context, userId, postId int) (bool, error)
 	GetLikesCount(ctx context.Context, postId int) (int, error)
+	GetLikesCounts(ctx context.Context, postIds []int) (map[int]int, error)
+	GetLikedPostIds(ctx context.Context, userId int, postIds []int) (map[int]bool, error)
 }
 
 type likeRepository struct {
@@ -54,3 +56,59 @@ func (r *likeRepository) GetLikesCount(ctx context.Context, postId int) (int, er
 		postId)
 	return count, err
 }
+
+// GetLikesCounts returns like counts for several posts in one query. Posts without likes are absent from the map.
+func (r *likeRepository) GetLikesCounts(ctx context.Context, postIds []int) (map[int]int, error) {
+	counts := make(map[int]int, len(postIds))
+	if len(postIds) == 0 {
+		return counts, nil
+	}
+
+	query, args, err := sqlx.In(
+		`SELECT post_id, COUNT(*) FROM likes WHERE post_id IN (?) GROUP BY post_id`,
+		postIds)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postId, count int
+		if err := rows.Scan(&postId, &count); err != nil {
+			return nil, err
+		}
+		counts[postId] = count
+	}
+	return counts, rows.Err()
+}
+
+// GetLikedPostIds returns which of the given posts the user has liked
+func (r *likeRepository) GetLikedPostIds(ctx context.Context, userId int, postIds []int) (map[int]bool, error) {
+	liked := make(map[int]bool)
+	if len(postIds) == 0 {
+		return liked, nil
+	}
+
+	query, args, err := sqlx.In(
+		`SELECT post_id FROM likes WHERE user_id = ? AND post_id IN (?)`,
+		userId, postIds)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	err = r.db.SelectContext(ctx, &ids, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		liked[id] = true
+	}
+	return liked, nil
+}
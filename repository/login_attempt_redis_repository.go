@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Failures of a subject in the current window
+	attemptFailuresKeyPrefix = "auth:failures:"
+	// Set while a subject is backing off or locked out, expiring with it
+	attemptLockKeyPrefix = "auth:lock:"
+	// Lockouts of a subject in the last lockoutsTTL, each lasting longer
+	attemptLockoutsKeyPrefix = "auth:lockouts:"
+	attemptLockoutsTTL       = 24 * time.Hour
+)
+
+// LoginAttemptRepository counts failed attempts at logging in or verifying an
+// email, per subject such as "login:account:jane@example.com" or "login:ip:203.0.113.7"
+type LoginAttemptRepository interface {
+	// GetRetryAfter returns how long the longest lock of the subjects lasts, 0 when none is locked
+	GetRetryAfter(ctx context.Context, subjects ...string) (time.Duration, error)
+	// RecordFailure counts a failure of a subject. Failures are forgotten
+	// window after the first one. Returns the failures so far along with the
+	// lockouts of the subject in the last day.
+	RecordFailure(ctx context.Context, subject string, window time.Duration) (failures int, lockouts int, err error)
+	// Lock makes the subject wait for a while. A lockout also starts its failures over and counts towards the next lockout.
+	Lock(ctx context.Context, subject string, duration time.Duration, lockout bool) error
+	// ClearFailures forgets the failures of a subject, its lockouts still count
+	ClearFailures(ctx context.Context, subject string) error
+}
+
+type loginAttemptRedisRepository struct {
+	redis *redis.Client
+}
+
+func NewLoginAttemptRedisRepository(redisClient *redis.Client) LoginAttemptRepository {
+	return &loginAttemptRedisRepository{redis: redisClient}
+}
+
+func (r *loginAttemptRedisRepository) GetRetryAfter(ctx context.Context, subjects ...string) (time.Duration, error) {
+	pipe := r.redis.Pipeline()
+	ttls := make([]*redis.DurationCmd, len(subjects))
+	for i, subject := range subjects {
+		ttls[i] = pipe.PTTL(ctx, attemptLockKeyPrefix+subject)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	var retryAfter time.Duration
+	for _, ttl := range ttls {
+		// Negative for keys that don't exist or don't expire
+		if ttl.Val() > retryAfter {
+			retryAfter = ttl.Val()
+		}
+	}
+	return retryAfter, nil
+}
+
+func (r *loginAttemptRedisRepository) RecordFailure(ctx context.Context, subject string, window time.Duration) (int, int, error) {
+	pipe := r.redis.TxPipeline()
+	failures := pipe.Incr(ctx, attemptFailuresKeyPrefix+subject)
+	pipe.ExpireNX(ctx, attemptFailuresKeyPrefix+subject, window)
+	lockouts := pipe.Get(ctx, attemptLockoutsKeyPrefix+subject)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return 0, 0, err
+	}
+
+	lockoutCount, err := lockouts.Int()
+	if err != nil && err != redis.Nil {
+		return 0, 0, err
+	}
+	return int(failures.Val()), lockoutCount, nil
+}
+
+func (r *loginAttemptRedisRepository) Lock(ctx context.Context, subject string, duration time.Duration, lockout bool) error {
+	pipe := r.redis.TxPipeline()
+	pipe.Set(ctx, attemptLockKeyPrefix+subject, 1, duration)
+	if lockout {
+		pipe.Del(ctx, attemptFailuresKeyPrefix+subject)
+		pipe.Incr(ctx, attemptLockoutsKeyPrefix+subject)
+		pipe.Expire(ctx, attemptLockoutsKeyPrefix+subject, attemptLockoutsTTL)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *loginAttemptRedisRepository) ClearFailures(ctx context.Context, subject string) error {
+	return r.redis.Del(ctx, attemptFailuresKeyPrefix+subject).Err()
+}
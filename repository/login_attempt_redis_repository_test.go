@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLoginAttemptRepo(t *testing.T) (LoginAttemptRepository, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewLoginAttemptRedisRepository(client), server
+}
+
+func TestLoginAttemptFailuresExpireAfterWindow(t *testing.T) {
+	repo, server := newTestLoginAttemptRepo(t)
+	ctx := context.Background()
+
+	for i := 1; i <= 3; i++ {
+		failures, lockouts, err := repo.RecordFailure(ctx, "login:account:a", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, i, failures)
+		assert.Zero(t, lockouts)
+	}
+
+	// The window runs from the first failure, later ones don't extend it
+	server.FastForward(time.Minute)
+	failures, _, err := repo.RecordFailure(ctx, "login:account:a", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, failures)
+}
+
+func TestLoginAttemptLockout(t *testing.T) {
+	repo, server := newTestLoginAttemptRepo(t)
+	ctx := context.Background()
+
+	_, _, err := repo.RecordFailure(ctx, "login:account:a", time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, repo.Lock(ctx, "login:account:a", 10*time.Minute, true))
+
+	retryAfter, err := repo.GetRetryAfter(ctx, "login:account:a", "login:ip:203.0.113.7")
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, retryAfter)
+
+	// A lockout starts the failures over and counts towards the next one
+	failures, lockouts, err := repo.RecordFailure(ctx, "login:account:a", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, failures)
+	assert.Equal(t, 1, lockouts)
+
+	server.FastForward(10 * time.Minute)
+	retryAfter, err = repo.GetRetryAfter(ctx, "login:account:a", "login:ip:203.0.113.7")
+	require.NoError(t, err)
+	assert.Zero(t, retryAfter)
+}
+
+func TestLoginAttemptClearFailuresKeepsLockouts(t *testing.T) {
+	repo, _ := newTestLoginAttemptRepo(t)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Lock(ctx, "login:account:a", time.Minute, true))
+	_, _, err := repo.RecordFailure(ctx, "login:account:a", time.Minute)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.ClearFailures(ctx, "login:account:a"))
+
+	failures, lockouts, err := repo.RecordFailure(ctx, "login:account:a", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, failures)
+	assert.Equal(t, 1, lockouts)
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+type MessageRepository interface {
+	CreateMessage(ctx context.Context, message *domain.Message) (*domain.Message, error)
+	GetMessagesByConversationId(ctx context.Context, conversationId, limit, offset int) ([]*domain.Message, error)
+	GetMessagesCount(ctx context.Context, conversationId int) (int, error)
+	GetLastMessages(ctx context.Context, conversationIds []int) (map[int]*domain.Message, error)
+	GetLatestMessageId(ctx context.Context, conversationId int) (int, error)
+}
+
+type messageRepository struct {
+	db *sqlx.DB
+}
+
+func NewMessageRepository(db *sqlx.DB) MessageRepository {
+	return &messageRepository{db: db}
+}
+
+// CreateMessage stores the message and bumps the conversation in its members' inboxes
+func (r *messageRepository) CreateMessage(ctx context.Context, message *domain.Message) (*domain.Message, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	created := domain.Message{}
+	err = tx.GetContext(ctx, &created,
+		`INSERT INTO messages (conversation_id, user_id, body) VALUES ($1, $2, $3) RETURNING *`,
+		message.ConversationId, message.UserId, message.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE conversations SET last_message_at = $2 WHERE id = $1`,
+		created.ConversationId, created.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (r *messageRepository) GetMessagesByConversationId(ctx context.Context, conversationId, limit, offset int) ([]*domain.Message, error) {
+	var messages []*domain.Message
+	err := r.db.SelectContext(ctx, &messages,
+		`SELECT * FROM messages WHERE conversation_id = $1 ORDER BY id DESC LIMIT $2 OFFSET $3`,
+		conversationId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *messageRepository) GetMessagesCount(ctx context.Context, conversationId int) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM messages WHERE conversation_id = $1`, conversationId)
+	return count, err
+}
+
+// GetLastMessages returns the newest message of each conversation, keyed by conversation id
+func (r *messageRepository) GetLastMessages(ctx context.Context, conversationIds []int) (map[int]*domain.Message, error) {
+	lastMessages := make(map[int]*domain.Message)
+	if len(conversationIds) == 0 {
+		return lastMessages, nil
+	}
+
+	query, args, err := sqlx.In(
+		`SELECT DISTINCT ON (conversation_id) * FROM messages
+		 WHERE conversation_id IN (?)
+		 ORDER BY conversation_id, id DESC`,
+		conversationIds)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []*domain.Message
+	err = r.db.SelectContext(ctx, &messages, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, message := range messages {
+		lastMessages[message.ConversationId] = message
+	}
+	return lastMessages, nil
+}
+
+func (r *messageRepository) GetLatestMessageId(ctx context.Context, conversationId int) (int, error) {
+	var id int
+	err := r.db.GetContext(ctx, &id,
+		`SELECT COALESCE(MAX(id), 0) FROM messages WHERE conversation_id = $1`,
+		conversationId)
+	return id, err
+}
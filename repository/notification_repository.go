@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Number of actors listed on a notification group
+const groupActorsShown = 3
+
+// notificationGroup is what notifications are grouped by, alerts stand alone
+const notificationGroup = `type, post_id, CASE WHEN type = 'alert' THEN id END`
+
+type NotificationRepository interface {
+	// CreateNotification stores a notification. Repeats of a follow or like by
+	// the same actor are ignored, repeated comments and mentions bring the
+	// group back as unread. Alerts are always stored.
+	CreateNotification(ctx context.Context, notification *domain.Notification) error
+	GetNotificationGroups(ctx context.Context, userId int, page domain.PaginationParams) ([]*domain.NotificationGroup, error)
+	GetNotificationGroupsCount(ctx context.Context, userId int) (int, error)
+	GetUnreadCount(ctx context.Context, userId int) (int, error)
+	MarkGroupRead(ctx context.Context, userId, notificationId int) error
+	MarkAllRead(ctx context.Context, userId int) error
+}
+
+type notificationRepository struct {
+	db *sqlx.DB
+}
+
+func NewNotificationRepository(db *sqlx.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) CreateNotification(ctx context.Context, notification *domain.Notification) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO notifications (user_id, actor_id, type, post_id, comment_id, ticker, alert_rule_id, detail)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 ON CONFLICT (user_id, type, COALESCE(post_id, 0), actor_id) DO UPDATE
+		 SET comment_id = EXCLUDED.comment_id, created_at = NOW(), read_at = NULL
+		 WHERE notifications.type IN ('comment', 'mention')`,
+		notification.UserId, notification.ActorId, notification.Type, notification.PostId, notification.CommentId,
+		notification.Ticker, notification.AlertRuleId, notification.Detail)
+	return err
+}
+
+func (r *notificationRepository) GetNotificationGroups(ctx context.Context, userId int, page domain.PaginationParams) ([]*domain.NotificationGroup, error) {
+	groups := make([]*domain.NotificationGroup, 0)
+	createdAt, id, offset := pageArgs(page)
+	err := r.db.SelectContext(ctx, &groups,
+		`SELECT * FROM (
+			SELECT MAX(id) AS id, type, post_id,
+			       (ARRAY_AGG(comment_id ORDER BY created_at DESC, id DESC))[1] AS comment_id,
+			       MAX(ticker) AS ticker, MAX(alert_rule_id) AS alert_rule_id, MAX(detail) AS detail,
+			       (ARRAY_AGG(actor_id ORDER BY created_at DESC, id DESC) FILTER (WHERE actor_id IS NOT NULL))[1:$6] AS actor_ids,
+			       COUNT(actor_id) AS actors_count,
+			       BOOL_OR(read_at IS NULL) AS unread,
+			       MAX(created_at) AS created_at
+			FROM notifications WHERE user_id = $1
+			GROUP BY `+notificationGroup+`
+		 ) g
+		 WHERE `+keysetCondition("created_at", "id", 2, 3)+`
+		 ORDER BY created_at DESC, id DESC LIMIT $4 OFFSET $5`,
+		userId, createdAt, id, page.Limit, offset, groupActorsShown)
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (r *notificationRepository) GetNotificationGroupsCount(ctx context.Context, userId int) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count,
+		`SELECT COUNT(*) FROM (SELECT 1 FROM notifications WHERE user_id = $1 GROUP BY `+notificationGroup+`) g`,
+		userId)
+	return count, err
+}
+
+func (r *notificationRepository) GetUnreadCount(ctx context.Context, userId int) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count,
+		`SELECT COUNT(*) FROM (
+			SELECT 1 FROM notifications WHERE user_id = $1 AND read_at IS NULL GROUP BY `+notificationGroup+`
+		 ) g`,
+		userId)
+	return count, err
+}
+
+func (r *notificationRepository) MarkGroupRead(ctx context.Context, userId, notificationId int) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE notifications n SET read_at = NOW()
+		 FROM notifications g
+		 WHERE g.id = $2 AND g.user_id = $1
+		   AND n.user_id = $1
+		   AND (n.type, n.post_id, CASE WHEN n.type = 'alert' THEN n.id END)
+		       IS NOT DISTINCT FROM (g.type, g.post_id, CASE WHEN g.type = 'alert' THEN g.id END)
+		   AND n.read_at IS NULL`,
+		userId, notificationId)
+	return err
+}
+
+func (r *notificationRepository) MarkAllRead(ctx context.Context, userId int) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL`,
+		userId)
+	return err
+}
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/utils"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// These tests need a throwaway PostgreSQL database, for example:
+//
+//	TEST_DB_DSN="host=localhost port=5433 user=postgres password=postgres dbname=trading_chat_test sslmode=disable" \
+//	go test ./repository -run Notification
+//
+// The schema is migrated on start and the rows created are removed afterwards.
+
+type notificationFixture struct {
+	t      *testing.T
+	db     *sqlx.DB
+	repo   NotificationRepository
+	prefix string
+	users  int
+}
+
+func newNotificationFixture(t *testing.T) *notificationFixture {
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DB_DSN is not set")
+	}
+
+	db, err := sqlx.Connect("postgres", dsn)
+	require.NoError(t, err)
+	utils.MigrateDB(db)
+
+	prefix := fmt.Sprintf("test-%d-", time.Now().UnixNano())
+	t.Cleanup(func() {
+		db.MustExec(`DELETE FROM users WHERE email LIKE $1`, prefix+"%")
+		db.Close()
+	})
+	return &notificationFixture{t: t, db: db, repo: NewNotificationRepository(db), prefix: prefix}
+}
+
+func (f *notificationFixture) user() int {
+	f.users++
+	var id int
+	require.NoError(f.t, f.db.Get(&id,
+		`INSERT INTO users (email, name) VALUES ($1, $2) RETURNING id`,
+		fmt.Sprintf("%s%d@example.com", f.prefix, f.users), fmt.Sprintf("User %d", f.users)))
+	return id
+}
+
+func (f *notificationFixture) post(userId int) int {
+	var id int
+	require.NoError(f.t, f.db.Get(&id,
+		`INSERT INTO posts (user_id, ticker, body) VALUES ($1, 'TEST', 'post') RETURNING id`, userId))
+	return id
+}
+
+func (f *notificationFixture) comment(userId, postId int) int {
+	var id int
+	require.NoError(f.t, f.db.Get(&id,
+		`INSERT INTO comments (user_id, post_id, body) VALUES ($1, $2, 'comment') RETURNING id`, userId, postId))
+	return id
+}
+
+func (f *notificationFixture) notify(notification *domain.Notification) {
+	require.NoError(f.t, f.repo.CreateNotification(context.Background(), notification))
+}
+
+func (f *notificationFixture) groups(userId int) []*domain.NotificationGroup {
+	groups, err := f.repo.GetNotificationGroups(context.Background(), userId, domain.PaginationParams{Limit: 20})
+	require.NoError(f.t, err)
+	return groups
+}
+
+func (f *notificationFixture) unread(userId int) int {
+	count, err := f.repo.GetUnreadCount(context.Background(), userId)
+	require.NoError(f.t, err)
+	return count
+}
+
+// findGroup returns the group of a type on a post, nil post for follows
+func findGroup(groups []*domain.NotificationGroup, kind string, postId *int) *domain.NotificationGroup {
+	for _, group := range groups {
+		if group.Type != kind {
+			continue
+		}
+		if (group.PostId == nil && postId == nil) || (group.PostId != nil && postId != nil && *group.PostId == *postId) {
+			return group
+		}
+	}
+	return nil
+}
+
+func TestNotificationsGroupByTypeAndPost(t *testing.T) {
+	f := newNotificationFixture(t)
+	ctx := context.Background()
+	owner, alice, bob, carol, dave := f.user(), f.user(), f.user(), f.user(), f.user()
+	first, second := f.post(owner), f.post(owner)
+
+	for _, actor := range []int{alice, bob, carol, dave} {
+		f.notify(&domain.Notification{UserId: owner, ActorId: &actor, Type: domain.NotificationLike, PostId: &first})
+	}
+	f.notify(&domain.Notification{UserId: owner, ActorId: &alice, Type: domain.NotificationLike, PostId: &second})
+	f.notify(&domain.Notification{UserId: owner, ActorId: &alice, Type: domain.NotificationFollow})
+	f.notify(&domain.Notification{UserId: owner, ActorId: &bob, Type: domain.NotificationFollow})
+	// Alerts are never grouped
+	for _, detail := range []string{"above 200", "above 210"} {
+		ticker := "TEST"
+		f.notify(&domain.Notification{UserId: owner, Type: domain.NotificationAlert, Ticker: &ticker, Detail: &detail})
+	}
+	// Someone else's notifications stay out
+	f.notify(&domain.Notification{UserId: alice, ActorId: &bob, Type: domain.NotificationFollow})
+
+	groups := f.groups(owner)
+	require.Len(t, groups, 5)
+
+	likes := findGroup(groups, domain.NotificationLike, &first)
+	require.NotNil(t, likes)
+	assert.Equal(t, 4, likes.ActorsCount)
+	// Most recent first, three shown
+	assert.Equal(t, []int64{int64(dave), int64(carol), int64(bob)}, []int64(likes.ActorIds))
+	assert.True(t, likes.Unread)
+
+	other := findGroup(groups, domain.NotificationLike, &second)
+	require.NotNil(t, other)
+	assert.Equal(t, 1, other.ActorsCount)
+
+	follows := findGroup(groups, domain.NotificationFollow, nil)
+	require.NotNil(t, follows)
+	assert.Equal(t, []int64{int64(bob), int64(alice)}, []int64(follows.ActorIds))
+
+	alerts := 0
+	for _, group := range groups {
+		if group.Type == domain.NotificationAlert {
+			alerts++
+			assert.Zero(t, group.ActorsCount)
+		}
+	}
+	assert.Equal(t, 2, alerts)
+
+	count, err := f.repo.GetNotificationGroupsCount(ctx, owner)
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+	assert.Equal(t, 5, f.unread(owner))
+}
+
+func TestNotificationRepeatsReRaiseOnlyCommentsAndMentions(t *testing.T) {
+	f := newNotificationFixture(t)
+	ctx := context.Background()
+	owner, alice := f.user(), f.user()
+	postId := f.post(owner)
+	firstComment, secondComment := f.comment(alice, postId), f.comment(alice, postId)
+
+	f.notify(&domain.Notification{UserId: owner, ActorId: &alice, Type: domain.NotificationLike, PostId: &postId})
+	f.notify(&domain.Notification{UserId: owner, ActorId: &alice, Type: domain.NotificationComment, PostId: &postId, CommentId: &firstComment})
+	f.notify(&domain.Notification{UserId: owner, ActorId: &alice, Type: domain.NotificationMention, PostId: &postId, CommentId: &firstComment})
+	require.NoError(t, f.repo.MarkAllRead(ctx, owner))
+	assert.Zero(t, f.unread(owner))
+
+	// Unliking and liking again is not news
+	f.notify(&domain.Notification{UserId: owner, ActorId: &alice, Type: domain.NotificationLike, PostId: &postId})
+	assert.Zero(t, f.unread(owner))
+
+	// Another comment and mention by the same actor are
+	f.notify(&domain.Notification{UserId: owner, ActorId: &alice, Type: domain.NotificationComment, PostId: &postId, CommentId: &secondComment})
+	f.notify(&domain.Notification{UserId: owner, ActorId: &alice, Type: domain.NotificationMention, PostId: &postId, CommentId: &secondComment})
+	assert.Equal(t, 2, f.unread(owner))
+
+	groups := f.groups(owner)
+	require.Len(t, groups, 3)
+	for _, kind := range []string{domain.NotificationComment, domain.NotificationMention} {
+		group := findGroup(groups, kind, &postId)
+		require.NotNil(t, group, kind)
+		assert.True(t, group.Unread, kind)
+		assert.Equal(t, 1, group.ActorsCount, kind)
+		require.NotNil(t, group.CommentId, kind)
+		assert.Equal(t, secondComment, *group.CommentId, kind)
+	}
+	assert.False(t, findGroup(groups, domain.NotificationLike, &postId).Unread)
+}
+
+func TestNotificationMarkGroupRead(t *testing.T) {
+	f := newNotificationFixture(t)
+	ctx := context.Background()
+	owner, alice, bob := f.user(), f.user(), f.user()
+	first, second := f.post(owner), f.post(owner)
+
+	f.notify(&domain.Notification{UserId: owner, ActorId: &alice, Type: domain.NotificationLike, PostId: &first})
+	f.notify(&domain.Notification{UserId: owner, ActorId: &bob, Type: domain.NotificationLike, PostId: &first})
+	f.notify(&domain.Notification{UserId: owner, ActorId: &alice, Type: domain.NotificationLike, PostId: &second})
+	f.notify(&domain.Notification{UserId: alice, ActorId: &bob, Type: domain.NotificationFollow})
+	require.Equal(t, 2, f.unread(owner))
+
+	likes := findGroup(f.groups(owner), domain.NotificationLike, &first)
+	require.NotNil(t, likes)
+
+	// Only the owner can mark it
+	require.NoError(t, f.repo.MarkGroupRead(ctx, alice, likes.Id))
+	assert.Equal(t, 2, f.unread(owner))
+
+	// Every notification in the group is read, the other groups are not
+	require.NoError(t, f.repo.MarkGroupRead(ctx, owner, likes.Id))
+	assert.Equal(t, 1, f.unread(owner))
+	groups := f.groups(owner)
+	assert.False(t, findGroup(groups, domain.NotificationLike, &first).Unread)
+	assert.True(t, findGroup(groups, domain.NotificationLike, &second).Unread)
+
+	// A new like brings the group back as unread
+	carol := f.user()
+	f.notify(&domain.Notification{UserId: owner, ActorId: &carol, Type: domain.NotificationLike, PostId: &first})
+	assert.Equal(t, 2, f.unread(owner))
+
+	require.NoError(t, f.repo.MarkAllRead(ctx, owner))
+	assert.Zero(t, f.unread(owner))
+	assert.Equal(t, 1, f.unread(alice))
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+)
+
+// keysetCondition matches the rows after the cursor in a (created_at, id) DESC
+// listing. It is always true when both arguments are NULL, i.e. without a cursor.
+func keysetCondition(createdAtColumn, idColumn string, createdAtArg, idArg int) string {
+	return fmt.Sprintf("($%d::timestamp IS NULL OR (%s, %s) < ($%d::timestamp, $%d::int))",
+		createdAtArg, createdAtColumn, idColumn, createdAtArg, idArg)
+}
+
+// keysetAscCondition is keysetCondition for a (created_at, id) ASC listing
+func keysetAscCondition(createdAtColumn, idColumn string, createdAtArg, idArg int) string {
+	return fmt.Sprintf("($%d::timestamp IS NULL OR (%s, %s) > ($%d::timestamp, $%d::int))",
+		createdAtArg, createdAtColumn, idColumn, createdAtArg, idArg)
+}
+
+// pageArgs returns the cursor arguments and the offset of a page. A cursor replaces the offset.
+func pageArgs(page domain.PaginationParams) (createdAt *time.Time, id *int, offset int) {
+	createdAt, id = page.Cursor.Args()
+	if page.Cursor != nil {
+		return createdAt, id, 0
+	}
+	return createdAt, id, page.Offset
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type PasswordResetRepository interface {
+	// CreateResetToken stores a reset token for the user, the ones they were sent before stop working
+	CreateResetToken(ctx context.Context, userId int, tokenHash string, expiresAt time.Time) error
+	// CountResetTokens counts the reset tokens the user was sent since a time, used or not
+	CountResetTokens(ctx context.Context, userId int, since time.Time) (int, error)
+	// ResetPassword redeems a live reset token, setting the password of its user
+	// and revoking all their sessions. Returns sql.ErrNoRows when the token is
+	// unknown, used or expired.
+	ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (userId int, err error)
+}
+
+type passwordResetRepository struct {
+	db *sqlx.DB
+}
+
+func NewPasswordResetRepository(db *sqlx.DB) PasswordResetRepository {
+	return &passwordResetRepository{db: db}
+}
+
+func (r *passwordResetRepository) CreateResetToken(ctx context.Context, userId int, tokenHash string, expiresAt time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE password_reset_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL`,
+		userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO password_reset_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`,
+		userId, tokenHash, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *passwordResetRepository) CountResetTokens(ctx context.Context, userId int, since time.Time) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count,
+		`SELECT COUNT(*) FROM password_reset_tokens WHERE user_id = $1 AND created_at > $2`,
+		userId, since)
+	return count, err
+}
+
+func (r *passwordResetRepository) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Claiming the token first makes it single-use under concurrent resets
+	var userId int
+	err = tx.GetContext(ctx, &userId,
+		`UPDATE password_reset_tokens SET used_at = NOW()
+		 WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		 RETURNING user_id`,
+		tokenHash)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE users SET password = $2, updated_at = NOW() WHERE id = $1`,
+		userId, passwordHash)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL`,
+		userId)
+	if err != nil {
+		return 0, err
+	}
+
+	return userId, tx.Commit()
+}
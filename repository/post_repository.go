@@ -5,20 +5,38 @@ import (
 
 	"github.com/Pro100-Almaz/trading-chat/domain"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type PostRepository interface {
-	GetPosts(ctx context.Context, userId, limit, offset int) ([]*domain.Post, error)
-	GetFollowingPosts(ctx context.Context, userId, limit, offset int) ([]*domain.Post, error)
+	GetPosts(ctx context.Context, userId int, page domain.PaginationParams) ([]*domain.Post, error)
+	GetFollowingPosts(ctx context.Context, userId int, page domain.PaginationParams) ([]*domain.Post, error)
 	GetPostById(ctx context.Context, id int) (*domain.Post, error)
-	GetPostsByUserId(ctx context.Context, userId, limit, offset int) ([]*domain.Post, error)
+	GetPostsByUserId(ctx context.Context, userId int, page domain.PaginationParams) ([]*domain.Post, error)
 	CreatePost(ctx context.Context, post *domain.Post) (*domain.Post, error)
+	CreatePostWithPrediction(ctx context.Context, post *domain.Post, prediction *domain.Prediction) (*domain.Post, error)
+	// UpdatePost stores the edited body, sentiment, tickers and entities of a
+	// post, keeping the version it replaces as a revision
+	UpdatePost(ctx context.Context, post *domain.Post) (*domain.Post, error)
+	// GetPostRevisions lists the earlier versions of a post, oldest first
+	GetPostRevisions(ctx context.Context, postId int) ([]*domain.PostRevision, error)
 	DeletePost(ctx context.Context, id int) error
+	GetPostsByIds(ctx context.Context, ids []int) ([]*domain.Post, error)
+	GetPostsByUserIds(ctx context.Context, userIds []int, page domain.PaginationParams) ([]*domain.Post, error)
+	// GetPostsByTickers returns the posts listed under any of the symbols
+	GetPostsByTickers(ctx context.Context, symbols []string, page domain.PaginationParams) ([]*domain.Post, error)
+	GetPostEntities(ctx context.Context, postIds []int) (map[int][]*domain.Entity, error)
 	GetPostsCount(ctx context.Context) (int, error)
+	GetTickerPostsCount(ctx context.Context, symbol string) (int, error)
 	GetFollowingPostsCount(ctx context.Context, userId int) (int, error)
 	GetUserPostsCount(ctx context.Context, userId int) (int, error)
 }
 
+// followingPostsCondition matches the posts of the users and tickers that user $1
+// follows, leaving out the user's own posts on followed tickers
+const followingPostsCondition = `(p.user_id IN (SELECT following_id FROM followers WHERE follower_id = $1)
+	 OR (p.tickers && ARRAY(SELECT symbol FROM ticker_followers WHERE user_id = $1)::TEXT[] AND p.user_id != $1))`
+
 type postRepository struct {
 	db *sqlx.DB
 }
@@ -27,27 +45,28 @@ func NewPostRepository(db *sqlx.DB) PostRepository {
 	return &postRepository{db: db}
 }
 
-func (r *postRepository) GetPosts(ctx context.Context, userId, limit, offset int) ([]*domain.Post, error) {
+func (r *postRepository) GetPosts(ctx context.Context, userId int, page domain.PaginationParams) ([]*domain.Post, error) {
 	var posts []*domain.Post
+	createdAt, id, offset := pageArgs(page)
 	err := r.db.SelectContext(ctx, &posts,
-		`SELECT * FROM posts WHERE user_id != $1
-         ORDER BY created_at DESC LIMIT $2 OFFSET $3 `,
-		userId, limit, offset)
+		`SELECT * FROM posts WHERE user_id != $1 AND `+keysetCondition("created_at", "id", 2, 3)+`
+         ORDER BY created_at DESC, id DESC LIMIT $4 OFFSET $5 `,
+		userId, createdAt, id, page.Limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	return posts, nil
 }
 
-func (r *postRepository) GetFollowingPosts(ctx context.Context, userId, limit, offset int) ([]*domain.Post, error) {
+func (r *postRepository) GetFollowingPosts(ctx context.Context, userId int, page domain.PaginationParams) ([]*domain.Post, error) {
 	var posts []*domain.Post
+	createdAt, id, offset := pageArgs(page)
 	err := r.db.SelectContext(ctx, &posts,
 		`SELECT p.* FROM posts p
-		 INNER JOIN followers f ON p.user_id = f.following_id
-		 WHERE f.follower_id = $1
-		 ORDER BY p.created_at DESC
-		 LIMIT $2 OFFSET $3`,
-		userId, limit, offset)
+		 WHERE `+followingPostsCondition+` AND `+keysetCondition("p.created_at", "p.id", 2, 3)+`
+		 ORDER BY p.created_at DESC, p.id DESC
+		 LIMIT $4 OFFSET $5`,
+		userId, createdAt, id, page.Limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -63,11 +82,66 @@ func (r *postRepository) GetPostById(ctx context.Context, id int) (*domain.Post,
 	return &post, nil
 }
 
-func (r *postRepository) GetPostsByUserId(ctx context.Context, userId, limit, offset int) ([]*domain.Post, error) {
+func (r *postRepository) GetPostsByUserId(ctx context.Context, userId int, page domain.PaginationParams) ([]*domain.Post, error) {
 	var posts []*domain.Post
+	createdAt, id, offset := pageArgs(page)
+	err := r.db.SelectContext(ctx, &posts,
+		`SELECT * FROM posts WHERE user_id = $1 AND `+keysetCondition("created_at", "id", 2, 3)+`
+		 ORDER BY created_at DESC, id DESC LIMIT $4 OFFSET $5`,
+		userId, createdAt, id, page.Limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// GetPostsByIds returns the posts that still exist among the given ids, in no particular order
+func (r *postRepository) GetPostsByIds(ctx context.Context, ids []int) ([]*domain.Post, error) {
+	posts := make([]*domain.Post, 0, len(ids))
+	if len(ids) == 0 {
+		return posts, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT * FROM posts WHERE id IN (?)`, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.SelectContext(ctx, &posts, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+func (r *postRepository) GetPostsByUserIds(ctx context.Context, userIds []int, page domain.PaginationParams) ([]*domain.Post, error) {
+	posts := make([]*domain.Post, 0)
+	if len(userIds) == 0 {
+		return posts, nil
+	}
+
+	createdAt, id, offset := pageArgs(page)
+	err := r.db.SelectContext(ctx, &posts,
+		`SELECT * FROM posts WHERE user_id = ANY($1) AND `+keysetCondition("created_at", "id", 2, 3)+`
+		 ORDER BY created_at DESC, id DESC LIMIT $4 OFFSET $5`,
+		pq.Array(userIds), createdAt, id, page.Limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+func (r *postRepository) GetPostsByTickers(ctx context.Context, symbols []string, page domain.PaginationParams) ([]*domain.Post, error) {
+	posts := make([]*domain.Post, 0)
+	if len(symbols) == 0 {
+		return posts, nil
+	}
+
+	createdAt, id, offset := pageArgs(page)
 	err := r.db.SelectContext(ctx, &posts,
-		`SELECT * FROM posts WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`,
-		userId, limit, offset)
+		`SELECT * FROM posts WHERE tickers && $1::TEXT[] AND `+keysetCondition("created_at", "id", 2, 3)+`
+		 ORDER BY created_at DESC, id DESC LIMIT $4 OFFSET $5`,
+		pq.Array(symbols), createdAt, id, page.Limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -75,16 +149,119 @@ func (r *postRepository) GetPostsByUserId(ctx context.Context, userId, limit, of
 }
 
 func (r *postRepository) CreatePost(ctx context.Context, post *domain.Post) (*domain.Post, error) {
-	var id int
-	err := r.db.QueryRowContext(ctx,
-		`INSERT INTO posts (user_id, ticker, body) VALUES ($1, $2, $3) RETURNING id`,
-		post.UserId, post.Ticker, post.Body).Scan(&id)
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	id, err := insertPost(ctx, tx, post)
 	if err != nil {
 		return nil, err
 	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 	return r.GetPostById(ctx, id)
 }
 
+// CreatePostWithPrediction creates a post and its prediction together, the
+// prediction takes the post's id, author and ticker
+func (r *postRepository) CreatePostWithPrediction(ctx context.Context, post *domain.Post, prediction *domain.Prediction) (*domain.Post, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	id, err := insertPost(ctx, tx, post)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO predictions (post_id, user_id, ticker, direction, target_price, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		id, post.UserId, post.Ticker, prediction.Direction, prediction.TargetPrice, prediction.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetPostById(ctx, id)
+}
+
+// insertPost stores a post with the entities of its body
+func insertPost(ctx context.Context, tx *sqlx.Tx, post *domain.Post) (int, error) {
+	var id int
+	err := tx.QueryRowContext(ctx,
+		`INSERT INTO posts (user_id, ticker, tickers, body, sentiment, price_at_post)
+		 VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		post.UserId, post.Ticker, post.Tickers, post.Body, post.Sentiment, post.PriceAtPost).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := insertEntities(ctx, tx, "post_entities", "post_id", id, post.Entities); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *postRepository) GetPostEntities(ctx context.Context, postIds []int) (map[int][]*domain.Entity, error) {
+	return selectEntities(ctx, r.db, "post_entities", "post_id", postIds)
+}
+
+func (r *postRepository) UpdatePost(ctx context.Context, post *domain.Post) (*domain.Post, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Locked so that concurrent edits each keep the version they replace
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO post_revisions (post_id, body, sentiment, created_at)
+		 SELECT id, body, sentiment, COALESCE(updated_at, created_at) FROM posts WHERE id = $1 FOR UPDATE`,
+		post.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE posts SET body = $2, sentiment = $3, tickers = $4, updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+		post.Id, post.Body, post.Sentiment, post.Tickers)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM post_entities WHERE post_id = $1`, post.Id); err != nil {
+		return nil, err
+	}
+	if err := insertEntities(ctx, tx, "post_entities", "post_id", post.Id, post.Entities); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetPostById(ctx, post.Id)
+}
+
+func (r *postRepository) GetPostRevisions(ctx context.Context, postId int) ([]*domain.PostRevision, error) {
+	revisions := make([]*domain.PostRevision, 0)
+	err := r.db.SelectContext(ctx, &revisions,
+		`SELECT * FROM post_revisions WHERE post_id = $1 ORDER BY created_at, id`,
+		postId)
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
 func (r *postRepository) DeletePost(ctx context.Context, id int) error {
 	_, err := r.db.ExecContext(ctx, `DELETE FROM posts WHERE id = $1`, id)
 	return err
@@ -99,9 +276,7 @@ func (r *postRepository) GetPostsCount(ctx context.Context) (int, error) {
 func (r *postRepository) GetFollowingPostsCount(ctx context.Context, userId int) (int, error) {
 	var count int
 	err := r.db.GetContext(ctx, &count,
-		`SELECT COUNT(*) FROM posts p
-		 INNER JOIN followers f ON p.user_id = f.following_id
-		 WHERE f.follower_id = $1`,
+		`SELECT COUNT(*) FROM posts p WHERE `+followingPostsCondition,
 		userId)
 	return count, err
 }
@@ -111,3 +286,9 @@ func (r *postRepository) GetUserPostsCount(ctx context.Context, userId int) (int
 	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM posts WHERE user_id = $1`, userId)
 	return count, err
 }
+
+func (r *postRepository) GetTickerPostsCount(ctx context.Context, symbol string) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM posts WHERE tickers @> ARRAY[$1]::TEXT[]`, symbol)
+	return count, err
+}
@@ -4,13 +4,17 @@ import (
 	"context"
 	"time"
 
+	"github.com/Pro100-Almaz/trading-chat/domain"
+
 	"github.com/jmoiron/sqlx"
 )
 
 type PostViewsDBRepository interface {
-	UpsertViewCounts(ctx context.Context, viewCounts map[int]int64) error
+	UpsertViewCounts(ctx context.Context, batchId string, viewCounts map[int]int64) error
 	GetViewCount(ctx context.Context, postId int) (int64, error)
 	GetMultipleViewCounts(ctx context.Context, postIds []int) (map[int]int64, error)
+	UpsertDailyViews(ctx context.Context, days []*domain.PostViewsDay) error
+	GetDailyViews(ctx context.Context, postId int, from time.Time) ([]*domain.PostViewsDay, error)
 }
 
 type postViewsDBRepository struct {
@@ -23,8 +27,10 @@ func NewPostViewsDBRepository(db *sqlx.DB) PostViewsDBRepository {
 	}
 }
 
-// UpsertViewCounts updates or inserts view counts in batch
-func (r *postViewsDBRepository) UpsertViewCounts(ctx context.Context, viewCounts map[int]int64) error {
+// UpsertViewCounts adds a sync batch of view counts in one transaction. Each
+// batch id is applied at most once, so a batch retried after a failure between
+// the commit and its removal from Redis is not counted twice.
+func (r *postViewsDBRepository) UpsertViewCounts(ctx context.Context, batchId string, viewCounts map[int]int64) error {
 	if len(viewCounts) == 0 {
 		return nil
 	}
@@ -35,10 +41,23 @@ func (r *postViewsDBRepository) UpsertViewCounts(ctx context.Context, viewCounts
 	}
 	defer tx.Rollback()
 
-	// PostgreSQL INSERT ... ON CONFLICT for batch upsert
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO post_views_sync_batches (batch_id) VALUES ($1) ON CONFLICT (batch_id) DO NOTHING`,
+		batchId)
+	if err != nil {
+		return err
+	}
+	if applied, err := result.RowsAffected(); err != nil {
+		return err
+	} else if applied == 0 {
+		return nil
+	}
+
+	// PostgreSQL INSERT ... ON CONFLICT for batch upsert. Views of posts deleted
+	// since they were counted are dropped instead of failing the whole batch.
 	query := `
 		INSERT INTO post_views (post_id, views_count, updated_at)
-		VALUES ($1, $2, $3)
+		SELECT id, $2, $3 FROM posts WHERE id = $1
 		ON CONFLICT (post_id)
 		DO UPDATE SET
 			views_count = post_views.views_count + EXCLUDED.views_count,
@@ -59,6 +78,13 @@ func (r *postViewsDBRepository) UpsertViewCounts(ctx context.Context, viewCounts
 		}
 	}
 
+	// Batch ids only need to outlive any realistic retry
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM post_views_sync_batches WHERE created_at < NOW() - INTERVAL '7 days'`)
+	if err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
@@ -113,3 +139,58 @@ func (r *postViewsDBRepository) GetMultipleViewCounts(ctx context.Context, postI
 
 	return viewCounts, nil
 }
+
+// UpsertDailyViews stores snapshots of daily view counts. Snapshots are absolute
+// and never lower a stored value, so replaying or reordering them is safe.
+func (r *postViewsDBRepository) UpsertDailyViews(ctx context.Context, days []*domain.PostViewsDay) error {
+	if len(days) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO post_views_daily (post_id, day, unique_viewers, impressions, updated_at)
+		SELECT id, $2, $3, $4, $5 FROM posts WHERE id = $1
+		ON CONFLICT (post_id, day)
+		DO UPDATE SET
+			unique_viewers = GREATEST(post_views_daily.unique_viewers, EXCLUDED.unique_viewers),
+			impressions = GREATEST(post_views_daily.impressions, EXCLUDED.impressions),
+			updated_at = EXCLUDED.updated_at
+	`
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, day := range days {
+		_, err = stmt.ExecContext(ctx, day.PostId, day.Date, day.UniqueViewers, day.Impressions, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetDailyViews gets the stored daily view counts of a post since the given day, oldest first
+func (r *postViewsDBRepository) GetDailyViews(ctx context.Context, postId int, from time.Time) ([]*domain.PostViewsDay, error) {
+	days := make([]*domain.PostViewsDay, 0)
+	err := r.db.SelectContext(ctx, &days, `
+		SELECT post_id, TO_CHAR(day, 'YYYY-MM-DD') AS date, unique_viewers, impressions
+		FROM post_views_daily
+		WHERE post_id = $1 AND day >= $2
+		ORDER BY day
+	`, postId, from.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	return days, nil
+}
@@ -2,18 +2,124 @@ package repository
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
 
 	"github.com/redis/go-redis/v9"
 )
 
+const (
+	postViewsKeyPrefix = "post:views:"
+	// Live counters are drained into this hash by the sync worker
+	viewsSyncPendingKey = "views:sync:pending"
+	// A pending hash promoted for syncing, tagged with a batch id so that
+	// retrying it after a partial failure is idempotent
+	viewsSyncBatchKey   = "views:sync:batch"
+	viewsSyncBatchIdKey = "_id"
+	viewsSyncLeaseKey   = "views:sync:lease"
+
+	// Per post per day HyperLogLog of viewer ids and raw impression counter
+	postViewersKeyPrefix     = "post:viewers:"
+	postImpressionsKeyPrefix = "post:impressions:"
+	// "postId:day" pairs touched since the last daily sync, and the set being synced
+	viewsDailyDirtyKey   = "views:daily:dirty"
+	viewsDailySyncingKey = "views:daily:syncing"
+	// Long enough for a day's keys to be synced after the day is over
+	viewsDailyTTL = 48 * time.Hour
+
+	viewsDayLayout = "2006-01-02"
+)
+
+// trackViewsScript records a viewer for each post. The total counter only goes
+// up the first time a user is seen on a post that day, impressions always do.
+// KEYS[1] is the dirty set, then per post the viewers, total and impressions keys.
+// ARGV[1] is the user id, ARGV[2] the daily keys TTL, ARGV[2+i] the dirty member of post i.
+var trackViewsScript = redis.NewScript(`
+local counted = 0
+for i = 1, (#KEYS - 1) / 3 do
+	local viewers = KEYS[3 * i - 1]
+	local impressions = KEYS[3 * i + 1]
+	if redis.call('PFADD', viewers, ARGV[1]) == 1 then
+		redis.call('INCR', KEYS[3 * i])
+		counted = counted + 1
+	end
+	redis.call('INCR', impressions)
+	redis.call('EXPIRE', viewers, ARGV[2])
+	redis.call('EXPIRE', impressions, ARGV[2])
+	redis.call('SADD', KEYS[1], ARGV[2 + i])
+end
+return counted
+`)
+
+// promoteDailySyncScript starts a daily sync from the dirty set, unless an
+// earlier one was not completed, in which case that one is retried
+var promoteDailySyncScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 0 and redis.call('EXISTS', KEYS[1]) == 1 then
+	redis.call('RENAME', KEYS[1], KEYS[2])
+end
+return 1
+`)
+
+// drainViewsScript atomically moves counters into the pending hash.
+// KEYS[1] is the pending hash, KEYS[2..n] the counters, ARGV[i] the post id of KEYS[i+1].
+var drainViewsScript = redis.NewScript(`
+local moved = 0
+for i = 2, #KEYS do
+	local count = redis.call('GETDEL', KEYS[i])
+	if count then
+		redis.call('HINCRBY', KEYS[1], ARGV[i - 1], count)
+		moved = moved + 1
+	end
+end
+return moved
+`)
+
+// promoteViewsBatchScript turns the pending hash into the sync batch unless a
+// previous batch has not been completed yet, in which case that one is retried.
+var promoteViewsBatchScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 0 then
+	if redis.call('EXISTS', KEYS[1]) == 0 then
+		return 0
+	end
+	redis.call('RENAME', KEYS[1], KEYS[2])
+	redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+end
+return 1
+`)
+
+// deleteIfFieldMatchesScript deletes a hash only if the given field still holds the expected value
+var deleteIfFieldMatchesScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], ARGV[1]) == ARGV[2] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// releaseLeaseScript deletes the lease only if it is still held by the caller
+var releaseLeaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
 type PostViewsRedisRepository interface {
-	IncrementViews(ctx context.Context, postIds []int) error
+	TrackViews(ctx context.Context, userId int, postIds []int) (int, error)
 	GetViewCount(ctx context.Context, postId int) (int64, error)
-	GetAllViewCounts(ctx context.Context) (map[int]int64, error)
-	ResetViewCount(ctx context.Context, postId int) error
+	GetViewCounts(ctx context.Context, postIds []int) (map[int]int64, error)
+	AcquireSyncLease(ctx context.Context, owner string, ttl time.Duration) (bool, error)
+	ReleaseSyncLease(ctx context.Context, owner string) error
+	PrepareSyncBatch(ctx context.Context) (batchId string, viewCounts map[int]int64, err error)
+	CompleteSyncBatch(ctx context.Context, batchId string) error
+	GetDailyViews(ctx context.Context, postId int, dates []string) (map[string]*domain.PostViewsDay, error)
+	PrepareDailySync(ctx context.Context) ([]*domain.PostViewsDay, error)
+	CompleteDailySync(ctx context.Context) error
 }
 
 type postViewsRedisRepository struct {
@@ -26,79 +132,266 @@ func NewPostViewsRedisRepository(redis *redis.Client) PostViewsRedisRepository {
 	}
 }
 
-// IncrementViews increments view count for multiple posts in Redis
-func (r *postViewsRedisRepository) IncrementViews(ctx context.Context, postIds []int) error {
+// TrackViews records that a user viewed some posts today and returns how many
+// of those views were counted, repeat views by the same user on the same day are not
+func (r *postViewsRedisRepository) TrackViews(ctx context.Context, userId int, postIds []int) (int, error) {
 	if len(postIds) == 0 {
-		return nil
+		return 0, nil
 	}
 
-	pipe := r.redis.Pipeline()
+	day := time.Now().UTC().Format(viewsDayLayout)
+	keys := make([]string, 0, 1+3*len(postIds))
+	args := make([]interface{}, 0, 2+len(postIds))
+	keys = append(keys, viewsDailyDirtyKey)
+	args = append(args, userId, int(viewsDailyTTL.Seconds()))
 	for _, postId := range postIds {
-		key := fmt.Sprintf("post:views:%d", postId)
-		pipe.Incr(ctx, key)
+		keys = append(keys,
+			postViewersKey(postId, day),
+			fmt.Sprintf("post:views:%d", postId),
+			postImpressionsKey(postId, day),
+		)
+		args = append(args, fmt.Sprintf("%d:%s", postId, day))
 	}
 
-	_, err := pipe.Exec(ctx)
-	return err
+	return trackViewsScript.Run(ctx, r.redis, keys, args...).Int()
 }
 
-// GetViewCount gets the view count for a single post from Redis
+// GetViewCount gets the pending (not yet synced) view count for a single post from Redis
 func (r *postViewsRedisRepository) GetViewCount(ctx context.Context, postId int) (int64, error) {
-	key := fmt.Sprintf("post:views:%d", postId)
-	count, err := r.redis.Get(ctx, key).Int64()
-	if err == redis.Nil {
-		return 0, nil
+	viewCounts, err := r.GetViewCounts(ctx, []int{postId})
+	if err != nil {
+		return 0, err
 	}
-	return count, err
+	return viewCounts[postId], nil
 }
 
-// GetAllViewCounts gets all view counts from Redis (for worker to sync to DB)
-func (r *postViewsRedisRepository) GetAllViewCounts(ctx context.Context) (map[int]int64, error) {
-	// Get all keys matching post:views:*
-	keys, err := r.redis.Keys(ctx, "post:views:*").Result()
+// GetViewCounts gets the pending (not yet synced) view counts for several posts.
+// It includes counters the worker has drained but not yet written to the database.
+func (r *postViewsRedisRepository) GetViewCounts(ctx context.Context, postIds []int) (map[int]int64, error) {
+	viewCounts := make(map[int]int64, len(postIds))
+	if len(postIds) == 0 {
+		return viewCounts, nil
+	}
+
+	keys := make([]string, 0, len(postIds))
+	fields := make([]string, 0, len(postIds))
+	for _, postId := range postIds {
+		keys = append(keys, fmt.Sprintf("post:views:%d", postId))
+		fields = append(fields, strconv.Itoa(postId))
+	}
+
+	pipe := r.redis.Pipeline()
+	live := pipe.MGet(ctx, keys...)
+	pending := pipe.HMGet(ctx, viewsSyncPendingKey, fields...)
+	batch := pipe.HMGet(ctx, viewsSyncBatchKey, fields...)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	for _, cmd := range []*redis.SliceCmd{live, pending, batch} {
+		for i, value := range cmd.Val() {
+			strVal, ok := value.(string)
+			if !ok {
+				continue
+			}
+			count, err := strconv.ParseInt(strVal, 10, 64)
+			if err == nil {
+				viewCounts[postIds[i]] += count
+			}
+		}
+	}
+
+	return viewCounts, nil
+}
+
+// AcquireSyncLease takes the cluster-wide sync lease so only one replica flushes at a time
+func (r *postViewsRedisRepository) AcquireSyncLease(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	return r.redis.SetNX(ctx, viewsSyncLeaseKey, owner, ttl).Result()
+}
+
+// ReleaseSyncLease gives the lease back, unless it already expired and was taken by another replica
+func (r *postViewsRedisRepository) ReleaseSyncLease(ctx context.Context, owner string) error {
+	return releaseLeaseScript.Run(ctx, r.redis, []string{viewsSyncLeaseKey}, owner).Err()
+}
+
+// PrepareSyncBatch moves all live counters into a sync batch and returns it.
+// Increments that land while this runs either make it into the batch or stay
+// in a fresh counter for the next sync, none are lost. An unfinished batch from
+// an earlier failed sync is returned again with its original id.
+func (r *postViewsRedisRepository) PrepareSyncBatch(ctx context.Context) (string, map[int]int64, error) {
+	var cursor uint64
+	for {
+		keys, next, err := r.redis.Scan(ctx, cursor, postViewsKeyPrefix+"*", 500).Result()
+		if err != nil {
+			return "", nil, err
+		}
+
+		scriptKeys := []string{viewsSyncPendingKey}
+		scriptArgs := make([]interface{}, 0, len(keys))
+		for _, key := range keys {
+			postId, err := strconv.Atoi(strings.TrimPrefix(key, postViewsKeyPrefix))
+			if err != nil {
+				continue
+			}
+			scriptKeys = append(scriptKeys, key)
+			scriptArgs = append(scriptArgs, postId)
+		}
+
+		if len(scriptArgs) > 0 {
+			if err := drainViewsScript.Run(ctx, r.redis, scriptKeys, scriptArgs...).Err(); err != nil {
+				return "", nil, err
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	batchId, err := newSyncBatchId()
 	if err != nil {
+		return "", nil, err
+	}
+
+	err = promoteViewsBatchScript.Run(ctx, r.redis,
+		[]string{viewsSyncPendingKey, viewsSyncBatchKey},
+		viewsSyncBatchIdKey, batchId,
+	).Err()
+	if err != nil {
+		return "", nil, err
+	}
+
+	values, err := r.redis.HGetAll(ctx, viewsSyncBatchKey).Result()
+	if err != nil {
+		return "", nil, err
+	}
+
+	viewCounts := make(map[int]int64, len(values))
+	batchId = ""
+	for field, value := range values {
+		if field == viewsSyncBatchIdKey {
+			batchId = value
+			continue
+		}
+		postId, err := strconv.Atoi(field)
+		if err != nil {
+			continue
+		}
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		viewCounts[postId] = count
+	}
+
+	return batchId, viewCounts, nil
+}
+
+// CompleteSyncBatch drops a batch once it has been written to the database
+func (r *postViewsRedisRepository) CompleteSyncBatch(ctx context.Context, batchId string) error {
+	return deleteIfFieldMatchesScript.Run(ctx, r.redis,
+		[]string{viewsSyncBatchKey},
+		viewsSyncBatchIdKey, batchId,
+	).Err()
+}
+
+// GetDailyViews gets the live unique viewer and impression counts of a post for
+// the given days. Only days within the keys TTL are found, older ones are omitted.
+func (r *postViewsRedisRepository) GetDailyViews(ctx context.Context, postId int, dates []string) (map[string]*domain.PostViewsDay, error) {
+	days := make([]*domain.PostViewsDay, 0, len(dates))
+	for _, date := range dates {
+		days = append(days, &domain.PostViewsDay{PostId: postId, Date: date})
+	}
+
+	if err := r.loadDailyViews(ctx, days); err != nil {
 		return nil, err
 	}
 
-	if len(keys) == 0 {
-		return make(map[int]int64), nil
+	result := make(map[string]*domain.PostViewsDay, len(days))
+	for _, day := range days {
+		if day.UniqueViewers > 0 || day.Impressions > 0 {
+			result[day.Date] = day
+		}
+	}
+	return result, nil
+}
+
+// PrepareDailySync snapshots the post days that had views since the last daily
+// sync. The counts are absolute, so writing the same snapshot twice is harmless,
+// and views arriving meanwhile mark their day dirty again for the next sync.
+func (r *postViewsRedisRepository) PrepareDailySync(ctx context.Context) ([]*domain.PostViewsDay, error) {
+	err := promoteDailySyncScript.Run(ctx, r.redis, []string{viewsDailyDirtyKey, viewsDailySyncingKey}).Err()
+	if err != nil {
+		return nil, err
 	}
 
-	// Get all values using MGET
-	values, err := r.redis.MGet(ctx, keys...).Result()
+	members, err := r.redis.SMembers(ctx, viewsDailySyncingKey).Result()
 	if err != nil {
 		return nil, err
 	}
 
-	// Build result map
-	viewCounts := make(map[int]int64)
-	for i, key := range keys {
-		// Extract post ID from key: "post:views:123" -> 123
-		parts := strings.Split(key, ":")
-		if len(parts) != 3 {
+	days := make([]*domain.PostViewsDay, 0, len(members))
+	for _, member := range members {
+		postIdStr, date, found := strings.Cut(member, ":")
+		if !found {
 			continue
 		}
-
-		postId, err := strconv.Atoi(parts[2])
+		postId, err := strconv.Atoi(postIdStr)
 		if err != nil {
 			continue
 		}
+		days = append(days, &domain.PostViewsDay{PostId: postId, Date: date})
+	}
 
-		if values[i] == nil {
-			viewCounts[postId] = 0
-		} else if strVal, ok := values[i].(string); ok {
-			count, err := strconv.ParseInt(strVal, 10, 64)
-			if err == nil {
-				viewCounts[postId] = count
-			}
+	if err := r.loadDailyViews(ctx, days); err != nil {
+		return nil, err
+	}
+	return days, nil
+}
+
+// CompleteDailySync drops the daily snapshot once it has been written to the database
+func (r *postViewsRedisRepository) CompleteDailySync(ctx context.Context) error {
+	return r.redis.Del(ctx, viewsDailySyncingKey).Err()
+}
+
+func (r *postViewsRedisRepository) loadDailyViews(ctx context.Context, days []*domain.PostViewsDay) error {
+	if len(days) == 0 {
+		return nil
+	}
+
+	pipe := r.redis.Pipeline()
+	viewers := make([]*redis.IntCmd, 0, len(days))
+	impressions := make([]*redis.StringCmd, 0, len(days))
+	for _, day := range days {
+		viewers = append(viewers, pipe.PFCount(ctx, postViewersKey(day.PostId, day.Date)))
+		impressions = append(impressions, pipe.Get(ctx, postImpressionsKey(day.PostId, day.Date)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return err
+	}
+
+	for i, day := range days {
+		day.UniqueViewers = viewers[i].Val()
+		if count, err := impressions[i].Int64(); err == nil {
+			day.Impressions = count
 		}
 	}
+	return nil
+}
 
-	return viewCounts, nil
+func postViewersKey(postId int, day string) string {
+	return fmt.Sprintf("%s%d:%s", postViewersKeyPrefix, postId, day)
 }
 
-// ResetViewCount resets the view count for a post in Redis (after syncing to DB)
-func (r *postViewsRedisRepository) ResetViewCount(ctx context.Context, postId int) error {
-	key := fmt.Sprintf("post:views:%d", postId)
-	return r.redis.Del(ctx, key).Err()
+func postImpressionsKey(postId int, day string) string {
+	return fmt.Sprintf("%s%d:%s", postImpressionsKeyPrefix, postId, day)
+}
+
+func newSyncBatchId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
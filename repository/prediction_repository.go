@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type PredictionRepository interface {
+	GetPredictionsByPostIds(ctx context.Context, postIds []int) (map[int]*domain.Prediction, error)
+	GetDuePredictions(ctx context.Context, limit int) ([]*domain.Prediction, error)
+	ResolvePrediction(ctx context.Context, id int, status string, price float64) error
+	VoidOverduePredictions(ctx context.Context, grace time.Duration) (int, error)
+	GetPredictionRecord(ctx context.Context, userId int) (*domain.PredictionRecord, error)
+}
+
+type predictionRepository struct {
+	db *sqlx.DB
+}
+
+func NewPredictionRepository(db *sqlx.DB) PredictionRepository {
+	return &predictionRepository{db: db}
+}
+
+// GetPredictionsByPostIds loads the predictions of several posts in one query, keyed by post id
+func (r *predictionRepository) GetPredictionsByPostIds(ctx context.Context, postIds []int) (map[int]*domain.Prediction, error) {
+	predictions := make(map[int]*domain.Prediction, len(postIds))
+	if len(postIds) == 0 {
+		return predictions, nil
+	}
+
+	var list []*domain.Prediction
+	err := r.db.SelectContext(ctx, &list, `SELECT * FROM predictions WHERE post_id = ANY($1)`, pq.Array(postIds))
+	if err != nil {
+		return nil, err
+	}
+	for _, prediction := range list {
+		predictions[prediction.PostId] = prediction
+	}
+	return predictions, nil
+}
+
+// GetDuePredictions returns pending predictions that have expired, the longest expired first
+func (r *predictionRepository) GetDuePredictions(ctx context.Context, limit int) ([]*domain.Prediction, error) {
+	predictions := make([]*domain.Prediction, 0)
+	err := r.db.SelectContext(ctx, &predictions,
+		`SELECT * FROM predictions WHERE status = 'pending' AND expires_at <= NOW()
+		 ORDER BY expires_at LIMIT $1`,
+		limit)
+	if err != nil {
+		return nil, err
+	}
+	return predictions, nil
+}
+
+// ResolvePrediction settles a pending prediction. One already settled, by
+// another replica for instance, is left as it is.
+func (r *predictionRepository) ResolvePrediction(ctx context.Context, id int, status string, price float64) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE predictions SET status = $2, resolved_price = $3, resolved_at = CURRENT_TIMESTAMP
+		 WHERE id = $1 AND status = 'pending'`,
+		id, status, price)
+	return err
+}
+
+// VoidOverduePredictions voids the pending predictions expired for longer than the grace period
+func (r *predictionRepository) VoidOverduePredictions(ctx context.Context, grace time.Duration) (int, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE predictions SET status = 'void', resolved_at = CURRENT_TIMESTAMP
+		 WHERE status = 'pending' AND expires_at < NOW() - $1 * INTERVAL '1 second'`,
+		grace.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+func (r *predictionRepository) GetPredictionRecord(ctx context.Context, userId int) (*domain.PredictionRecord, error) {
+	record := domain.PredictionRecord{}
+	err := r.db.GetContext(ctx, &record,
+		`SELECT COUNT(*) FILTER (WHERE status = 'hit') AS hits,
+		        COUNT(*) FILTER (WHERE status = 'miss') AS misses,
+		        COUNT(*) FILTER (WHERE status = 'pending') AS pending
+		 FROM predictions WHERE user_id = $1`,
+		userId)
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *domain.Session) (*domain.Session, error)
+	GetSessionByFamilyId(ctx context.Context, familyId string) (*domain.Session, error)
+	// GetSessions lists the sessions of the user that are neither revoked nor expired, most recently seen first
+	GetSessions(ctx context.Context, userId int) ([]*domain.Session, error)
+	// RotateToken moves a live session on to the refresh token of next, along
+	// with the device it was refreshed from, provided tokenId is still its
+	// latest. Returns false when it isn't.
+	RotateToken(ctx context.Context, tokenId string, next *domain.Session) (bool, error)
+	// TouchSession records that a session was just used, at most once a minute
+	TouchSession(ctx context.Context, id int) error
+	// RevokeSession ends a session of the user, returns false when there was no live one
+	RevokeSession(ctx context.Context, userId int, familyId string) (bool, error)
+	RevokeSessionById(ctx context.Context, userId, id int) (bool, error)
+	// RevokeOtherSessions ends the live sessions of the user but one, returning how many it ended
+	RevokeOtherSessions(ctx context.Context, userId, exceptId int) (int, error)
+}
+
+type sessionRepository struct {
+	db *sqlx.DB
+}
+
+func NewSessionRepository(db *sqlx.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) CreateSession(ctx context.Context, session *domain.Session) (*domain.Session, error) {
+	created := domain.Session{}
+	err := r.db.GetContext(ctx, &created,
+		`INSERT INTO sessions (user_id, family_id, token_id, device, user_agent, ip, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *`,
+		session.UserId, session.FamilyId, session.TokenId, session.Device, session.UserAgent, session.Ip, session.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (r *sessionRepository) GetSessionByFamilyId(ctx context.Context, familyId string) (*domain.Session, error) {
+	session := domain.Session{}
+	err := r.db.GetContext(ctx, &session, `SELECT * FROM sessions WHERE family_id = $1`, familyId)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) GetSessions(ctx context.Context, userId int) ([]*domain.Session, error) {
+	sessions := make([]*domain.Session, 0)
+	err := r.db.SelectContext(ctx, &sessions,
+		`SELECT * FROM sessions
+		 WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		 ORDER BY last_seen_at DESC, id DESC`,
+		userId)
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *sessionRepository) RotateToken(ctx context.Context, tokenId string, next *domain.Session) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE sessions SET token_id = $3, device = $4, user_agent = $5, ip = $6, expires_at = $7, last_seen_at = CURRENT_TIMESTAMP
+		 WHERE family_id = $1 AND token_id = $2 AND revoked_at IS NULL`,
+		next.FamilyId, tokenId, next.TokenId, next.Device, next.UserAgent, next.Ip, next.ExpiresAt)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *sessionRepository) TouchSession(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE sessions SET last_seen_at = CURRENT_TIMESTAMP
+		 WHERE id = $1 AND last_seen_at < CURRENT_TIMESTAMP - INTERVAL '1 minute'`,
+		id)
+	return err
+}
+
+func (r *sessionRepository) RevokeSession(ctx context.Context, userId int, familyId string) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND family_id = $2 AND revoked_at IS NULL`,
+		userId, familyId)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *sessionRepository) RevokeSessionById(ctx context.Context, userId, id int) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND id = $2 AND revoked_at IS NULL`,
+		userId, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *sessionRepository) RevokeOtherSessions(ctx context.Context, userId, exceptId int) (int, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP`,
+		userId, exceptId)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	return int(rows), err
+}
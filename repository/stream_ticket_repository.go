@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Tickets are stored hashed, the key expires with the ticket
+const streamTicketKeyPrefix = "stream:ticket:"
+
+// StreamTicketRepository keeps the tickets WebSocket and Server-Sent Events
+// connections authenticate with, each standing for a session of a user
+type StreamTicketRepository interface {
+	CreateTicket(ctx context.Context, ticket string, userId int, familyId string, ttl time.Duration) error
+	// RedeemTicket spends a ticket and returns what it stands for, redis.Nil
+	// when it was spent already, expired or never existed
+	RedeemTicket(ctx context.Context, ticket string) (userId int, familyId string, err error)
+}
+
+type streamTicketRepository struct {
+	redis *redis.Client
+}
+
+func NewStreamTicketRepository(redisClient *redis.Client) StreamTicketRepository {
+	return &streamTicketRepository{redis: redisClient}
+}
+
+func streamTicketKey(ticket string) string {
+	sum := sha256.Sum256([]byte(ticket))
+	return streamTicketKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+func (r *streamTicketRepository) CreateTicket(ctx context.Context, ticket string, userId int, familyId string, ttl time.Duration) error {
+	return r.redis.Set(ctx, streamTicketKey(ticket), fmt.Sprintf("%d:%s", userId, familyId), ttl).Err()
+}
+
+func (r *streamTicketRepository) RedeemTicket(ctx context.Context, ticket string) (int, string, error) {
+	value, err := r.redis.GetDel(ctx, streamTicketKey(ticket)).Result()
+	if err != nil {
+		return 0, "", err
+	}
+
+	id, familyId, ok := strings.Cut(value, ":")
+	userId, err := strconv.Atoi(id)
+	if !ok || err != nil {
+		return 0, "", fmt.Errorf("malformed stream ticket %q", value)
+	}
+	return userId, familyId, nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStreamTicketRepo(t *testing.T) (StreamTicketRepository, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewStreamTicketRepository(client), server
+}
+
+func TestStreamTicketRedeemsOnce(t *testing.T) {
+	repo, server := newTestStreamTicketRepo(t)
+	ctx := context.Background()
+
+	require.NoError(t, repo.CreateTicket(ctx, "ticket", 7, "family", 30*time.Second))
+	// Only the hash of the ticket is stored
+	assert.False(t, server.Exists(streamTicketKeyPrefix+"ticket"))
+
+	userId, familyId, err := repo.RedeemTicket(ctx, "ticket")
+	require.NoError(t, err)
+	assert.Equal(t, 7, userId)
+	assert.Equal(t, "family", familyId)
+
+	_, _, err = repo.RedeemTicket(ctx, "ticket")
+	assert.ErrorIs(t, err, redis.Nil)
+}
+
+func TestStreamTicketExpires(t *testing.T) {
+	repo, server := newTestStreamTicketRepo(t)
+	ctx := context.Background()
+
+	require.NoError(t, repo.CreateTicket(ctx, "ticket", 7, "family", 30*time.Second))
+	server.FastForward(31 * time.Second)
+
+	_, _, err := repo.RedeemTicket(ctx, "ticket")
+	assert.ErrorIs(t, err, redis.Nil)
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+type TickerFollowerRepository interface {
+	Follow(ctx context.Context, userId int, symbol string) error
+	Unfollow(ctx context.Context, userId int, symbol string) error
+	IsFollowing(ctx context.Context, userId int, symbol string) (bool, error)
+	GetFollowers(ctx context.Context, symbol string, page domain.PaginationParams) ([]*domain.FollowListEntry, error)
+	GetFollowersCount(ctx context.Context, symbol string) (int, error)
+	GetFollowerIds(ctx context.Context, symbol string) ([]int, error)
+	GetFollowedSymbols(ctx context.Context, userId int) ([]string, error)
+	GetLargeFollowedSymbols(ctx context.Context, userId, minFollowers int) ([]string, error)
+}
+
+type tickerFollowerRepository struct {
+	db *sqlx.DB
+}
+
+func NewTickerFollowerRepository(db *sqlx.DB) TickerFollowerRepository {
+	return &tickerFollowerRepository{db: db}
+}
+
+func (r *tickerFollowerRepository) Follow(ctx context.Context, userId int, symbol string) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO ticker_followers (user_id, symbol) VALUES ($1, $2) ON CONFLICT (user_id, symbol) DO NOTHING`,
+		userId, symbol)
+	return err
+}
+
+func (r *tickerFollowerRepository) Unfollow(ctx context.Context, userId int, symbol string) error {
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM ticker_followers WHERE user_id = $1 AND symbol = $2`,
+		userId, symbol)
+	return err
+}
+
+func (r *tickerFollowerRepository) IsFollowing(ctx context.Context, userId int, symbol string) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists,
+		`SELECT EXISTS(SELECT 1 FROM ticker_followers WHERE user_id = $1 AND symbol = $2)`,
+		userId, symbol)
+	return exists, err
+}
+
+func (r *tickerFollowerRepository) GetFollowers(ctx context.Context, symbol string, page domain.PaginationParams) ([]*domain.FollowListEntry, error) {
+	var users []*domain.FollowListEntry
+	createdAt, id, offset := pageArgs(page)
+	err := r.db.SelectContext(ctx, &users,
+		`SELECT u.*, tf.id AS follow_id, tf.created_at AS followed_at FROM users u
+		 INNER JOIN ticker_followers tf ON u.id = tf.user_id
+		 WHERE tf.symbol = $1 AND `+keysetCondition("tf.created_at", "tf.id", 2, 3)+`
+		 ORDER BY tf.created_at DESC, tf.id DESC
+		 LIMIT $4 OFFSET $5`,
+		symbol, createdAt, id, page.Limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *tickerFollowerRepository) GetFollowersCount(ctx context.Context, symbol string) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM ticker_followers WHERE symbol = $1`, symbol)
+	return count, err
+}
+
+func (r *tickerFollowerRepository) GetFollowerIds(ctx context.Context, symbol string) ([]int, error) {
+	ids := make([]int, 0)
+	err := r.db.SelectContext(ctx, &ids, `SELECT user_id FROM ticker_followers WHERE symbol = $1`, symbol)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *tickerFollowerRepository) GetFollowedSymbols(ctx context.Context, userId int) ([]string, error) {
+	symbols := make([]string, 0)
+	err := r.db.SelectContext(ctx, &symbols, `SELECT symbol FROM ticker_followers WHERE user_id = $1`, userId)
+	if err != nil {
+		return nil, err
+	}
+	return symbols, nil
+}
+
+// GetLargeFollowedSymbols returns the tickers a user follows that have at least minFollowers followers
+func (r *tickerFollowerRepository) GetLargeFollowedSymbols(ctx context.Context, userId, minFollowers int) ([]string, error) {
+	symbols := make([]string, 0)
+	err := r.db.SelectContext(ctx, &symbols,
+		`SELECT tf.symbol FROM ticker_followers tf
+		 WHERE tf.user_id = $1
+		 AND (SELECT COUNT(*) FROM ticker_followers c WHERE c.symbol = tf.symbol) >= $2`,
+		userId, minFollowers)
+	if err != nil {
+		return nil, err
+	}
+	return symbols, nil
+}
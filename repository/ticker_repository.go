@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type TickerRepository interface {
+	GetTickerBySymbol(ctx context.Context, symbol string) (*domain.Ticker, error)
+	SearchTickers(ctx context.Context, query string, limit int) ([]*domain.Ticker, error)
+	GetTickersBySymbols(ctx context.Context, symbols []string) (map[string]*domain.Ticker, error)
+	InsertMissingTickers(ctx context.Context, tickers []*domain.Ticker) (int, error)
+	UpsertTickers(ctx context.Context, tickers []*domain.Ticker) (int, error)
+	GetTickerStats(ctx context.Context, symbol string, since time.Time) (*domain.TickerStats, error)
+	GetSentimentCounts(ctx context.Context, symbol string, window time.Duration) (*domain.SentimentCounts, error)
+	GetSentimentSeries(ctx context.Context, symbol string, bucket time.Duration, buckets int) ([]*domain.SentimentBucket, error)
+}
+
+type tickerRepository struct {
+	db *sqlx.DB
+}
+
+func NewTickerRepository(db *sqlx.DB) TickerRepository {
+	return &tickerRepository{db: db}
+}
+
+func (r *tickerRepository) GetTickerBySymbol(ctx context.Context, symbol string) (*domain.Ticker, error) {
+	ticker := domain.Ticker{}
+	err := r.db.GetContext(ctx, &ticker, `SELECT * FROM tickers WHERE symbol = $1`, symbol)
+	if err != nil {
+		return nil, err
+	}
+	return &ticker, nil
+}
+
+func (r *tickerRepository) GetTickersBySymbols(ctx context.Context, symbols []string) (map[string]*domain.Ticker, error) {
+	tickers := make(map[string]*domain.Ticker, len(symbols))
+	if len(symbols) == 0 {
+		return tickers, nil
+	}
+
+	var list []*domain.Ticker
+	err := r.db.SelectContext(ctx, &list, `SELECT * FROM tickers WHERE symbol = ANY($1)`, pq.Array(symbols))
+	if err != nil {
+		return nil, err
+	}
+	for _, ticker := range list {
+		tickers[ticker.Symbol] = ticker
+	}
+	return tickers, nil
+}
+
+// SearchTickers finds active tickers by symbol prefix or name, exact symbol
+// matches first, then symbol prefixes, then names
+func (r *tickerRepository) SearchTickers(ctx context.Context, query string, limit int) ([]*domain.Ticker, error) {
+	tickers := make([]*domain.Ticker, 0)
+	symbol := strings.ToUpper(query)
+	err := r.db.SelectContext(ctx, &tickers,
+		`SELECT * FROM tickers
+		 WHERE is_active AND (symbol LIKE $2 || '%' OR name ILIKE '%' || $3 || '%')
+		 ORDER BY symbol = $1 DESC, symbol LIKE $2 || '%' DESC, LENGTH(symbol), symbol
+		 LIMIT $4`,
+		symbol, escapeLike(symbol), escapeLike(query), limit)
+	if err != nil {
+		return nil, err
+	}
+	return tickers, nil
+}
+
+// InsertMissingTickers adds the tickers that don't exist yet and returns how many were added
+func (r *tickerRepository) InsertMissingTickers(ctx context.Context, tickers []*domain.Ticker) (int, error) {
+	return r.insertTickers(ctx, tickers, `ON CONFLICT (symbol) DO NOTHING`)
+}
+
+// UpsertTickers creates or updates tickers and returns how many were written
+func (r *tickerRepository) UpsertTickers(ctx context.Context, tickers []*domain.Ticker) (int, error) {
+	return r.insertTickers(ctx, tickers, `
+		ON CONFLICT (symbol) DO UPDATE SET
+			name = EXCLUDED.name,
+			exchange = EXCLUDED.exchange,
+			asset_class = EXCLUDED.asset_class,
+			is_active = EXCLUDED.is_active,
+			updated_at = CURRENT_TIMESTAMP`)
+}
+
+func (r *tickerRepository) insertTickers(ctx context.Context, tickers []*domain.Ticker, onConflict string) (int, error) {
+	if len(tickers) == 0 {
+		return 0, nil
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO tickers (symbol, name, exchange, asset_class, is_active) VALUES ($1, $2, $3, $4, $5) `+onConflict)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	written := 0
+	for _, ticker := range tickers {
+		result, err := stmt.ExecContext(ctx, ticker.Symbol, ticker.Name, ticker.Exchange, ticker.AssetClass, ticker.IsActive)
+		if err != nil {
+			return 0, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		written += int(affected)
+	}
+
+	return written, tx.Commit()
+}
+
+// GetTickerStats counts the posts on a ticker, and the authors and bullish and
+// bearish posts among those created since the given time
+func (r *tickerRepository) GetTickerStats(ctx context.Context, symbol string, since time.Time) (*domain.TickerStats, error) {
+	stats := domain.TickerStats{}
+	err := r.db.GetContext(ctx, &stats,
+		`SELECT COUNT(*) AS post_count,
+		        COUNT(DISTINCT user_id) FILTER (WHERE created_at >= $2) AS recent_authors,
+		        COUNT(*) FILTER (WHERE created_at >= $2 AND sentiment = 'bullish') AS recent_bullish,
+		        COUNT(*) FILTER (WHERE created_at >= $2 AND sentiment = 'bearish') AS recent_bearish
+		 FROM posts WHERE ticker = $1`,
+		symbol, since)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetSentimentCounts counts the tagged posts on a ticker created within the window
+func (r *tickerRepository) GetSentimentCounts(ctx context.Context, symbol string, window time.Duration) (*domain.SentimentCounts, error) {
+	counts := domain.SentimentCounts{}
+	err := r.db.GetContext(ctx, &counts,
+		`SELECT COUNT(*) FILTER (WHERE sentiment = 'bullish') AS bullish,
+		        COUNT(*) FILTER (WHERE sentiment = 'bearish') AS bearish,
+		        COUNT(*) FILTER (WHERE sentiment = 'neutral') AS neutral
+		 FROM posts
+		 WHERE ticker = $1 AND sentiment IS NOT NULL AND created_at >= NOW() - $2 * INTERVAL '1 second'`,
+		symbol, window.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	return &counts, nil
+}
+
+// GetSentimentSeries counts the tagged posts on a ticker in the last buckets
+// periods of the given length, going back from now. Buckets without posts are
+// absent, BucketsAgo 0 is the most recent one.
+func (r *tickerRepository) GetSentimentSeries(ctx context.Context, symbol string, bucket time.Duration, buckets int) ([]*domain.SentimentBucket, error) {
+	series := make([]*domain.SentimentBucket, 0)
+	err := r.db.SelectContext(ctx, &series,
+		`SELECT FLOOR(EXTRACT(EPOCH FROM NOW() - created_at) / $2)::int AS buckets_ago,
+		        COUNT(*) FILTER (WHERE sentiment = 'bullish') AS bullish,
+		        COUNT(*) FILTER (WHERE sentiment = 'bearish') AS bearish,
+		        COUNT(*) FILTER (WHERE sentiment = 'neutral') AS neutral
+		 FROM posts
+		 WHERE ticker = $1 AND sentiment IS NOT NULL AND created_at >= NOW() - $2 * $3 * INTERVAL '1 second'
+		 GROUP BY 1`,
+		symbol, bucket.Seconds(), buckets)
+	if err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// escapeLike escapes the LIKE wildcards in user input
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Members are zero-padded post ids, so that posts sharing a score sort by id
+	timelineKeyPrefix = "timeline:"
+	// Scored below every post, it tells a built timeline with no posts from
+	// one that was never built, which would be rebuilt on every read
+	timelineBuiltMember = "built"
+	// Timelines of users who stop reading them expire, and are rebuilt if they come back
+	timelineTTL = 14 * 24 * time.Hour
+	// Timelines updated by one script call, so a huge fan-out doesn't block Redis for long
+	timelineScriptBatch = 1000
+)
+
+// timelineAddScript adds posts to the timelines that exist and trims them. A
+// missing timeline is left alone, it gets rebuilt in full on its next read.
+// ARGV[1] is the maximum length, followed by score and post id pairs. The
+// lowest member is the built marker, which the trim keeps on top of maxLen.
+var timelineAddScript = redis.NewScript(`
+local maxLen = tonumber(ARGV[1])
+for _, key in ipairs(KEYS) do
+	if redis.call('EXISTS', key) == 1 then
+		for i = 2, #ARGV, 2 do
+			redis.call('ZADD', key, ARGV[i], ARGV[i + 1])
+		end
+		redis.call('ZREMRANGEBYRANK', key, 1, -maxLen - 1)
+	end
+end
+return 0
+`)
+
+// TimelineRepository stores each user's home timeline as a sorted set of post
+// ids scored by creation time, ordered by (created_at, id) like the database
+type TimelineRepository interface {
+	AddPost(ctx context.Context, userIds []int, post *domain.Post, maxLen int) error
+	AddPosts(ctx context.Context, userId int, posts []*domain.Post, maxLen int) error
+	Rebuild(ctx context.Context, userId int, posts []*domain.Post, maxLen int) error
+	RemovePosts(ctx context.Context, userIds []int, postIds []int) error
+	GetPostIds(ctx context.Context, userId int, cursor *domain.Cursor, count int) ([]int, error)
+	Len(ctx context.Context, userId int) (size int64, built bool, err error)
+}
+
+type timelineRepository struct {
+	redis *redis.Client
+}
+
+func NewTimelineRepository(redis *redis.Client) TimelineRepository {
+	return &timelineRepository{
+		redis: redis,
+	}
+}
+
+// AddPost pushes a post into the existing timelines of the given users
+func (r *timelineRepository) AddPost(ctx context.Context, userIds []int, post *domain.Post, maxLen int) error {
+	for start := 0; start < len(userIds); start += timelineScriptBatch {
+		end := min(start+timelineScriptBatch, len(userIds))
+
+		keys := make([]string, 0, end-start)
+		for _, userId := range userIds[start:end] {
+			keys = append(keys, timelineKey(userId))
+		}
+
+		err := timelineAddScript.Run(ctx, r.redis, keys, maxLen, timelineScore(post), timelineMember(post.Id)).Err()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddPosts merges posts into a user's timeline if it exists
+func (r *timelineRepository) AddPosts(ctx context.Context, userId int, posts []*domain.Post, maxLen int) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, 1+2*len(posts))
+	args = append(args, maxLen)
+	for _, post := range posts {
+		args = append(args, timelineScore(post), timelineMember(post.Id))
+	}
+	return timelineAddScript.Run(ctx, r.redis, []string{timelineKey(userId)}, args...).Err()
+}
+
+// Rebuild replaces a user's timeline with the given posts, which may be none
+func (r *timelineRepository) Rebuild(ctx context.Context, userId int, posts []*domain.Post, maxLen int) error {
+	key := timelineKey(userId)
+	members := make([]redis.Z, 0, len(posts))
+	for _, post := range posts {
+		members = append(members, redis.Z{Score: timelineScore(post), Member: timelineMember(post.Id)})
+	}
+
+	_, err := r.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.ZAdd(ctx, key, redis.Z{Score: 0, Member: timelineBuiltMember})
+		if len(members) > 0 {
+			pipe.ZAdd(ctx, key, members...)
+			pipe.ZRemRangeByRank(ctx, key, 1, int64(-maxLen-1))
+		}
+		pipe.Expire(ctx, key, timelineTTL)
+		return nil
+	})
+	return err
+}
+
+// RemovePosts removes posts from the timelines of the given users
+func (r *timelineRepository) RemovePosts(ctx context.Context, userIds []int, postIds []int) error {
+	if len(userIds) == 0 || len(postIds) == 0 {
+		return nil
+	}
+
+	members := make([]interface{}, 0, len(postIds))
+	for _, postId := range postIds {
+		members = append(members, timelineMember(postId))
+	}
+
+	pipe := r.redis.Pipeline()
+	for _, userId := range userIds {
+		pipe.ZRem(ctx, timelineKey(userId), members...)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetPostIds returns up to count post ids of a user's timeline, newest first,
+// starting after the cursor when one is given
+func (r *timelineRepository) GetPostIds(ctx context.Context, userId int, cursor *domain.Cursor, count int) ([]int, error) {
+	key := timelineKey(userId)
+	ids := make([]int, 0, count)
+	max := "+inf"
+
+	if cursor != nil {
+		score := strconv.FormatInt(cursor.CreatedAt.UnixMicro(), 10)
+
+		// Posts sharing the cursor's timestamp, rarely more than one, come
+		// after it when their id is lower
+		ties, err := r.redis.ZRevRangeByScore(ctx, key, &redis.ZRangeBy{Min: score, Max: score}).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range ties {
+			if postId, err := strconv.Atoi(member); err == nil && postId < cursor.Id && len(ids) < count {
+				ids = append(ids, postId)
+			}
+		}
+		max = "(" + score
+	}
+
+	if len(ids) == count {
+		return ids, nil
+	}
+	// Above the built marker
+	members, err := r.redis.ZRevRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min:   "(0",
+		Max:   max,
+		Count: int64(count - len(ids)),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		if postId, err := strconv.Atoi(member); err == nil {
+			ids = append(ids, postId)
+		}
+	}
+	return ids, nil
+}
+
+// Len returns the number of posts in a user's timeline and whether it was
+// built, and keeps a timeline that is being read from expiring
+func (r *timelineRepository) Len(ctx context.Context, userId int) (int64, bool, error) {
+	key := timelineKey(userId)
+	pipe := r.redis.Pipeline()
+	card := pipe.ZCard(ctx, key)
+	pipe.Expire(ctx, key, timelineTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, false, err
+	}
+
+	// The built marker is not a post
+	if card.Val() == 0 {
+		return 0, false, nil
+	}
+	return card.Val() - 1, true, nil
+}
+
+func timelineKey(userId int) string {
+	return fmt.Sprintf("%s%d", timelineKeyPrefix, userId)
+}
+
+// timelineMember pads a post id so that members compare like the ids do
+func timelineMember(postId int) string {
+	return fmt.Sprintf("%019d", postId)
+}
+
+func timelineScore(post *domain.Post) float64 {
+	return float64(post.CreatedAt.UnixMicro())
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTimelineRepo(t *testing.T) TimelineRepository {
+	repo, _ := newTestTimelineRepoServer(t)
+	return repo
+}
+
+func newTestTimelineRepoServer(t *testing.T) (TimelineRepository, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewTimelineRepository(client), server
+}
+
+func TestTimelineAddPostOnlyTouchesBuiltTimelines(t *testing.T) {
+	repo := newTestTimelineRepo(t)
+	ctx := context.Background()
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.Rebuild(ctx, 1, []*domain.Post{{Id: 10, CreatedAt: base}}, 3))
+
+	// User 2 has no timeline yet, it must not end up with a partial one
+	require.NoError(t, repo.AddPost(ctx, []int{1, 2}, &domain.Post{Id: 11, CreatedAt: base.Add(time.Minute)}, 3))
+
+	ids, err := repo.GetPostIds(ctx, 1, nil, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{11, 10}, ids)
+
+	_, built, err := repo.Len(ctx, 2)
+	require.NoError(t, err)
+	assert.False(t, built)
+}
+
+func TestTimelineEmptyStaysBuilt(t *testing.T) {
+	repo, server := newTestTimelineRepoServer(t)
+	ctx := context.Background()
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Someone who follows nobody yet
+	require.NoError(t, repo.Rebuild(ctx, 1, nil, 3))
+	size, built, err := repo.Len(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, built)
+	assert.Zero(t, size)
+
+	ids, err := repo.GetPostIds(ctx, 1, nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	// Posts still reach it, and removing them leaves it built
+	require.NoError(t, repo.AddPost(ctx, []int{1}, &domain.Post{Id: 7, CreatedAt: base}, 3))
+	require.NoError(t, repo.RemovePosts(ctx, []int{1}, []int{7}))
+	_, built, err = repo.Len(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, built)
+
+	// Until nobody reads it for long enough
+	server.FastForward(timelineTTL)
+	_, built, err = repo.Len(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, built)
+}
+
+func TestTimelineTrimsToMaxLength(t *testing.T) {
+	repo := newTestTimelineRepo(t)
+	ctx := context.Background()
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.Rebuild(ctx, 1, []*domain.Post{{Id: 1, CreatedAt: base}}, 3))
+	for i := 2; i <= 5; i++ {
+		post := &domain.Post{Id: i, CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+		require.NoError(t, repo.AddPost(ctx, []int{1}, post, 3))
+	}
+
+	ids, err := repo.GetPostIds(ctx, 1, nil, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{5, 4, 3}, ids)
+
+	size, built, err := repo.Len(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, built)
+	assert.EqualValues(t, 3, size)
+}
+
+func TestTimelineGetPostIdsAfterCursor(t *testing.T) {
+	repo := newTestTimelineRepo(t)
+	ctx := context.Background()
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Posts 2 and 3 share a timestamp
+	posts := []*domain.Post{
+		{Id: 1, CreatedAt: base},
+		{Id: 2, CreatedAt: base.Add(time.Minute)},
+		{Id: 3, CreatedAt: base.Add(time.Minute)},
+		{Id: 4, CreatedAt: base.Add(2 * time.Minute)},
+	}
+	require.NoError(t, repo.Rebuild(ctx, 1, posts, 10))
+
+	ids, err := repo.GetPostIds(ctx, 1, nil, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4, 3}, ids)
+
+	ids, err = repo.GetPostIds(ctx, 1, &domain.Cursor{CreatedAt: posts[2].CreatedAt, Id: 3}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 1}, ids)
+}
+
+func TestTimelineOrdersTiesById(t *testing.T) {
+	repo := newTestTimelineRepo(t)
+	ctx := context.Background()
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Unpadded, "9" would sort after "10" and "11" as strings
+	posts := []*domain.Post{
+		{Id: 8, CreatedAt: base},
+		{Id: 9, CreatedAt: base.Add(time.Minute)},
+		{Id: 10, CreatedAt: base.Add(time.Minute)},
+		{Id: 11, CreatedAt: base.Add(time.Minute)},
+		{Id: 12, CreatedAt: base.Add(2 * time.Minute)},
+	}
+	require.NoError(t, repo.Rebuild(ctx, 1, posts, 10))
+
+	// One post a page, every page ends on a tie
+	var cursor *domain.Cursor
+	var seen []int
+	for {
+		ids, err := repo.GetPostIds(ctx, 1, cursor, 1)
+		require.NoError(t, err)
+		if len(ids) == 0 {
+			break
+		}
+		seen = append(seen, ids...)
+		for _, post := range posts {
+			if post.Id == ids[0] {
+				cursor = &domain.Cursor{CreatedAt: post.CreatedAt, Id: post.Id}
+			}
+		}
+	}
+	assert.Equal(t, []int{12, 11, 10, 9, 8}, seen)
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type TrendingDBRepository interface {
+	GetPostSignals(ctx context.Context, window, halfLife time.Duration) ([]*domain.PostSignals, error)
+}
+
+type trendingDBRepository struct {
+	db *sqlx.DB
+}
+
+func NewTrendingDBRepository(db *sqlx.DB) TrendingDBRepository {
+	return &trendingDBRepository{db: db}
+}
+
+// GetPostSignals returns the posts created, liked, commented on or viewed
+// within the window. Each like, comment and the post itself weigh
+// 0.5^(age / halfLife). Viewers are the unique viewers of the UTC days the
+// window touches.
+func (r *trendingDBRepository) GetPostSignals(ctx context.Context, window, halfLife time.Duration) ([]*domain.PostSignals, error) {
+	signals := make([]*domain.PostSignals, 0)
+	err := r.db.SelectContext(ctx, &signals,
+		`WITH recent_likes AS (
+			SELECT post_id, SUM(POWER(0.5, EXTRACT(EPOCH FROM NOW() - created_at) / $2)) AS likes
+			FROM likes WHERE created_at >= NOW() - $1 * INTERVAL '1 second'
+			GROUP BY post_id
+		 ), recent_comments AS (
+			SELECT post_id, SUM(POWER(0.5, EXTRACT(EPOCH FROM NOW() - created_at) / $2)) AS comments
+			FROM comments WHERE created_at >= NOW() - $1 * INTERVAL '1 second' AND deleted_at IS NULL
+			GROUP BY post_id
+		 ), recent_views AS (
+			SELECT post_id, SUM(unique_viewers) AS viewers
+			FROM post_views_daily WHERE day >= (NOW() - $1 * INTERVAL '1 second')::date
+			GROUP BY post_id
+		 )
+		 SELECT p.id AS post_id, p.ticker,
+		        EXTRACT(EPOCH FROM NOW() - p.created_at) AS age_seconds,
+		        CASE WHEN p.created_at >= NOW() - $1 * INTERVAL '1 second'
+		             THEN POWER(0.5, EXTRACT(EPOCH FROM NOW() - p.created_at) / $2) ELSE 0 END AS mention,
+		        COALESCE(l.likes, 0) AS likes,
+		        COALESCE(c.comments, 0) AS comments,
+		        COALESCE(v.viewers, 0) AS viewers
+		 FROM posts p
+		 LEFT JOIN recent_likes l ON l.post_id = p.id
+		 LEFT JOIN recent_comments c ON c.post_id = p.id
+		 LEFT JOIN recent_views v ON v.post_id = p.id
+		 WHERE p.created_at >= NOW() - $1 * INTERVAL '1 second'
+		    OR l.post_id IS NOT NULL OR c.post_id IS NOT NULL OR v.post_id IS NOT NULL`,
+		window.Seconds(), halfLife.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	return signals, nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	trendingTickersKey = "trending:tickers"
+	trendingPostsKey   = "trending:posts"
+	trendingLeaseKey   = "trending:lease"
+)
+
+// TrendingRedisRepository keeps the trending rankings as sorted sets, the
+// highest score first
+type TrendingRedisRepository interface {
+	AcquireLease(ctx context.Context, owner string, ttl time.Duration) (bool, error)
+	ReleaseLease(ctx context.Context, owner string) error
+	ReplaceTickers(ctx context.Context, scores []*domain.TrendingScore) error
+	ReplacePosts(ctx context.Context, scores []*domain.TrendingScore) error
+	GetTickers(ctx context.Context, limit int) ([]*domain.TrendingScore, error)
+	GetPosts(ctx context.Context, limit int) ([]*domain.TrendingScore, error)
+}
+
+type trendingRedisRepository struct {
+	redis *redis.Client
+}
+
+func NewTrendingRedisRepository(redis *redis.Client) TrendingRedisRepository {
+	return &trendingRedisRepository{
+		redis: redis,
+	}
+}
+
+// AcquireLease takes the cluster-wide lease so only one replica computes the rankings at a time
+func (r *trendingRedisRepository) AcquireLease(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	return r.redis.SetNX(ctx, trendingLeaseKey, owner, ttl).Result()
+}
+
+// ReleaseLease gives the lease back, unless it already expired and was taken by another replica
+func (r *trendingRedisRepository) ReleaseLease(ctx context.Context, owner string) error {
+	return releaseLeaseScript.Run(ctx, r.redis, []string{trendingLeaseKey}, owner).Err()
+}
+
+func (r *trendingRedisRepository) ReplaceTickers(ctx context.Context, scores []*domain.TrendingScore) error {
+	return r.replaceRanking(ctx, trendingTickersKey, scores)
+}
+
+func (r *trendingRedisRepository) ReplacePosts(ctx context.Context, scores []*domain.TrendingScore) error {
+	return r.replaceRanking(ctx, trendingPostsKey, scores)
+}
+
+func (r *trendingRedisRepository) GetTickers(ctx context.Context, limit int) ([]*domain.TrendingScore, error) {
+	return r.getRanking(ctx, trendingTickersKey, limit)
+}
+
+func (r *trendingRedisRepository) GetPosts(ctx context.Context, limit int) ([]*domain.TrendingScore, error) {
+	return r.getRanking(ctx, trendingPostsKey, limit)
+}
+
+// replaceRanking swaps a ranking for a new one, readers see either in full
+func (r *trendingRedisRepository) replaceRanking(ctx context.Context, key string, scores []*domain.TrendingScore) error {
+	members := make([]redis.Z, 0, len(scores))
+	for _, score := range scores {
+		members = append(members, redis.Z{Score: score.Score, Member: score.Member})
+	}
+
+	_, err := r.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		if len(members) > 0 {
+			pipe.ZAdd(ctx, key, members...)
+		}
+		return nil
+	})
+	return err
+}
+
+// getRanking returns the top members of a ranking, highest score first
+func (r *trendingRedisRepository) getRanking(ctx context.Context, key string, limit int) ([]*domain.TrendingScore, error) {
+	entries, err := r.redis.ZRevRangeWithScores(ctx, key, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]*domain.TrendingScore, 0, len(entries))
+	for _, entry := range entries {
+		member, ok := entry.Member.(string)
+		if !ok {
+			continue
+		}
+		scores = append(scores, &domain.TrendingScore{Member: member, Score: entry.Score})
+	}
+	return scores, nil
+}
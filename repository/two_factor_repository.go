@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type TwoFactorRepository interface {
+	GetTwoFactor(ctx context.Context, userId int) (*domain.TwoFactor, error)
+	// SaveSecret stores a new secret for the user to enroll with, unless they have two-factor authentication on
+	SaveSecret(ctx context.Context, userId int, secret string) error
+	// Enable turns two-factor authentication on, accepting the code of step,
+	// and replaces the recovery codes. Returns false when it was on already or
+	// the step was used.
+	Enable(ctx context.Context, userId int, step int64, recoveryCodeHashes []string) (bool, error)
+	// Disable removes the secret, recovery codes and pending challenges of the user
+	Disable(ctx context.Context, userId int) error
+	// UseStep records that a code of step was accepted, returns false when one of it or a later step already was
+	UseStep(ctx context.Context, userId int, step int64) (bool, error)
+	// UseRecoveryCode spends an unused recovery code, returns false when there is none with the hash
+	UseRecoveryCode(ctx context.Context, userId int, codeHash string) (bool, error)
+	CreateChallenge(ctx context.Context, userId int, tokenHash string, expiresAt time.Time) error
+	// AttemptChallenge counts an attempt at a live challenge that has attempts
+	// left and returns its user. Returns sql.ErrNoRows otherwise.
+	AttemptChallenge(ctx context.Context, tokenHash string, maxAttempts int) (userId int, err error)
+	// CompleteChallenge spends a challenge, returns false when it was already
+	CompleteChallenge(ctx context.Context, tokenHash string) (bool, error)
+}
+
+type twoFactorRepository struct {
+	db *sqlx.DB
+}
+
+func NewTwoFactorRepository(db *sqlx.DB) TwoFactorRepository {
+	return &twoFactorRepository{db: db}
+}
+
+func (r *twoFactorRepository) GetTwoFactor(ctx context.Context, userId int) (*domain.TwoFactor, error) {
+	twoFactor := domain.TwoFactor{}
+	err := r.db.GetContext(ctx, &twoFactor, `SELECT * FROM two_factor WHERE user_id = $1`, userId)
+	if err != nil {
+		return nil, err
+	}
+	return &twoFactor, nil
+}
+
+func (r *twoFactorRepository) SaveSecret(ctx context.Context, userId int, secret string) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO two_factor (user_id, secret) VALUES ($1, $2)
+		 ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = 0, created_at = CURRENT_TIMESTAMP
+		 WHERE two_factor.enabled_at IS NULL`,
+		userId, secret)
+	return err
+}
+
+func (r *twoFactorRepository) Enable(ctx context.Context, userId int, step int64, recoveryCodeHashes []string) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE two_factor SET enabled_at = CURRENT_TIMESTAMP, last_used_step = $2
+		 WHERE user_id = $1 AND enabled_at IS NULL AND last_used_step < $2`,
+		userId, step)
+	if err != nil {
+		return false, err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM two_factor_recovery_codes WHERE user_id = $1`, userId); err != nil {
+		return false, err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO two_factor_recovery_codes (user_id, code_hash) SELECT $1, UNNEST($2::text[])`,
+		userId, pq.Array(recoveryCodeHashes))
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (r *twoFactorRepository) Disable(ctx context.Context, userId int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		`DELETE FROM two_factor WHERE user_id = $1`,
+		`DELETE FROM two_factor_recovery_codes WHERE user_id = $1`,
+		`DELETE FROM two_factor_challenges WHERE user_id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, userId); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *twoFactorRepository) UseStep(ctx context.Context, userId int, step int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE two_factor SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`,
+		userId, step)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *twoFactorRepository) UseRecoveryCode(ctx context.Context, userId int, codeHash string) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE two_factor_recovery_codes SET used_at = CURRENT_TIMESTAMP
+		 WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`,
+		userId, codeHash)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *twoFactorRepository) CreateChallenge(ctx context.Context, userId int, tokenHash string, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO two_factor_challenges (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`,
+		userId, tokenHash, expiresAt)
+	return err
+}
+
+func (r *twoFactorRepository) AttemptChallenge(ctx context.Context, tokenHash string, maxAttempts int) (int, error) {
+	var userId int
+	err := r.db.GetContext(ctx, &userId,
+		`UPDATE two_factor_challenges SET attempts = attempts + 1
+		 WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW() AND attempts < $2
+		 RETURNING user_id`,
+		tokenHash, maxAttempts)
+	return userId, err
+}
+
+func (r *twoFactorRepository) CompleteChallenge(ctx context.Context, tokenHash string) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE two_factor_challenges SET used_at = CURRENT_TIMESTAMP WHERE token_hash = $1 AND used_at IS NULL`,
+		tokenHash)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
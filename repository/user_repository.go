@@ -2,17 +2,23 @@ package repository
 
 import (
 	"context"
+	"strings"
 
 	"github.com/Pro100-Almaz/trading-chat/domain"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserRepository interface {
 	GetUsers(ctx context.Context) ([]*domain.User, error)
 	GetUserById(ctx context.Context, id int) (*domain.User, error)
+	GetUsersByIds(ctx context.Context, ids []int) (map[int]*domain.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
+	// GetUserIdsByNames maps lower-cased names to the one user holding each,
+	// names shared by several users are left out
+	GetUserIdsByNames(ctx context.Context, names []string) (map[string]int, error)
 	CreateUser(ctx context.Context, user *domain.User) (*domain.User, error)
 	UpdateUser(ctx context.Context, user *domain.User) error
 	DeleteUser(ctx context.Context, userId int) error
@@ -48,6 +54,60 @@ func (r *userRepository) GetUserById(ctx context.Context, id int) (*domain.User,
 	return &user, nil
 }
 
+// GetUsersByIds loads several users in one query, keyed by id. Unknown ids are absent from the map.
+func (r *userRepository) GetUsersByIds(ctx context.Context, ids []int) (map[int]*domain.User, error) {
+	usersById := make(map[int]*domain.User, len(ids))
+	if len(ids) == 0 {
+		return usersById, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT * FROM users WHERE id IN (?)`, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []*domain.User
+	err = r.db.SelectContext(ctx, &users, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		usersById[user.Id] = user
+	}
+	return usersById, nil
+}
+
+func (r *userRepository) GetUserIdsByNames(ctx context.Context, names []string) (map[string]int, error) {
+	ids := make(map[string]int, len(names))
+	if len(names) == 0 {
+		return ids, nil
+	}
+
+	lowered := make([]string, 0, len(names))
+	for _, name := range names {
+		lowered = append(lowered, strings.ToLower(name))
+	}
+
+	var rows []struct {
+		Name string `db:"name"`
+		Id   int    `db:"id"`
+	}
+	err := r.db.SelectContext(ctx, &rows,
+		`SELECT LOWER(name) AS name, MIN(id) AS id FROM users
+		 WHERE LOWER(name) = ANY($1)
+		 GROUP BY LOWER(name) HAVING COUNT(*) = 1`,
+		pq.Array(lowered))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		ids[row.Name] = row.Id
+	}
+	return ids, nil
+}
+
 func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
 	user := domain.User{}
 	err := r.db.GetContext(ctx, &user, `SELECT * FROM users WHERE email = $1`, email)
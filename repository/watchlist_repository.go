@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// WatchlistRepository keeps watchlists and their tickers. Lookups are scoped
+// to the owner, a watchlist of another user reads as missing.
+type WatchlistRepository interface {
+	GetWatchlists(ctx context.Context, userId int) ([]*domain.Watchlist, error)
+	// GetWatchlist returns a watchlist of the user, or sql.ErrNoRows
+	GetWatchlist(ctx context.Context, userId, watchlistId int) (*domain.Watchlist, error)
+	GetWatchlistsCount(ctx context.Context, userId int) (int, error)
+	// CreateWatchlist stores a watchlist with its tickers, or returns
+	// sql.ErrNoRows when the user has one of that name
+	CreateWatchlist(ctx context.Context, watchlist *domain.Watchlist, symbols []string) (int, error)
+	// UpdateWatchlist renames a watchlist and, unless symbols is nil, replaces
+	// its tickers. Returns sql.ErrNoRows when the name is taken.
+	UpdateWatchlist(ctx context.Context, watchlist *domain.Watchlist, symbols []string) error
+	DeleteWatchlist(ctx context.Context, userId, watchlistId int) error
+	// GetSymbols lists the tickers of several watchlists in the order they were added, keyed by watchlist id
+	GetSymbols(ctx context.Context, watchlistIds []int) (map[int][]string, error)
+	GetSymbolsCount(ctx context.Context, watchlistId int) (int, error)
+	AddSymbol(ctx context.Context, watchlistId int, symbol string) error
+	RemoveSymbol(ctx context.Context, watchlistId int, symbol string) error
+	HasSymbol(ctx context.Context, watchlistId int, symbol string) (bool, error)
+}
+
+type watchlistRepository struct {
+	db *sqlx.DB
+}
+
+func NewWatchlistRepository(db *sqlx.DB) WatchlistRepository {
+	return &watchlistRepository{db: db}
+}
+
+func (r *watchlistRepository) GetWatchlists(ctx context.Context, userId int) ([]*domain.Watchlist, error) {
+	watchlists := make([]*domain.Watchlist, 0)
+	err := r.db.SelectContext(ctx, &watchlists,
+		`SELECT * FROM watchlists WHERE user_id = $1 ORDER BY created_at, id`,
+		userId)
+	if err != nil {
+		return nil, err
+	}
+	return watchlists, nil
+}
+
+func (r *watchlistRepository) GetWatchlist(ctx context.Context, userId, watchlistId int) (*domain.Watchlist, error) {
+	var watchlist domain.Watchlist
+	err := r.db.GetContext(ctx, &watchlist,
+		`SELECT * FROM watchlists WHERE id = $1 AND user_id = $2`,
+		watchlistId, userId)
+	if err != nil {
+		return nil, err
+	}
+	return &watchlist, nil
+}
+
+func (r *watchlistRepository) GetWatchlistsCount(ctx context.Context, userId int) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM watchlists WHERE user_id = $1`, userId)
+	return count, err
+}
+
+func (r *watchlistRepository) CreateWatchlist(ctx context.Context, watchlist *domain.Watchlist, symbols []string) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO watchlists (user_id, name) VALUES ($1, $2)
+		 ON CONFLICT (user_id, name) DO NOTHING RETURNING id`,
+		watchlist.UserId, watchlist.Name).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := insertSymbols(ctx, tx, id, symbols); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+func (r *watchlistRepository) UpdateWatchlist(ctx context.Context, watchlist *domain.Watchlist, symbols []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE watchlists SET name = $3, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = $1 AND user_id = $2
+		   AND NOT EXISTS (SELECT 1 FROM watchlists WHERE user_id = $2 AND name = $3 AND id <> $1)`,
+		watchlist.Id, watchlist.UserId, watchlist.Name)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	if symbols != nil {
+		// Alert rules on the tickers taken off go with them
+		_, err = tx.ExecContext(ctx,
+			`DELETE FROM watchlist_tickers WHERE watchlist_id = $1 AND symbol <> ALL($2)`,
+			watchlist.Id, pq.Array(symbols))
+		if err != nil {
+			return err
+		}
+		if err := insertSymbols(ctx, tx, watchlist.Id, symbols); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// insertSymbols adds tickers to a watchlist, skipping the ones it holds
+func insertSymbols(ctx context.Context, tx *sqlx.Tx, watchlistId int, symbols []string) error {
+	for _, symbol := range symbols {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO watchlist_tickers (watchlist_id, symbol) VALUES ($1, $2)
+			 ON CONFLICT (watchlist_id, symbol) DO NOTHING`,
+			watchlistId, symbol)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *watchlistRepository) DeleteWatchlist(ctx context.Context, userId, watchlistId int) error {
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM watchlists WHERE id = $1 AND user_id = $2`,
+		watchlistId, userId)
+	return err
+}
+
+func (r *watchlistRepository) GetSymbols(ctx context.Context, watchlistIds []int) (map[int][]string, error) {
+	symbols := make(map[int][]string, len(watchlistIds))
+	if len(watchlistIds) == 0 {
+		return symbols, nil
+	}
+
+	var rows []struct {
+		WatchlistId int    `db:"watchlist_id"`
+		Symbol      string `db:"symbol"`
+	}
+	err := r.db.SelectContext(ctx, &rows,
+		`SELECT watchlist_id, symbol FROM watchlist_tickers WHERE watchlist_id = ANY($1)
+		 ORDER BY created_at, symbol`,
+		pq.Array(watchlistIds))
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		symbols[row.WatchlistId] = append(symbols[row.WatchlistId], row.Symbol)
+	}
+	return symbols, nil
+}
+
+func (r *watchlistRepository) GetSymbolsCount(ctx context.Context, watchlistId int) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM watchlist_tickers WHERE watchlist_id = $1`, watchlistId)
+	return count, err
+}
+
+func (r *watchlistRepository) AddSymbol(ctx context.Context, watchlistId int, symbol string) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO watchlist_tickers (watchlist_id, symbol) VALUES ($1, $2)
+		 ON CONFLICT (watchlist_id, symbol) DO NOTHING`,
+		watchlistId, symbol)
+	return err
+}
+
+func (r *watchlistRepository) RemoveSymbol(ctx context.Context, watchlistId int, symbol string) error {
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM watchlist_tickers WHERE watchlist_id = $1 AND symbol = $2`,
+		watchlistId, symbol)
+	return err
+}
+
+func (r *watchlistRepository) HasSymbol(ctx context.Context, watchlistId int, symbol string) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists,
+		`SELECT EXISTS(SELECT 1 FROM watchlist_tickers WHERE watchlist_id = $1 AND symbol = $2)`,
+		watchlistId, symbol)
+	return exists, err
+}
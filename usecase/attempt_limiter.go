@@ -0,0 +1,176 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/internal/email"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// attemptPolicy is how failed attempts of one account or address are slowed
+// down. Past freeFailures each failure makes the next attempt wait, twice as
+// long every time. At lockoutFailures the subject is locked out, twice as long
+// as the lockout before it on the same day.
+type attemptPolicy struct {
+	// Failures are forgotten this long after the first one
+	window          time.Duration
+	freeFailures    int
+	lockoutFailures int
+	backoffBase     time.Duration
+	maxBackoff      time.Duration
+	lockoutBase     time.Duration
+	maxLockout      time.Duration
+}
+
+var (
+	accountAttemptPolicy = attemptPolicy{
+		window:          15 * time.Minute,
+		freeFailures:    3,
+		lockoutFailures: 10,
+		backoffBase:     time.Second,
+		maxBackoff:      time.Minute,
+		lockoutBase:     15 * time.Minute,
+		maxLockout:      24 * time.Hour,
+	}
+	// Several people can share an address, so it takes more to lock one out
+	ipAttemptPolicy = attemptPolicy{
+		window:          15 * time.Minute,
+		freeFailures:    10,
+		lockoutFailures: 50,
+		backoffBase:     time.Second,
+		maxBackoff:      time.Minute,
+		lockoutBase:     15 * time.Minute,
+		maxLockout:      24 * time.Hour,
+	}
+	// A second factor is only asked of someone who knows the password, and
+	// six digits are guessed through soon, so it locks out sooner and for longer.
+	// Failures count per user across challenges.
+	twoFactorAttemptPolicy = attemptPolicy{
+		window:          time.Hour,
+		freeFailures:    2,
+		lockoutFailures: 5,
+		backoffBase:     time.Second,
+		maxBackoff:      time.Minute,
+		lockoutBase:     30 * time.Minute,
+		maxLockout:      24 * time.Hour,
+	}
+)
+
+// penalty returns how long a subject waits after its latest failure, and
+// whether that is a lockout
+func (p attemptPolicy) penalty(failures, lockouts int) (time.Duration, bool) {
+	if failures >= p.lockoutFailures {
+		return doubled(p.lockoutBase, lockouts, p.maxLockout), true
+	}
+	if failures > p.freeFailures {
+		return doubled(p.backoffBase, failures-p.freeFailures-1, p.maxBackoff), false
+	}
+	return 0, false
+}
+
+// doubled returns base doubled times over, up to max
+func doubled(base time.Duration, times int, max time.Duration) time.Duration {
+	d := base
+	for i := 0; i < times && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
+
+// attemptLimiter slows down guessing at one action, such as "login", per
+// account as accountPolicy says and per address. It lets attempts through
+// when Redis is down rather than lock everyone out.
+type attemptLimiter struct {
+	loginAttemptRepository repository.LoginAttemptRepository
+	emailService           *email.EmailService
+	action                 string
+	accountPolicy          attemptPolicy
+}
+
+func newAttemptLimiter(loginAttemptRepository repository.LoginAttemptRepository, emailService *email.EmailService, action string, accountPolicy attemptPolicy) *attemptLimiter {
+	return &attemptLimiter{
+		loginAttemptRepository: loginAttemptRepository,
+		emailService:           emailService,
+		action:                 action,
+		accountPolicy:          accountPolicy,
+	}
+}
+
+func (l *attemptLimiter) accountSubject(account string) string {
+	return l.action + ":account:" + strings.ToLower(strings.TrimSpace(account))
+}
+
+func (l *attemptLimiter) ipSubject(ip string) string {
+	return l.action + ":ip:" + ip
+}
+
+// check returns a TooManyAttemptsError while the account or the address has to wait
+func (l *attemptLimiter) check(ctx context.Context, account, ip string) error {
+	retryAfter, err := l.loginAttemptRepository.GetRetryAfter(ctx, l.accountSubject(account), l.ipSubject(ip))
+	if err != nil {
+		log.Error("Failed to check failed attempts: ", err)
+		return nil
+	}
+	if retryAfter > 0 {
+		return &domain.TooManyAttemptsError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// fail records a failed attempt at the account from the address. When that
+// locks the account out, the user is told by email if there is one, without
+// holding up the request.
+func (l *attemptLimiter) fail(ctx context.Context, account, ip string, user *domain.User) {
+	lockout, err := l.record(ctx, l.accountSubject(account), l.accountPolicy)
+	if err != nil {
+		log.Error("Failed to record failed attempt: ", err)
+	} else if lockout > 0 && user != nil {
+		go func(to string) {
+			if err := l.emailService.SendLockoutNotice(to, lockout); err != nil {
+				log.Error("Failed to send lockout email: ", err)
+			}
+		}(user.Email)
+	}
+
+	if _, err := l.record(ctx, l.ipSubject(ip), ipAttemptPolicy); err != nil {
+		log.Error("Failed to record failed attempt: ", err)
+	}
+}
+
+// record counts a failure of the subject and makes it wait as the policy says.
+// Returns how long it is locked out for, 0 when it only backs off.
+func (l *attemptLimiter) record(ctx context.Context, subject string, policy attemptPolicy) (time.Duration, error) {
+	failures, lockouts, err := l.loginAttemptRepository.RecordFailure(ctx, subject, policy.window)
+	if err != nil {
+		return 0, err
+	}
+
+	wait, lockout := policy.penalty(failures, lockouts)
+	if wait == 0 {
+		return 0, nil
+	}
+	if err := l.loginAttemptRepository.Lock(ctx, subject, wait, lockout); err != nil {
+		return 0, err
+	}
+	if !lockout {
+		return 0, nil
+	}
+
+	log.Warnf("Locked out %s for %s after %d failed attempts", subject, wait, failures)
+	return wait, nil
+}
+
+// succeed forgets the failures of the account after a successful attempt
+func (l *attemptLimiter) succeed(ctx context.Context, account string) {
+	if err := l.loginAttemptRepository.ClearFailures(ctx, l.accountSubject(account)); err != nil {
+		log.Error("Failed to clear failed attempts: ", err)
+	}
+}
@@ -0,0 +1,41 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttemptPolicyPenalty(t *testing.T) {
+	tests := []struct {
+		name        string
+		failures    int
+		lockouts    int
+		wantWait    time.Duration
+		wantLockout bool
+	}{
+		{name: "free failures", failures: 3, wantWait: 0},
+		{name: "first backoff", failures: 4, wantWait: time.Second},
+		{name: "backoff doubles", failures: 6, wantWait: 4 * time.Second},
+		{name: "backoff is capped", failures: 9, wantWait: 32 * time.Second},
+		{name: "first lockout", failures: 10, wantWait: 15 * time.Minute, wantLockout: true},
+		{name: "lockout doubles", failures: 10, lockouts: 2, wantWait: time.Hour, wantLockout: true},
+		{name: "lockout is capped", failures: 10, lockouts: 20, wantWait: 24 * time.Hour, wantLockout: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait, lockout := accountAttemptPolicy.penalty(tt.failures, tt.lockouts)
+			assert.Equal(t, tt.wantWait, wait)
+			assert.Equal(t, tt.wantLockout, lockout)
+		})
+	}
+}
+
+func TestDoubled(t *testing.T) {
+	assert.Equal(t, time.Second, doubled(time.Second, 0, time.Minute))
+	assert.Equal(t, 8*time.Second, doubled(time.Second, 3, time.Minute))
+	assert.Equal(t, time.Minute, doubled(time.Second, 6, time.Minute))
+	assert.Equal(t, time.Minute, doubled(time.Second, 1000, time.Minute))
+}
@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const maxChatMessageLength = 1000
+
+type chatUseCase struct {
+	chatMessageRepository repository.ChatMessageRepository
+	chatPubSubRepository  repository.ChatPubSubRepository
+	userRepository        repository.UserRepository
+	contextTimeout        time.Duration
+}
+
+func NewChatUseCase(
+	chatMessageRepo repository.ChatMessageRepository,
+	chatPubSubRepo repository.ChatPubSubRepository,
+	userRepo repository.UserRepository,
+	timeout time.Duration,
+) domain.ChatUseCase {
+	return &chatUseCase{
+		chatMessageRepository: chatMessageRepo,
+		chatPubSubRepository:  chatPubSubRepo,
+		userRepository:        userRepo,
+		contextTimeout:        timeout,
+	}
+}
+
+func normalizeChatTicker(ticker string) (string, error) {
+	ticker = strings.ToUpper(strings.TrimSpace(ticker))
+	if ticker == "" {
+		return "", errors.New("ticker is required")
+	}
+	if len(ticker) > 20 {
+		return "", errors.New("ticker must be at most 20 characters")
+	}
+	return ticker, nil
+}
+
+func (uc *chatUseCase) GetHistory(ctx context.Context, ticker string, limit, offset int) (*domain.PaginatedResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	ticker, err := normalizeChatTicker(ticker)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := uc.chatMessageRepository.GetMessagesByTicker(ctx, ticker, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := uc.chatMessageRepository.GetMessagesCount(ctx, ticker)
+	if err != nil {
+		return nil, err
+	}
+
+	userIds := make([]int, 0, len(messages))
+	seen := make(map[int]bool, len(messages))
+	for _, message := range messages {
+		if !seen[message.UserId] {
+			seen[message.UserId] = true
+			userIds = append(userIds, message.UserId)
+		}
+	}
+	users, err := uc.userRepository.GetUsersByIds(ctx, userIds)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*domain.ChatMessageResponse, 0, len(messages))
+	for _, message := range messages {
+		response := &domain.ChatMessageResponse{
+			Id:        message.Id,
+			Ticker:    message.Ticker,
+			Body:      message.Body,
+			CreatedAt: message.CreatedAt,
+		}
+		if user, ok := users[message.UserId]; ok {
+			response.Author = domain.Author{Id: user.Id, Name: user.Name, AvatarEmoji: user.AvatarEmoji}
+		}
+		responses = append(responses, response)
+	}
+
+	return domain.NewPaginatedResponse(responses, total, limit, offset), nil
+}
+
+func (uc *chatUseCase) SendMessage(ctx context.Context, userId int, ticker string, request *domain.SendChatMessageRequest) (*domain.ChatMessageResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	ticker, err := normalizeChatTicker(ticker)
+	if err != nil {
+		return nil, err
+	}
+
+	body := strings.TrimSpace(request.Body)
+	if body == "" {
+		return nil, errors.New("body is required")
+	}
+	if len(body) > maxChatMessageLength {
+		return nil, errors.New("message is too long")
+	}
+
+	user, err := uc.userRepository.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := uc.chatMessageRepository.CreateMessage(ctx, &domain.ChatMessage{
+		Ticker: ticker,
+		UserId: userId,
+		Body:   body,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &domain.ChatMessageResponse{
+		Id:        message.Id,
+		Ticker:    message.Ticker,
+		Body:      message.Body,
+		CreatedAt: message.CreatedAt,
+		Author:    domain.Author{Id: user.Id, Name: user.Name, AvatarEmoji: user.AvatarEmoji},
+	}
+
+	// The message is already persisted, so it will show up in history even if
+	// the live fan-out fails. Failing the send would only make the client retry
+	// and store it twice.
+	if err := uc.chatPubSubRepository.Publish(ctx, response); err != nil {
+		log.Error("Failed to publish chat message: ", err)
+	}
+
+	return response, nil
+}
+
+func (uc *chatUseCase) Subscribe(ctx context.Context, ticker string) (<-chan *domain.ChatMessageResponse, error) {
+	ticker, err := normalizeChatTicker(ticker)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.chatPubSubRepository.Subscribe(ctx, ticker)
+}
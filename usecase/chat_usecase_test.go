@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChatMessageRepo keeps chat messages in memory, numbered and timed in the order they are created
+type fakeChatMessageRepo struct {
+	mu       sync.Mutex
+	messages []*domain.ChatMessage
+	now      time.Time
+}
+
+func (f *fakeChatMessageRepo) CreateMessage(ctx context.Context, message *domain.ChatMessage) (*domain.ChatMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	created := *message
+	created.Id = len(f.messages) + 1
+	created.CreatedAt = f.now.Add(time.Duration(created.Id) * time.Second)
+	f.messages = append(f.messages, &created)
+	return &created, nil
+}
+
+func (f *fakeChatMessageRepo) GetMessagesByTicker(ctx context.Context, ticker string, limit, offset int) ([]*domain.ChatMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	messages := make([]*domain.ChatMessage, 0)
+	for _, message := range f.messages {
+		if message.Ticker == ticker {
+			messages = append(messages, message)
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].Id > messages[j].Id })
+	if offset >= len(messages) {
+		return []*domain.ChatMessage{}, nil
+	}
+	return messages[offset:min(offset+limit, len(messages))], nil
+}
+
+func (f *fakeChatMessageRepo) GetMessagesCount(ctx context.Context, ticker string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for _, message := range f.messages {
+		if message.Ticker == ticker {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func newTestChatUseCase(t *testing.T) (domain.ChatUseCase, *fakeChatMessageRepo, *fakeUserRepo, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	messages := &fakeChatMessageRepo{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	users := newFakeUserRepo(
+		&domain.User{Id: 1, Name: "Jane", AvatarEmoji: 3},
+		&domain.User{Id: 2, Name: "John", AvatarEmoji: 7},
+	)
+	uc := NewChatUseCase(messages, repository.NewChatPubSubRepository(client), users, time.Second)
+	return uc, messages, users, server
+}
+
+func TestChatSendMessagePersistsAndPublishes(t *testing.T) {
+	uc, messages, _, _ := newTestChatUseCase(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	live, err := uc.Subscribe(ctx, "aapl")
+	require.NoError(t, err)
+
+	sent, err := uc.SendMessage(ctx, 1, " aapl ", &domain.SendChatMessageRequest{Body: "  Anyone watching the open?  "})
+	require.NoError(t, err)
+	assert.Equal(t, "AAPL", sent.Ticker)
+	assert.Equal(t, "Anyone watching the open?", sent.Body)
+	assert.Equal(t, domain.Author{Id: 1, Name: "Jane", AvatarEmoji: 3}, sent.Author)
+
+	require.Len(t, messages.messages, 1)
+	assert.Equal(t, "AAPL", messages.messages[0].Ticker)
+
+	select {
+	case received := <-live:
+		assert.Equal(t, sent.Id, received.Id)
+		assert.Equal(t, sent.Body, received.Body)
+		assert.Equal(t, sent.Author, received.Author)
+	case <-time.After(time.Second):
+		t.Fatal("message was not published to the room")
+	}
+}
+
+func TestChatSendMessageIsPersistedWhenPublishFails(t *testing.T) {
+	uc, messages, _, server := newTestChatUseCase(t)
+	server.Close()
+
+	// The send succeeded, the room just missed it live
+	sent, err := uc.SendMessage(context.Background(), 1, "AAPL", &domain.SendChatMessageRequest{Body: "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", sent.Body)
+	require.Len(t, messages.messages, 1)
+	assert.Equal(t, sent.Id, messages.messages[0].Id)
+}
+
+func TestChatSendMessageValidates(t *testing.T) {
+	uc, messages, _, _ := newTestChatUseCase(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		ticker string
+		body   string
+	}{
+		{name: "empty body", ticker: "AAPL", body: "   "},
+		{name: "too long", ticker: "AAPL", body: strings.Repeat("a", maxChatMessageLength+1)},
+		{name: "no ticker", ticker: " ", body: "hello"},
+		{name: "ticker too long", ticker: strings.Repeat("A", 21), body: "hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := uc.SendMessage(ctx, 1, tt.ticker, &domain.SendChatMessageRequest{Body: tt.body})
+			assert.Error(t, err)
+		})
+	}
+	assert.Empty(t, messages.messages)
+}
+
+func TestChatHistoryLoadsAuthorsInOneQuery(t *testing.T) {
+	uc, _, users, _ := newTestChatUseCase(t)
+	ctx := context.Background()
+
+	for i, userId := range []int{1, 2, 1, 2, 1} {
+		_, err := uc.SendMessage(ctx, userId, "AAPL", &domain.SendChatMessageRequest{Body: strings.Repeat("x", i+1)})
+		require.NoError(t, err)
+	}
+	_, err := uc.SendMessage(ctx, 2, "TSLA", &domain.SendChatMessageRequest{Body: "other room"})
+	require.NoError(t, err)
+	users.getUserByIdCalls, users.getUsersByIdsCalls = 0, 0
+
+	page, err := uc.GetHistory(ctx, "aapl", 3, 1)
+	require.NoError(t, err)
+	require.NotNil(t, page.Total)
+	assert.Equal(t, 5, *page.Total)
+
+	history := page.Data.([]*domain.ChatMessageResponse)
+	require.Len(t, history, 3)
+	// Newest first, past the offset
+	assert.Equal(t, []int{4, 3, 2}, []int{history[0].Id, history[1].Id, history[2].Id})
+	assert.Equal(t, "John", history[0].Author.Name)
+	assert.Equal(t, "Jane", history[1].Author.Name)
+
+	assert.Equal(t, 1, users.getUsersByIdsCalls)
+	assert.Zero(t, users.getUserByIdCalls)
+}
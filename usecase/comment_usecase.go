@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"time"
 
@@ -9,61 +10,109 @@ import (
 	"github.com/Pro100-Almaz/trading-chat/repository"
 )
 
+const (
+	// Replies to replies are allowed this many levels under a comment on the post
+	maxReplyDepth = 4
+	// Replies listed under each comment of a post, the rest are paged through separately
+	repliesShown = 3
+)
+
 type commentUseCase struct {
-	commentRepository repository.CommentRepository
-	postRepository    repository.PostRepository
-	userRepository    repository.UserRepository
-	contextTimeout    time.Duration
+	commentRepository   repository.CommentRepository
+	postRepository      repository.PostRepository
+	userRepository      repository.UserRepository
+	feedEventRepository repository.FeedEventRepository
+	tickerUseCase       domain.TickerUseCase
+	notificationUseCase domain.NotificationUseCase
+	contextTimeout      time.Duration
 }
 
 func NewCommentUseCase(
 	commentRepo repository.CommentRepository,
 	postRepo repository.PostRepository,
 	userRepo repository.UserRepository,
+	feedEventRepo repository.FeedEventRepository,
+	tickerUseCase domain.TickerUseCase,
+	notificationUseCase domain.NotificationUseCase,
 	timeout time.Duration,
 ) domain.CommentUseCase {
 	return &commentUseCase{
-		commentRepository: commentRepo,
-		postRepository:    postRepo,
-		userRepository:    userRepo,
-		contextTimeout:    timeout,
+		commentRepository:   commentRepo,
+		postRepository:      postRepo,
+		userRepository:      userRepo,
+		feedEventRepository: feedEventRepo,
+		tickerUseCase:       tickerUseCase,
+		notificationUseCase: notificationUseCase,
+		contextTimeout:      timeout,
 	}
 }
 
-func (uc *commentUseCase) GetComments(ctx context.Context, postId, limit, offset int) (*domain.PaginatedResponse, error) {
+func (uc *commentUseCase) GetComments(ctx context.Context, postId int, page domain.PaginationParams) (*domain.PaginatedResponse, error) {
 	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
 	defer cancel()
 
-	comments, err := uc.commentRepository.GetCommentsByPostId(ctx, postId, limit, offset)
+	comments, err := uc.commentRepository.GetCommentsByPostId(ctx, postId, fetchPage(page))
 	if err != nil {
 		return nil, err
 	}
+	comments, next := trimPage(comments, page.Limit, commentCursor)
 
-	total, err := uc.commentRepository.GetCommentsCount(ctx, postId)
+	commentIds := make([]int, 0, len(comments))
+	for _, comment := range comments {
+		commentIds = append(commentIds, comment.Id)
+	}
+	replies, err := uc.commentRepository.GetFirstReplies(ctx, commentIds, repliesShown)
 	if err != nil {
 		return nil, err
 	}
 
-	responses := make([]*domain.CommentResponse, 0, len(comments))
+	all := make([]*domain.Comment, 0, len(comments))
+	all = append(all, comments...)
 	for _, comment := range comments {
-		user, err := uc.userRepository.GetUserById(ctx, comment.UserId)
-		if err != nil {
-			return nil, err
-		}
+		all = append(all, replies[comment.Id]...)
+	}
+	responses, err := uc.buildCommentResponses(ctx, all)
+	if err != nil {
+		return nil, err
+	}
 
-		responses = append(responses, &domain.CommentResponse{
-			Id:        comment.Id,
-			Body:      comment.Body,
-			CreatedAt: comment.CreatedAt,
-			Author: domain.Author{
-				Id:          user.Id,
-				Name:        user.Name,
-				AvatarEmoji: user.AvatarEmoji,
-			},
-		})
+	// Replies follow the top-level comments, in the order they were listed
+	topLevel := responses[:len(comments)]
+	position := len(comments)
+	for i, comment := range comments {
+		count := len(replies[comment.Id])
+		topLevel[i].Replies = responses[position : position+count]
+		position += count
 	}
 
-	return domain.NewPaginatedResponse(responses, total, limit, offset), nil
+	return newPageResponse(topLevel, page, next, func() (int, error) {
+		return uc.commentRepository.GetTopLevelCommentsCount(ctx, postId)
+	})
+}
+
+func (uc *commentUseCase) GetReplies(ctx context.Context, commentId int, page domain.PaginationParams) (*domain.PaginatedResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	if _, err := uc.getComment(ctx, commentId); err != nil {
+		return nil, err
+	}
+
+	replies, err := uc.commentRepository.GetReplies(ctx, commentId, fetchPage(page))
+	if err != nil {
+		return nil, err
+	}
+	replies, next := trimPage(replies, page.Limit, commentCursor)
+
+	responses, err := uc.buildCommentResponses(ctx, replies)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPageResponse(responses, page, next, func() (int, error) {
+		counts, err := uc.commentRepository.GetRepliesCounts(ctx, []int{commentId})
+		return counts[commentId], err
+	})
 }
 
 func (uc *commentUseCase) CreateComment(ctx context.Context, userId, postId int, request *domain.CreateCommentRequest) (*domain.CommentResponse, error) {
@@ -75,18 +124,46 @@ func (uc *commentUseCase) CreateComment(ctx context.Context, userId, postId int,
 	}
 
 	// Verify post exists
-	_, err := uc.postRepository.GetPostById(ctx, postId)
+	post, err := uc.postRepository.GetPostById(ctx, postId)
+	if err != nil {
+		return nil, err
+	}
+
+	depth := 0
+	var parent *domain.Comment
+	if request.ParentCommentId != nil {
+		parent, err = uc.getComment(ctx, *request.ParentCommentId)
+		if err != nil {
+			return nil, err
+		}
+		if parent.PostId != postId || parent.DeletedAt != nil {
+			return nil, domain.ErrCommentNotFound
+		}
+		depth = parent.Depth + 1
+		if depth > maxReplyDepth {
+			return nil, domain.ErrReplyTooDeep
+		}
+	}
+
+	entities, err := resolveEntities(ctx, uc.tickerUseCase, uc.userRepository, request.Body)
 	if err != nil {
 		return nil, err
 	}
 
 	comment := &domain.Comment{
-		UserId: userId,
-		PostId: postId,
-		Body:   request.Body,
+		UserId:          userId,
+		PostId:          postId,
+		ParentCommentId: request.ParentCommentId,
+		Depth:           depth,
+		Body:            request.Body,
+		Entities:        entities,
 	}
 
 	createdComment, err := uc.commentRepository.CreateComment(ctx, comment)
+	if err == sql.ErrNoRows {
+		// The parent was deleted meanwhile
+		return nil, domain.ErrCommentNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -96,30 +173,210 @@ func (uc *commentUseCase) CreateComment(ctx context.Context, userId, postId int,
 		return nil, err
 	}
 
-	return &domain.CommentResponse{
-		Id:        createdComment.Id,
-		Body:      createdComment.Body,
-		CreatedAt: createdComment.CreatedAt,
+	response := &domain.CommentResponse{
+		Id:              createdComment.Id,
+		ParentCommentId: createdComment.ParentCommentId,
+		Body:            createdComment.Body,
+		CreatedAt:       createdComment.CreatedAt,
 		Author: domain.Author{
 			Id:          user.Id,
 			Name:        user.Name,
 			AvatarEmoji: user.AvatarEmoji,
 		},
-	}, nil
+		Entities: entityList(comment.Entities),
+	}
+
+	event := &domain.FeedEvent{
+		Type:         domain.FeedEventCommentCreated,
+		PostId:       post.Id,
+		Ticker:       post.Ticker,
+		Tickers:      post.Tickers,
+		PostAuthorId: post.UserId,
+		Comment:      response,
+	}
+	if commentsCount, err := uc.commentRepository.GetCommentsCount(ctx, post.Id); err == nil {
+		event.CommentsCount = &commentsCount
+	}
+	publishFeedEvent(ctx, uc.feedEventRepository, event)
+
+	// The post's author hears of the comment, not of being mentioned in it too
+	notifications := []*domain.Notification{{
+		UserId:    post.UserId,
+		ActorId:   &userId,
+		Type:      domain.NotificationComment,
+		PostId:    &post.Id,
+		CommentId: &createdComment.Id,
+	}}
+	notifications = append(notifications, mentionNotifications(userId, post.Id, &createdComment.Id, comment.Entities, post.UserId)...)
+	notifyAsync(uc.notificationUseCase, notifications...)
+
+	return response, nil
+}
+
+func (uc *commentUseCase) UpdateComment(ctx context.Context, userId, commentId int, request *domain.UpdateCommentRequest) (*domain.CommentResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	if request.Body == "" {
+		return nil, errors.New("body is required")
+	}
+
+	comment, err := uc.getComment(ctx, commentId)
+	if err != nil {
+		return nil, err
+	}
+	if comment.DeletedAt != nil {
+		return nil, domain.ErrCommentNotFound
+	}
+	if comment.UserId != userId {
+		return nil, errors.New("you can only edit your own comments")
+	}
+
+	edited := request.Body != comment.Body
+	if edited {
+		previous, err := uc.commentRepository.GetCommentEntities(ctx, []int{comment.Id})
+		if err != nil {
+			return nil, err
+		}
+		entities, err := resolveEntities(ctx, uc.tickerUseCase, uc.userRepository, request.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		update := *comment
+		update.Body = request.Body
+		update.Entities = entities
+		comment, err = uc.commentRepository.UpdateComment(ctx, &update)
+		if err == sql.ErrNoRows {
+			// Deleted meanwhile
+			return nil, domain.ErrCommentNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Only the users the edit newly mentions hear of it
+		notifyAsync(uc.notificationUseCase,
+			mentionNotifications(userId, comment.PostId, &comment.Id, entities, mentionedUserIds(previous[comment.Id])...)...)
+	}
+
+	responses, err := uc.buildCommentResponses(ctx, []*domain.Comment{comment})
+	if err != nil {
+		return nil, err
+	}
+	response := responses[0]
+	if !edited {
+		return response, nil
+	}
+
+	if post, err := uc.postRepository.GetPostById(ctx, comment.PostId); err == nil {
+		publishFeedEvent(ctx, uc.feedEventRepository, &domain.FeedEvent{
+			Type:         domain.FeedEventCommentEdited,
+			PostId:       post.Id,
+			Ticker:       post.Ticker,
+			Tickers:      post.Tickers,
+			PostAuthorId: post.UserId,
+			Comment:      response,
+		})
+	}
+
+	return response, nil
+}
+
+func (uc *commentUseCase) GetCommentRevisions(ctx context.Context, commentId int) ([]*domain.CommentRevision, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	comment, err := uc.getComment(ctx, commentId)
+	if err != nil {
+		return nil, err
+	}
+	// A placeholder keeps its revisions stored, they are just not shown
+	if comment.DeletedAt != nil {
+		return nil, domain.ErrCommentNotFound
+	}
+	return uc.commentRepository.GetCommentRevisions(ctx, commentId)
 }
 
 func (uc *commentUseCase) DeleteComment(ctx context.Context, userId, commentId int) error {
 	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
 	defer cancel()
 
-	comment, err := uc.commentRepository.GetCommentById(ctx, commentId)
+	comment, err := uc.getComment(ctx, commentId)
 	if err != nil {
 		return err
 	}
+	if comment.DeletedAt != nil {
+		return domain.ErrCommentNotFound
+	}
 
 	if comment.UserId != userId {
 		return errors.New("you can only delete your own comments")
 	}
 
-	return uc.commentRepository.DeleteComment(ctx, commentId)
+	err = uc.commentRepository.DeleteComment(ctx, commentId)
+	if err == sql.ErrNoRows {
+		return domain.ErrCommentNotFound
+	}
+	return err
+}
+
+// getComment loads a comment, deleted placeholders included
+func (uc *commentUseCase) getComment(ctx context.Context, commentId int) (*domain.Comment, error) {
+	comment, err := uc.commentRepository.GetCommentById(ctx, commentId)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrCommentNotFound
+	}
+	return comment, err
+}
+
+// buildCommentResponses describes comments in the order given, loading their
+// authors, entities and reply counts in one go
+func (uc *commentUseCase) buildCommentResponses(ctx context.Context, comments []*domain.Comment) ([]*domain.CommentResponse, error) {
+	commentIds := make([]int, 0, len(comments))
+	userIds := make([]int, 0, len(comments))
+	for _, comment := range comments {
+		commentIds = append(commentIds, comment.Id)
+		if comment.DeletedAt == nil {
+			userIds = append(userIds, comment.UserId)
+		}
+	}
+
+	users, err := uc.userRepository.GetUsersByIds(ctx, userIds)
+	if err != nil {
+		return nil, err
+	}
+	entities, err := uc.commentRepository.GetCommentEntities(ctx, commentIds)
+	if err != nil {
+		return nil, err
+	}
+	repliesCounts, err := uc.commentRepository.GetRepliesCounts(ctx, commentIds)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*domain.CommentResponse, 0, len(comments))
+	for _, comment := range comments {
+		response := &domain.CommentResponse{
+			Id:              comment.Id,
+			ParentCommentId: comment.ParentCommentId,
+			Body:            comment.Body,
+			CreatedAt:       comment.CreatedAt,
+			EditedAt:        comment.EditedAt,
+			Entities:        entityList(entities[comment.Id]),
+			RepliesCount:    repliesCounts[comment.Id],
+		}
+		if comment.DeletedAt != nil {
+			response.Body = domain.DeletedCommentBody
+			response.IsDeleted = true
+		} else if user, ok := users[comment.UserId]; ok {
+			response.Author = domain.Author{Id: user.Id, Name: user.Name, AvatarEmoji: user.AvatarEmoji}
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+func commentCursor(comment *domain.Comment) *domain.Cursor {
+	return &domain.Cursor{CreatedAt: comment.CreatedAt, Id: comment.Id}
 }
@@ -0,0 +1,401 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCommentRepo holds comments and their revisions in memory and deletes
+// them like the table does: a comment with replies becomes a placeholder and
+// placeholders left without replies go with it
+type fakeCommentRepo struct {
+	mu        sync.Mutex
+	comments  map[int]*domain.Comment
+	revisions map[int][]*domain.CommentRevision
+	nextId    int
+	// Every comment is a second after the one before, so that order is stable
+	clock time.Time
+}
+
+func newFakeCommentRepo() *fakeCommentRepo {
+	return &fakeCommentRepo{
+		comments:  make(map[int]*domain.Comment),
+		revisions: make(map[int][]*domain.CommentRevision),
+		clock:     time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+// sorted returns copies of the comments that match, oldest first
+func (f *fakeCommentRepo) sorted(match func(*domain.Comment) bool) []*domain.Comment {
+	comments := make([]*domain.Comment, 0)
+	for _, comment := range f.comments {
+		if match(comment) {
+			copied := *comment
+			comments = append(comments, &copied)
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+		}
+		return comments[i].Id < comments[j].Id
+	})
+	return comments
+}
+
+// pageComments applies the cursor, or else the offset, and the limit to comments in page order
+func pageComments(comments []*domain.Comment, params domain.PaginationParams, after func(*domain.Comment, *domain.Cursor) bool) []*domain.Comment {
+	start := min(params.Offset, len(comments))
+	if params.Cursor != nil {
+		start = len(comments)
+		for i, comment := range comments {
+			if after(comment, params.Cursor) {
+				start = i
+				break
+			}
+		}
+	}
+	end := min(start+params.Limit, len(comments))
+	return comments[start:end]
+}
+
+func (f *fakeCommentRepo) GetCommentsByPostId(ctx context.Context, postId int, params domain.PaginationParams) ([]*domain.Comment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	comments := f.sorted(func(c *domain.Comment) bool { return c.PostId == postId && c.ParentCommentId == nil })
+	for i, j := 0, len(comments)-1; i < j; i, j = i+1, j-1 {
+		comments[i], comments[j] = comments[j], comments[i]
+	}
+	return pageComments(comments, params, func(c *domain.Comment, cursor *domain.Cursor) bool {
+		return c.CreatedAt.Before(cursor.CreatedAt) || c.CreatedAt.Equal(cursor.CreatedAt) && c.Id < cursor.Id
+	}), nil
+}
+
+func (f *fakeCommentRepo) GetTopLevelCommentsCount(ctx context.Context, postId int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.sorted(func(c *domain.Comment) bool { return c.PostId == postId && c.ParentCommentId == nil })), nil
+}
+
+func (f *fakeCommentRepo) GetReplies(ctx context.Context, parentId int, params domain.PaginationParams) ([]*domain.Comment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	replies := f.sorted(func(c *domain.Comment) bool { return c.ParentCommentId != nil && *c.ParentCommentId == parentId })
+	return pageComments(replies, params, func(c *domain.Comment, cursor *domain.Cursor) bool {
+		return c.CreatedAt.After(cursor.CreatedAt) || c.CreatedAt.Equal(cursor.CreatedAt) && c.Id > cursor.Id
+	}), nil
+}
+
+func (f *fakeCommentRepo) GetFirstReplies(ctx context.Context, parentIds []int, limit int) (map[int][]*domain.Comment, error) {
+	replies := make(map[int][]*domain.Comment, len(parentIds))
+	for _, parentId := range parentIds {
+		first, err := f.GetReplies(ctx, parentId, domain.PaginationParams{Limit: limit})
+		if err != nil {
+			return nil, err
+		}
+		if len(first) > 0 {
+			replies[parentId] = first
+		}
+	}
+	return replies, nil
+}
+
+func (f *fakeCommentRepo) GetRepliesCounts(ctx context.Context, parentIds []int) (map[int]int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	counts := make(map[int]int, len(parentIds))
+	for _, parentId := range parentIds {
+		if count := f.repliesCount(parentId); count > 0 {
+			counts[parentId] = count
+		}
+	}
+	return counts, nil
+}
+
+func (f *fakeCommentRepo) repliesCount(parentId int) int {
+	count := 0
+	for _, comment := range f.comments {
+		if comment.ParentCommentId != nil && *comment.ParentCommentId == parentId {
+			count++
+		}
+	}
+	return count
+}
+
+func (f *fakeCommentRepo) GetCommentById(ctx context.Context, id int) (*domain.Comment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	comment, ok := f.comments[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *comment
+	return &copied, nil
+}
+
+func (f *fakeCommentRepo) CreateComment(ctx context.Context, comment *domain.Comment) (*domain.Comment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if comment.ParentCommentId != nil {
+		parent, ok := f.comments[*comment.ParentCommentId]
+		if !ok || parent.DeletedAt != nil {
+			return nil, sql.ErrNoRows
+		}
+	}
+
+	f.clock = f.clock.Add(time.Second)
+	created := *comment
+	f.nextId++
+	created.Id = f.nextId
+	created.CreatedAt = f.clock
+	f.comments[created.Id] = &created
+	copied := created
+	return &copied, nil
+}
+
+func (f *fakeCommentRepo) UpdateComment(ctx context.Context, comment *domain.Comment) (*domain.Comment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stored, ok := f.comments[comment.Id]
+	if !ok || stored.DeletedAt != nil {
+		return nil, sql.ErrNoRows
+	}
+
+	written := stored.CreatedAt
+	if stored.EditedAt != nil {
+		written = *stored.EditedAt
+	}
+	f.clock = f.clock.Add(time.Second)
+	f.revisions[stored.Id] = append(f.revisions[stored.Id], &domain.CommentRevision{
+		CommentId:  stored.Id,
+		Body:       stored.Body,
+		CreatedAt:  written,
+		ReplacedAt: f.clock,
+	})
+	editedAt := f.clock
+	stored.Body = comment.Body
+	stored.EditedAt = &editedAt
+	copied := *stored
+	return &copied, nil
+}
+
+func (f *fakeCommentRepo) GetCommentRevisions(ctx context.Context, commentId int) ([]*domain.CommentRevision, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*domain.CommentRevision{}, f.revisions[commentId]...), nil
+}
+
+func (f *fakeCommentRepo) DeleteComment(ctx context.Context, id int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	comment, ok := f.comments[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	if f.repliesCount(id) > 0 {
+		now := time.Now()
+		comment.Body = ""
+		comment.DeletedAt = &now
+		return nil
+	}
+
+	for {
+		delete(f.comments, comment.Id)
+		delete(f.revisions, comment.Id)
+		if comment.ParentCommentId == nil {
+			return nil
+		}
+		parent, ok := f.comments[*comment.ParentCommentId]
+		if !ok || parent.DeletedAt == nil || f.repliesCount(parent.Id) > 0 {
+			return nil
+		}
+		comment = parent
+	}
+}
+
+func (f *fakeCommentRepo) GetCommentsCount(ctx context.Context, postId int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.sorted(func(c *domain.Comment) bool { return c.PostId == postId && c.DeletedAt == nil })), nil
+}
+
+func (f *fakeCommentRepo) GetCommentsCounts(ctx context.Context, postIds []int) (map[int]int, error) {
+	counts := make(map[int]int, len(postIds))
+	for _, postId := range postIds {
+		count, err := f.GetCommentsCount(ctx, postId)
+		if err != nil {
+			return nil, err
+		}
+		counts[postId] = count
+	}
+	return counts, nil
+}
+
+func (f *fakeCommentRepo) GetCommentEntities(ctx context.Context, commentIds []int) (map[int][]*domain.Entity, error) {
+	return map[int][]*domain.Entity{}, nil
+}
+
+// fakeCommentPostRepo knows the posts being commented on, the other methods
+// are left to the nil interface
+type fakeCommentPostRepo struct {
+	repository.PostRepository
+	posts map[int]*domain.Post
+}
+
+func (f *fakeCommentPostRepo) GetPostById(ctx context.Context, id int) (*domain.Post, error) {
+	post, ok := f.posts[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return post, nil
+}
+
+// fakeNotifier drops the notifications it is given
+type fakeNotifier struct {
+	domain.NotificationUseCase
+}
+
+func (f *fakeNotifier) Notify(ctx context.Context, notifications ...*domain.Notification) error {
+	return nil
+}
+
+func newTestCommentUseCase() (*commentUseCase, *fakeCommentRepo) {
+	comments := newFakeCommentRepo()
+	posts := &fakeCommentPostRepo{posts: map[int]*domain.Post{1: {Id: 1, UserId: 1, Ticker: "AAPL"}}}
+	users := newFakeUserRepo(
+		&domain.User{Id: 1, Name: "Jane"},
+		&domain.User{Id: 2, Name: "John"},
+	)
+	uc := NewCommentUseCase(comments, posts, users, &fakeFeedEventRepo{}, nil, &fakeNotifier{}, time.Second).(*commentUseCase)
+	return uc, comments
+}
+
+// reply comments on post 1 as user 2, under parentId unless it is 0
+func reply(t *testing.T, uc *commentUseCase, parentId int, body string) (*domain.CommentResponse, error) {
+	t.Helper()
+	request := &domain.CreateCommentRequest{Body: body}
+	if parentId != 0 {
+		request.ParentCommentId = &parentId
+	}
+	return uc.CreateComment(context.Background(), 2, 1, request)
+}
+
+func TestCommentRepliesStopAtMaxDepth(t *testing.T) {
+	uc, comments := newTestCommentUseCase()
+
+	top, err := reply(t, uc, 0, "top")
+	require.NoError(t, err)
+
+	parentId := top.Id
+	for depth := 1; depth <= maxReplyDepth; depth++ {
+		created, err := reply(t, uc, parentId, "deeper")
+		require.NoError(t, err)
+		stored, err := comments.GetCommentById(context.Background(), created.Id)
+		require.NoError(t, err)
+		assert.Equal(t, depth, stored.Depth)
+		parentId = created.Id
+	}
+
+	_, err = reply(t, uc, parentId, "too deep")
+	assert.ErrorIs(t, err, domain.ErrReplyTooDeep)
+}
+
+func TestDeletedCommentWithRepliesStaysAsPlaceholder(t *testing.T) {
+	uc, comments := newTestCommentUseCase()
+	ctx := context.Background()
+
+	top, err := reply(t, uc, 0, "first take")
+	require.NoError(t, err)
+	_, err = uc.UpdateComment(ctx, 2, top.Id, &domain.UpdateCommentRequest{Body: "second take"})
+	require.NoError(t, err)
+	child, err := reply(t, uc, top.Id, "a reply")
+	require.NoError(t, err)
+
+	require.NoError(t, uc.DeleteComment(ctx, 2, top.Id))
+
+	page, err := uc.GetComments(ctx, 1, domain.PaginationParams{Limit: 10})
+	require.NoError(t, err)
+	listed := page.Data.([]*domain.CommentResponse)
+	require.Len(t, listed, 1)
+	assert.True(t, listed[0].IsDeleted)
+	assert.Equal(t, domain.DeletedCommentBody, listed[0].Body)
+	assert.Zero(t, listed[0].Author.Id)
+	assert.Equal(t, 1, listed[0].RepliesCount)
+	require.Len(t, listed[0].Replies, 1)
+	assert.Equal(t, child.Id, listed[0].Replies[0].Id)
+
+	// Its history is kept, but no longer shown
+	_, err = uc.GetCommentRevisions(ctx, top.Id)
+	assert.ErrorIs(t, err, domain.ErrCommentNotFound)
+	stored, err := comments.GetCommentRevisions(ctx, top.Id)
+	require.NoError(t, err)
+	assert.Len(t, stored, 1)
+
+	// A placeholder takes no replies and can't be deleted again
+	_, err = reply(t, uc, top.Id, "too late")
+	assert.ErrorIs(t, err, domain.ErrCommentNotFound)
+	assert.ErrorIs(t, uc.DeleteComment(ctx, 2, top.Id), domain.ErrCommentNotFound)
+
+	// The last reply going takes the placeholder with it
+	require.NoError(t, uc.DeleteComment(ctx, 2, child.Id))
+	_, err = comments.GetCommentById(ctx, top.Id)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestGetRepliesPages(t *testing.T) {
+	uc, _ := newTestCommentUseCase()
+	ctx := context.Background()
+
+	top, err := reply(t, uc, 0, "top")
+	require.NoError(t, err)
+	want := make([]int, 0)
+	for i := 0; i < 5; i++ {
+		created, err := reply(t, uc, top.Id, "reply")
+		require.NoError(t, err)
+		want = append(want, created.Id)
+	}
+
+	// The post's comments show the oldest few replies and how many there are
+	page, err := uc.GetComments(ctx, 1, domain.PaginationParams{Limit: 10})
+	require.NoError(t, err)
+	listed := page.Data.([]*domain.CommentResponse)
+	require.Len(t, listed, 1)
+	assert.Equal(t, 5, listed[0].RepliesCount)
+	require.Len(t, listed[0].Replies, repliesShown)
+	assert.Equal(t, want[0], listed[0].Replies[0].Id)
+
+	// The rest are paged through, oldest first
+	seen := make([]int, 0)
+	params := domain.PaginationParams{Limit: 2}
+	for {
+		page, err := uc.GetReplies(ctx, top.Id, params)
+		require.NoError(t, err)
+		for _, reply := range page.Data.([]*domain.CommentResponse) {
+			seen = append(seen, reply.Id)
+		}
+		if !page.HasMore {
+			break
+		}
+		params.Cursor, err = domain.DecodeCursor(page.NextCursor)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, want, seen)
+
+	// Offset pages count the replies
+	page, err = uc.GetReplies(ctx, top.Id, domain.PaginationParams{Limit: 2, Offset: 4})
+	require.NoError(t, err)
+	require.NotNil(t, page.Total)
+	assert.Equal(t, 5, *page.Total)
+	assert.Len(t, page.Data, 1)
+}
@@ -0,0 +1,300 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+)
+
+const (
+	// Including the creator
+	maxConversationMembers = 10
+	maxMessageLength       = 2000
+)
+
+type conversationUseCase struct {
+	conversationRepository       repository.ConversationRepository
+	conversationMemberRepository repository.ConversationMemberRepository
+	messageRepository            repository.MessageRepository
+	userRepository               repository.UserRepository
+	contextTimeout               time.Duration
+}
+
+func NewConversationUseCase(
+	conversationRepo repository.ConversationRepository,
+	conversationMemberRepo repository.ConversationMemberRepository,
+	messageRepo repository.MessageRepository,
+	userRepo repository.UserRepository,
+	timeout time.Duration,
+) domain.ConversationUseCase {
+	return &conversationUseCase{
+		conversationRepository:       conversationRepo,
+		conversationMemberRepository: conversationMemberRepo,
+		messageRepository:            messageRepo,
+		userRepository:               userRepo,
+		contextTimeout:               timeout,
+	}
+}
+
+func (uc *conversationUseCase) StartConversation(ctx context.Context, userId int, request *domain.StartConversationRequest) (*domain.ConversationResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	// Deduplicate and drop the caller, who is always a member
+	seen := map[int]bool{userId: true}
+	otherIds := make([]int, 0, len(request.UserIds))
+	for _, id := range request.UserIds {
+		if !seen[id] {
+			seen[id] = true
+			otherIds = append(otherIds, id)
+		}
+	}
+
+	if len(otherIds) == 0 {
+		return nil, errors.New("at least one other user is required")
+	}
+	if len(otherIds)+1 > maxConversationMembers {
+		return nil, errors.New("too many members in conversation")
+	}
+
+	users, err := uc.userRepository.GetUsersByIds(ctx, otherIds)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) != len(otherIds) {
+		return nil, domain.ErrUserNotFound
+	}
+
+	// Reopen the existing one-to-one conversation instead of starting a second one
+	var conversation *domain.Conversation
+	if len(otherIds) == 1 {
+		conversation, err = uc.conversationRepository.CreateDirectConversation(ctx, userId, otherIds[0])
+	} else {
+		conversation, err = uc.conversationRepository.CreateConversation(ctx, &domain.Conversation{
+			IsGroup:   true,
+			CreatedBy: &userId,
+		}, append([]int{userId}, otherIds...))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.buildConversationResponse(ctx, userId, conversation.Id)
+}
+
+func (uc *conversationUseCase) GetInbox(ctx context.Context, userId, limit, offset int) (*domain.PaginatedResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	entries, err := uc.conversationRepository.GetInbox(ctx, userId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := uc.conversationRepository.GetInboxCount(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	responses, err := uc.enrichConversations(ctx, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewPaginatedResponse(responses, total, limit, offset), nil
+}
+
+func (uc *conversationUseCase) GetMessages(ctx context.Context, userId, conversationId, limit, offset int) (*domain.PaginatedResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	if err := uc.checkMember(ctx, conversationId, userId); err != nil {
+		return nil, err
+	}
+
+	messages, err := uc.messageRepository.GetMessagesByConversationId(ctx, conversationId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := uc.messageRepository.GetMessagesCount(ctx, conversationId)
+	if err != nil {
+		return nil, err
+	}
+
+	authorIds := make([]int, 0, len(messages))
+	for _, message := range messages {
+		authorIds = append(authorIds, message.UserId)
+	}
+	users, err := uc.userRepository.GetUsersByIds(ctx, authorIds)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*domain.MessageResponse, 0, len(messages))
+	for _, message := range messages {
+		responses = append(responses, toMessageResponse(message, users[message.UserId]))
+	}
+
+	return domain.NewPaginatedResponse(responses, total, limit, offset), nil
+}
+
+func (uc *conversationUseCase) SendMessage(ctx context.Context, userId, conversationId int, request *domain.SendMessageRequest) (*domain.MessageResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	body := strings.TrimSpace(request.Body)
+	if body == "" {
+		return nil, errors.New("body is required")
+	}
+	if len(body) > maxMessageLength {
+		return nil, errors.New("message is too long")
+	}
+
+	if err := uc.checkMember(ctx, conversationId, userId); err != nil {
+		return nil, err
+	}
+
+	message, err := uc.messageRepository.CreateMessage(ctx, &domain.Message{
+		ConversationId: conversationId,
+		UserId:         userId,
+		Body:           body,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The sender has obviously read everything up to their own message
+	if err := uc.conversationMemberRepository.MarkAsRead(ctx, conversationId, userId, message.Id); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userRepository.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return toMessageResponse(message, user), nil
+}
+
+func (uc *conversationUseCase) MarkAsRead(ctx context.Context, userId, conversationId int, request *domain.MarkReadRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	if err := uc.checkMember(ctx, conversationId, userId); err != nil {
+		return err
+	}
+
+	messageId := request.MessageId
+	if messageId == 0 {
+		latestId, err := uc.messageRepository.GetLatestMessageId(ctx, conversationId)
+		if err != nil {
+			return err
+		}
+		messageId = latestId
+	}
+
+	return uc.conversationMemberRepository.MarkAsRead(ctx, conversationId, userId, messageId)
+}
+
+func (uc *conversationUseCase) checkMember(ctx context.Context, conversationId, userId int) error {
+	isMember, err := uc.conversationMemberRepository.IsMember(ctx, conversationId, userId)
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return domain.ErrUserNotAllowed
+	}
+	return nil
+}
+
+func (uc *conversationUseCase) buildConversationResponse(ctx context.Context, userId, conversationId int) (*domain.ConversationResponse, error) {
+	conversation, err := uc.conversationRepository.GetConversationById(ctx, conversationId)
+	if err != nil {
+		return nil, err
+	}
+
+	responses, err := uc.enrichConversations(ctx, []*domain.InboxEntry{{Conversation: *conversation}})
+	if err != nil {
+		return nil, err
+	}
+	return responses[0], nil
+}
+
+// enrichConversations loads members, last messages and authors for a page of conversations in a fixed number of queries
+func (uc *conversationUseCase) enrichConversations(ctx context.Context, entries []*domain.InboxEntry) ([]*domain.ConversationResponse, error) {
+	conversationIds := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		conversationIds = append(conversationIds, entry.Id)
+	}
+
+	members, err := uc.conversationMemberRepository.GetMembers(ctx, conversationIds)
+	if err != nil {
+		return nil, err
+	}
+
+	lastMessages, err := uc.messageRepository.GetLastMessages(ctx, conversationIds)
+	if err != nil {
+		return nil, err
+	}
+
+	userIds := make([]int, 0, len(members))
+	membersByConversation := make(map[int][]int)
+	for _, member := range members {
+		userIds = append(userIds, member.UserId)
+		membersByConversation[member.ConversationId] = append(membersByConversation[member.ConversationId], member.UserId)
+	}
+
+	users, err := uc.userRepository.GetUsersByIds(ctx, userIds)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*domain.ConversationResponse, 0, len(entries))
+	for _, entry := range entries {
+		authors := make([]domain.Author, 0, len(membersByConversation[entry.Id]))
+		for _, memberId := range membersByConversation[entry.Id] {
+			if user, ok := users[memberId]; ok {
+				authors = append(authors, toAuthor(user))
+			}
+		}
+
+		var lastMessage *domain.MessageResponse
+		if message, ok := lastMessages[entry.Id]; ok {
+			lastMessage = toMessageResponse(message, users[message.UserId])
+		}
+
+		responses = append(responses, &domain.ConversationResponse{
+			Id:            entry.Id,
+			IsGroup:       entry.IsGroup,
+			Members:       authors,
+			LastMessage:   lastMessage,
+			UnreadCount:   entry.UnreadCount,
+			CreatedAt:     entry.CreatedAt,
+			LastMessageAt: entry.LastMessageAt,
+		})
+	}
+
+	return responses, nil
+}
+
+func toAuthor(user *domain.User) domain.Author {
+	if user == nil {
+		return domain.Author{}
+	}
+	return domain.Author{Id: user.Id, Name: user.Name, AvatarEmoji: user.AvatarEmoji}
+}
+
+func toMessageResponse(message *domain.Message, user *domain.User) *domain.MessageResponse {
+	return &domain.MessageResponse{
+		Id:             message.Id,
+		ConversationId: message.ConversationId,
+		Body:           message.Body,
+		CreatedAt:      message.CreatedAt,
+		Author:         toAuthor(user),
+	}
+}
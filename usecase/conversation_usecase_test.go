@@ -0,0 +1,360 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConversationStore holds conversations, their members and messages in
+// memory, serving as the conversation, member and message repositories. Like
+// the unique index on the pair, it keeps one one-to-one conversation per pair.
+type fakeConversationStore struct {
+	mu            sync.Mutex
+	conversations []*domain.Conversation
+	members       []*domain.ConversationMember
+	messages      []*domain.Message
+	now           time.Time
+}
+
+func newFakeConversationStore() *fakeConversationStore {
+	return &fakeConversationStore{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (f *fakeConversationStore) CreateConversation(ctx context.Context, conversation *domain.Conversation, memberIds []int) (*domain.Conversation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.insertConversation(*conversation, memberIds), nil
+}
+
+func (f *fakeConversationStore) insertConversation(conversation domain.Conversation, memberIds []int) *domain.Conversation {
+	conversation.Id = len(f.conversations) + 1
+	conversation.CreatedAt = f.now
+	f.conversations = append(f.conversations, &conversation)
+	for _, memberId := range memberIds {
+		f.members = append(f.members, &domain.ConversationMember{ConversationId: conversation.Id, UserId: memberId, JoinedAt: f.now})
+	}
+	return &conversation
+}
+
+func (f *fakeConversationStore) CreateDirectConversation(ctx context.Context, userId, otherUserId int) (*domain.Conversation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	low, high := min(userId, otherUserId), max(userId, otherUserId)
+	if existing := f.findDirect(low, high); existing != nil {
+		return existing, nil
+	}
+	return f.insertConversation(domain.Conversation{CreatedBy: &userId, DirectUserLow: &low, DirectUserHigh: &high}, []int{userId, otherUserId}), nil
+}
+
+func (f *fakeConversationStore) findDirect(low, high int) *domain.Conversation {
+	for _, conversation := range f.conversations {
+		if conversation.DirectUserLow != nil && *conversation.DirectUserLow == low && *conversation.DirectUserHigh == high {
+			return conversation
+		}
+	}
+	return nil
+}
+
+func (f *fakeConversationStore) GetConversationById(ctx context.Context, id int) (*domain.Conversation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, conversation := range f.conversations {
+		if conversation.Id == id {
+			return conversation, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeConversationStore) FindDirectConversation(ctx context.Context, userId, otherUserId int) (*domain.Conversation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if existing := f.findDirect(min(userId, otherUserId), max(userId, otherUserId)); existing != nil {
+		return existing, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeConversationStore) GetInbox(ctx context.Context, userId, limit, offset int) ([]*domain.InboxEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entries := make([]*domain.InboxEntry, 0)
+	for _, member := range f.members {
+		if member.UserId != userId {
+			continue
+		}
+		entry := &domain.InboxEntry{}
+		for _, conversation := range f.conversations {
+			if conversation.Id == member.ConversationId {
+				entry.Conversation = *conversation
+			}
+		}
+		for _, message := range f.messages {
+			if message.ConversationId == member.ConversationId && message.Id > member.LastReadMessageId && message.UserId != userId {
+				entry.UnreadCount++
+			}
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Id > entries[j].Id })
+	if offset >= len(entries) {
+		return []*domain.InboxEntry{}, nil
+	}
+	return entries[offset:min(offset+limit, len(entries))], nil
+}
+
+func (f *fakeConversationStore) GetInboxCount(ctx context.Context, userId int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for _, member := range f.members {
+		if member.UserId == userId {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeConversationStore) IsMember(ctx context.Context, conversationId, userId int) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.member(conversationId, userId) != nil, nil
+}
+
+func (f *fakeConversationStore) member(conversationId, userId int) *domain.ConversationMember {
+	for _, member := range f.members {
+		if member.ConversationId == conversationId && member.UserId == userId {
+			return member
+		}
+	}
+	return nil
+}
+
+func (f *fakeConversationStore) GetMembers(ctx context.Context, conversationIds []int) ([]*domain.ConversationMember, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	members := make([]*domain.ConversationMember, 0)
+	for _, member := range f.members {
+		for _, id := range conversationIds {
+			if member.ConversationId == id {
+				members = append(members, member)
+			}
+		}
+	}
+	return members, nil
+}
+
+func (f *fakeConversationStore) MarkAsRead(ctx context.Context, conversationId, userId, messageId int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if member := f.member(conversationId, userId); member != nil {
+		member.LastReadMessageId = max(member.LastReadMessageId, messageId)
+	}
+	return nil
+}
+
+func (f *fakeConversationStore) CreateMessage(ctx context.Context, message *domain.Message) (*domain.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	created := *message
+	created.Id = len(f.messages) + 1
+	created.CreatedAt = f.now.Add(time.Duration(created.Id) * time.Second)
+	f.messages = append(f.messages, &created)
+	return &created, nil
+}
+
+func (f *fakeConversationStore) conversationMessages(conversationId int) []*domain.Message {
+	messages := make([]*domain.Message, 0)
+	for _, message := range f.messages {
+		if message.ConversationId == conversationId {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
+
+func (f *fakeConversationStore) GetMessagesByConversationId(ctx context.Context, conversationId, limit, offset int) ([]*domain.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	messages := f.conversationMessages(conversationId)
+	sort.Slice(messages, func(i, j int) bool { return messages[i].Id > messages[j].Id })
+	if offset >= len(messages) {
+		return []*domain.Message{}, nil
+	}
+	return messages[offset:min(offset+limit, len(messages))], nil
+}
+
+func (f *fakeConversationStore) GetMessagesCount(ctx context.Context, conversationId int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.conversationMessages(conversationId)), nil
+}
+
+func (f *fakeConversationStore) GetLastMessages(ctx context.Context, conversationIds []int) (map[int]*domain.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	last := make(map[int]*domain.Message)
+	for _, id := range conversationIds {
+		if messages := f.conversationMessages(id); len(messages) > 0 {
+			last[id] = messages[len(messages)-1]
+		}
+	}
+	return last, nil
+}
+
+func (f *fakeConversationStore) GetLatestMessageId(ctx context.Context, conversationId int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	latestId := 0
+	for _, message := range f.conversationMessages(conversationId) {
+		latestId = max(latestId, message.Id)
+	}
+	return latestId, nil
+}
+
+func newTestConversationUseCase() (domain.ConversationUseCase, *fakeConversationStore) {
+	store := newFakeConversationStore()
+	users := newFakeUserRepo(
+		&domain.User{Id: 1, Name: "Jane"},
+		&domain.User{Id: 2, Name: "John"},
+		&domain.User{Id: 3, Name: "Ann"},
+	)
+	return NewConversationUseCase(store, store, store, users, time.Second), store
+}
+
+func TestStartConversationReopensDirectConversation(t *testing.T) {
+	uc, store := newTestConversationUseCase()
+	ctx := context.Background()
+
+	first, err := uc.StartConversation(ctx, 1, &domain.StartConversationRequest{UserIds: []int{2}})
+	require.NoError(t, err)
+	assert.False(t, first.IsGroup)
+	assert.Len(t, first.Members, 2)
+
+	// From the other side, and with the caller and duplicates listed
+	again, err := uc.StartConversation(ctx, 2, &domain.StartConversationRequest{UserIds: []int{1, 2, 1}})
+	require.NoError(t, err)
+	assert.Equal(t, first.Id, again.Id)
+
+	group, err := uc.StartConversation(ctx, 1, &domain.StartConversationRequest{UserIds: []int{2, 3}})
+	require.NoError(t, err)
+	assert.True(t, group.IsGroup)
+	assert.NotEqual(t, first.Id, group.Id)
+	assert.Len(t, store.conversations, 2)
+}
+
+func TestStartConversationConcurrentlyCreatesOne(t *testing.T) {
+	uc, store := newTestConversationUseCase()
+	ctx := context.Background()
+
+	const callers = 20
+	ids := make([]int, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			userId, otherId := 1, 2
+			if i%2 == 1 {
+				userId, otherId = 2, 1
+			}
+			conversation, err := uc.StartConversation(ctx, userId, &domain.StartConversationRequest{UserIds: []int{otherId}})
+			if assert.NoError(t, err) {
+				ids[i] = conversation.Id
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, id := range ids {
+		assert.Equal(t, ids[0], id)
+	}
+	assert.Len(t, store.conversations, 1)
+	assert.Len(t, store.members, 2)
+}
+
+func TestStartConversationValidates(t *testing.T) {
+	uc, store := newTestConversationUseCase()
+	ctx := context.Background()
+
+	_, err := uc.StartConversation(ctx, 1, &domain.StartConversationRequest{UserIds: []int{1}})
+	assert.Error(t, err)
+
+	_, err = uc.StartConversation(ctx, 1, &domain.StartConversationRequest{UserIds: []int{2, 99}})
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+	tooMany := make([]int, 0, maxConversationMembers)
+	for id := 2; id <= maxConversationMembers+1; id++ {
+		tooMany = append(tooMany, id)
+	}
+	_, err = uc.StartConversation(ctx, 1, &domain.StartConversationRequest{UserIds: tooMany})
+	assert.Error(t, err)
+	assert.Empty(t, store.conversations)
+}
+
+func TestConversationUnreadCounts(t *testing.T) {
+	uc, _ := newTestConversationUseCase()
+	ctx := context.Background()
+
+	conversation, err := uc.StartConversation(ctx, 1, &domain.StartConversationRequest{UserIds: []int{2}})
+	require.NoError(t, err)
+
+	unread := func(userId int) int {
+		page, err := uc.GetInbox(ctx, userId, 10, 0)
+		require.NoError(t, err)
+		inbox := page.Data.([]*domain.ConversationResponse)
+		require.Len(t, inbox, 1)
+		return inbox[0].UnreadCount
+	}
+
+	for _, body := range []string{"hi", "  are you there?  "} {
+		_, err := uc.SendMessage(ctx, 1, conversation.Id, &domain.SendMessageRequest{Body: body})
+		require.NoError(t, err)
+	}
+	// Their own messages are read for the sender
+	assert.Zero(t, unread(1))
+	assert.Equal(t, 2, unread(2))
+
+	reply, err := uc.SendMessage(ctx, 2, conversation.Id, &domain.SendMessageRequest{Body: "yes"})
+	require.NoError(t, err)
+	// Replying reads what came before
+	assert.Zero(t, unread(2))
+	assert.Equal(t, 1, unread(1))
+
+	// Without a message id everything is read, and the marker never moves back
+	require.NoError(t, uc.MarkAsRead(ctx, 1, conversation.Id, &domain.MarkReadRequest{}))
+	assert.Zero(t, unread(1))
+	require.NoError(t, uc.MarkAsRead(ctx, 1, conversation.Id, &domain.MarkReadRequest{MessageId: reply.Id - 2}))
+	assert.Zero(t, unread(1))
+
+	page, err := uc.GetInbox(ctx, 1, 10, 0)
+	require.NoError(t, err)
+	last := page.Data.([]*domain.ConversationResponse)[0].LastMessage
+	require.NotNil(t, last)
+	assert.Equal(t, "yes", last.Body)
+	assert.Equal(t, "John", last.Author.Name)
+}
+
+func TestConversationIsForMembersOnly(t *testing.T) {
+	uc, _ := newTestConversationUseCase()
+	ctx := context.Background()
+
+	conversation, err := uc.StartConversation(ctx, 1, &domain.StartConversationRequest{UserIds: []int{2}})
+	require.NoError(t, err)
+
+	_, err = uc.SendMessage(ctx, 3, conversation.Id, &domain.SendMessageRequest{Body: "hi"})
+	assert.ErrorIs(t, err, domain.ErrUserNotAllowed)
+	_, err = uc.GetMessages(ctx, 3, conversation.Id, 10, 0)
+	assert.ErrorIs(t, err, domain.ErrUserNotAllowed)
+	err = uc.MarkAsRead(ctx, 3, conversation.Id, &domain.MarkReadRequest{})
+	assert.ErrorIs(t, err, domain.ErrUserNotAllowed)
+}
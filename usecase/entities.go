@@ -0,0 +1,210 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+)
+
+const (
+	maxCashtagLength = 12
+	maxMentionLength = 30
+	maxHashtagLength = 50
+)
+
+// parseEntities finds the cashtags, mentions and hashtags of a body. A $, @ or
+// # only starts one at the start of the body or after a character that can't
+// be part of a word, so emails and amounts like US$5 are left alone. Cashtags
+// start with a letter and may hold a . or / between characters, as in $BRK.B
+// or $BTC/USD. Mentions are letters, digits and underscores. Hashtags take
+// letters of any script, digits and underscores, with at least one letter.
+func parseEntities(body string) []*domain.Entity {
+	runes := []rune(body)
+	entities := make([]*domain.Entity, 0)
+
+	for i := 0; i < len(runes); i++ {
+		if i > 0 && isWordRune(runes[i-1]) {
+			continue
+		}
+
+		var entity *domain.Entity
+		switch runes[i] {
+		case '$':
+			entity = parseCashtag(runes, i)
+		case '@':
+			entity = parseWord(runes, i, domain.EntityMention, maxMentionLength, isMentionRune)
+		case '#':
+			entity = parseWord(runes, i, domain.EntityHashtag, maxHashtagLength, isWordRune)
+			if entity != nil && !strings.ContainsFunc(entity.Text, unicode.IsLetter) {
+				entity = nil
+			}
+			if entity != nil {
+				entity.Text = strings.ToLower(entity.Text)
+			}
+		}
+
+		if entity != nil {
+			entities = append(entities, entity)
+			i = entity.End - 1
+		}
+	}
+	return entities
+}
+
+func parseCashtag(runes []rune, start int) *domain.Entity {
+	end := start + 1
+	if end >= len(runes) || !isASCIILetter(runes[end]) {
+		return nil
+	}
+	for end < len(runes) {
+		r := runes[end]
+		// A separator only counts between two characters of the symbol
+		if (r == '.' || r == '/') && end+1 < len(runes) && isASCIIAlnum(runes[end+1]) {
+			end += 2
+			continue
+		}
+		if !isASCIIAlnum(r) {
+			break
+		}
+		end++
+	}
+	if end-start-1 > maxCashtagLength || (end < len(runes) && isWordRune(runes[end])) {
+		return nil
+	}
+	return &domain.Entity{
+		Type:  domain.EntityCashtag,
+		Start: start,
+		End:   end,
+		Text:  normalizeTicker(string(runes[start:end])),
+	}
+}
+
+// parseWord reads the run of allowed runes after the sigil at start
+func parseWord(runes []rune, start int, entityType string, maxLength int, allowed func(rune) bool) *domain.Entity {
+	end := start + 1
+	for end < len(runes) && allowed(runes[end]) {
+		end++
+	}
+	if end == start+1 || end-start-1 > maxLength || (end < len(runes) && isWordRune(runes[end])) {
+		return nil
+	}
+	return &domain.Entity{
+		Type:  entityType,
+		Start: start,
+		End:   end,
+		Text:  string(runes[start+1 : end]),
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func isMentionRune(r rune) bool {
+	return isASCIIAlnum(r) || r == '_'
+}
+
+func isASCIILetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isASCIIAlnum(r rune) bool {
+	return isASCIILetter(r) || (r >= '0' && r <= '9')
+}
+
+// resolveEntities parses a body and keeps the entities that can be linked:
+// cashtags of known, active tickers and mentions of a name only one user has.
+// Hashtags are always kept.
+func resolveEntities(
+	ctx context.Context,
+	tickerUseCase domain.TickerUseCase,
+	userRepo repository.UserRepository,
+	body string,
+) ([]*domain.Entity, error) {
+	entities := parseEntities(body)
+
+	symbols := make([]string, 0)
+	names := make([]string, 0)
+	for _, entity := range entities {
+		switch entity.Type {
+		case domain.EntityCashtag:
+			symbols = append(symbols, entity.Text)
+		case domain.EntityMention:
+			names = append(names, entity.Text)
+		}
+	}
+
+	known := make(map[string]bool)
+	if len(symbols) > 0 {
+		resolved, err := tickerUseCase.ResolveSymbols(ctx, symbols)
+		if err != nil {
+			return nil, err
+		}
+		for _, symbol := range resolved {
+			known[symbol] = true
+		}
+	}
+
+	userIds, err := userRepo.GetUserIdsByNames(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := entities[:0]
+	for _, entity := range entities {
+		switch entity.Type {
+		case domain.EntityCashtag:
+			if !known[entity.Text] {
+				continue
+			}
+		case domain.EntityMention:
+			userId, ok := userIds[strings.ToLower(entity.Text)]
+			if !ok {
+				continue
+			}
+			entity.UserId = &userId
+		}
+		resolved = append(resolved, entity)
+	}
+	return resolved, nil
+}
+
+// postTickers lists the primary ticker of a post followed by the other
+// tickers its cashtags mention, without duplicates
+func postTickers(primary string, entities []*domain.Entity) []string {
+	tickers := []string{primary}
+	seen := map[string]bool{primary: true}
+	for _, entity := range entities {
+		if entity.Type == domain.EntityCashtag && !seen[entity.Text] {
+			seen[entity.Text] = true
+			tickers = append(tickers, entity.Text)
+		}
+	}
+	return tickers
+}
+
+// addedTickers returns the tickers in after that are not in before
+func addedTickers(before, after []string) []string {
+	listed := make(map[string]bool, len(before))
+	for _, ticker := range before {
+		listed[ticker] = true
+	}
+	added := make([]string, 0)
+	for _, ticker := range after {
+		if !listed[ticker] {
+			added = append(added, ticker)
+		}
+	}
+	return added
+}
+
+// entityList returns entities, or an empty list for a body without any
+func entityList(entities []*domain.Entity) []*domain.Entity {
+	if entities == nil {
+		return []*domain.Entity{}
+	}
+	return entities
+}
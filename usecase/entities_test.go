@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEntities(t *testing.T) {
+	entities := parseEntities("Long $aapl and $BRK.B, ask @jane_doe. #Earnings #1 mail@example.com US$5 ¡#Ñandú $btc/usd.")
+
+	assert.Equal(t, []*domain.Entity{
+		{Type: domain.EntityCashtag, Start: 5, End: 10, Text: "AAPL"},
+		{Type: domain.EntityCashtag, Start: 15, End: 21, Text: "BRK.B"},
+		{Type: domain.EntityMention, Start: 27, End: 36, Text: "jane_doe"},
+		{Type: domain.EntityHashtag, Start: 38, End: 47, Text: "earnings"},
+		{Type: domain.EntityHashtag, Start: 74, End: 80, Text: "ñandú"},
+		{Type: domain.EntityCashtag, Start: 81, End: 89, Text: "BTCUSD"},
+	}, entities)
+}
+
+func TestPostTickers(t *testing.T) {
+	entities := []*domain.Entity{
+		{Type: domain.EntityCashtag, Text: "TSLA"},
+		{Type: domain.EntityHashtag, Text: "ev"},
+		{Type: domain.EntityCashtag, Text: "AAPL"},
+		{Type: domain.EntityCashtag, Text: "TSLA"},
+	}
+	assert.Equal(t, []string{"AAPL", "TSLA"}, postTickers("AAPL", entities))
+}
+
+func TestAddedTickers(t *testing.T) {
+	assert.Equal(t, []string{"TSLA"}, addedTickers([]string{"SPY", "AAPL"}, []string{"SPY", "TSLA"}))
+	assert.Equal(t, []string{"TSLA", "NVDA"}, addedTickers([]string{"SPY"}, []string{"SPY", "TSLA", "NVDA"}))
+	assert.Empty(t, addedTickers([]string{"SPY", "AAPL"}, []string{"SPY"}))
+	assert.Empty(t, addedTickers([]string{"SPY", "AAPL"}, []string{"SPY", "AAPL"}))
+}
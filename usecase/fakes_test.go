@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+)
+
+// fakeUserRepo holds users in memory by id and counts lookups by id
+type fakeUserRepo struct {
+	mu                 sync.Mutex
+	users              map[int]*domain.User
+	getUserByIdCalls   int
+	getUsersByIdsCalls int
+}
+
+func newFakeUserRepo(users ...*domain.User) *fakeUserRepo {
+	f := &fakeUserRepo{users: make(map[int]*domain.User)}
+	for _, user := range users {
+		f.users[user.Id] = user
+	}
+	return f
+}
+
+func (f *fakeUserRepo) GetUsers(ctx context.Context) ([]*domain.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	users := make([]*domain.User, 0, len(f.users))
+	for _, user := range f.users {
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (f *fakeUserRepo) GetUserById(ctx context.Context, id int) (*domain.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.getUserByIdCalls++
+	user, ok := f.users[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (f *fakeUserRepo) GetUsersByIds(ctx context.Context, ids []int) (map[int]*domain.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.getUsersByIdsCalls++
+	users := make(map[int]*domain.User, len(ids))
+	for _, id := range ids {
+		if user, ok := f.users[id]; ok {
+			users[id] = user
+		}
+	}
+	return users, nil
+}
+
+func (f *fakeUserRepo) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, user := range f.users {
+		if strings.EqualFold(user.Email, email) {
+			return user, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeUserRepo) GetUserIdsByNames(ctx context.Context, names []string) (map[string]int, error) {
+	return map[string]int{}, nil
+}
+
+func (f *fakeUserRepo) CreateUser(ctx context.Context, user *domain.User) (*domain.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	user.Id = len(f.users) + 1
+	f.users[user.Id] = user
+	return user, nil
+}
+
+func (f *fakeUserRepo) UpdateUser(ctx context.Context, user *domain.User) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.users[user.Id] = user
+	return nil
+}
+
+func (f *fakeUserRepo) DeleteUser(ctx context.Context, userId int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.users, userId)
+	return nil
+}
+
+// fakeSessionRepo holds sessions in memory by family id
+type fakeSessionRepo struct {
+	mu       sync.Mutex
+	sessions map[string]*domain.Session
+}
+
+func newFakeSessionRepo() *fakeSessionRepo {
+	return &fakeSessionRepo{sessions: make(map[string]*domain.Session)}
+}
+
+func (f *fakeSessionRepo) CreateSession(ctx context.Context, session *domain.Session) (*domain.Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	created := *session
+	created.Id = len(f.sessions) + 1
+	created.CreatedAt = time.Now()
+	created.LastSeenAt = created.CreatedAt
+	f.sessions[created.FamilyId] = &created
+	return &created, nil
+}
+
+func (f *fakeSessionRepo) GetSessionByFamilyId(ctx context.Context, familyId string) (*domain.Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	session, ok := f.sessions[familyId]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (f *fakeSessionRepo) GetSessions(ctx context.Context, userId int) ([]*domain.Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sessions := make([]*domain.Session, 0)
+	for _, session := range f.sessions {
+		if session.UserId == userId && session.RevokedAt == nil && session.ExpiresAt.After(time.Now()) {
+			copied := *session
+			sessions = append(sessions, &copied)
+		}
+	}
+	return sessions, nil
+}
+
+func (f *fakeSessionRepo) RotateToken(ctx context.Context, tokenId string, next *domain.Session) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	session, ok := f.sessions[next.FamilyId]
+	if !ok || session.TokenId != tokenId || session.RevokedAt != nil {
+		return false, nil
+	}
+	session.TokenId = next.TokenId
+	session.Device, session.UserAgent, session.Ip = next.Device, next.UserAgent, next.Ip
+	session.ExpiresAt = next.ExpiresAt
+	session.LastSeenAt = time.Now()
+	return true, nil
+}
+
+func (f *fakeSessionRepo) TouchSession(ctx context.Context, id int) error {
+	return nil
+}
+
+func (f *fakeSessionRepo) RevokeSession(ctx context.Context, userId int, familyId string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	session, ok := f.sessions[familyId]
+	if !ok || session.UserId != userId || session.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	session.RevokedAt = &now
+	return true, nil
+}
+
+func (f *fakeSessionRepo) RevokeSessionById(ctx context.Context, userId, id int) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, session := range f.sessions {
+		if session.Id == id && session.UserId == userId && session.RevokedAt == nil {
+			now := time.Now()
+			session.RevokedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeSessionRepo) RevokeOtherSessions(ctx context.Context, userId, exceptId int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	revoked := 0
+	for _, session := range f.sessions {
+		if session.UserId == userId && session.Id != exceptId && session.RevokedAt == nil {
+			now := time.Now()
+			session.RevokedAt = &now
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+// revokeAll ends every live session of the user, as resetting their password does
+func (f *fakeSessionRepo) revokeAll(userId int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, session := range f.sessions {
+		if session.UserId == userId && session.RevokedAt == nil {
+			now := time.Now()
+			session.RevokedAt = &now
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type feedStreamUseCase struct {
+	feedEventRepository repository.FeedEventRepository
+	followerRepository  repository.FollowerRepository
+	tickerFollowerRepo  repository.TickerFollowerRepository
+	contextTimeout      time.Duration
+}
+
+func NewFeedStreamUseCase(
+	feedEventRepo repository.FeedEventRepository,
+	followerRepo repository.FollowerRepository,
+	tickerFollowerRepo repository.TickerFollowerRepository,
+	timeout time.Duration,
+) domain.FeedStreamUseCase {
+	return &feedStreamUseCase{
+		feedEventRepository: feedEventRepo,
+		followerRepository:  followerRepo,
+		tickerFollowerRepo:  tickerFollowerRepo,
+		contextTimeout:      timeout,
+	}
+}
+
+func (uc *feedStreamUseCase) Subscribe(ctx context.Context, userId int, filter domain.FeedFilter) (<-chan *domain.FeedEvent, error) {
+	filter.Ticker = strings.ToUpper(strings.TrimSpace(filter.Ticker))
+	if filter.Feed == "" {
+		filter.Feed = domain.FeedGlobal
+		if filter.Ticker != "" {
+			filter.Feed = domain.FeedTicker
+		}
+	}
+
+	var match func(event *domain.FeedEvent) bool
+	switch filter.Feed {
+	case domain.FeedGlobal:
+		// Mirrors GetGlobalFeed, which leaves out the viewer's own posts
+		match = func(event *domain.FeedEvent) bool {
+			return event.Type != domain.FeedEventPostCreated || event.PostAuthorId != userId
+		}
+	case domain.FeedTicker:
+		if filter.Ticker == "" {
+			return nil, errors.New("ticker is required")
+		}
+		match = func(event *domain.FeedEvent) bool {
+			return slices.Contains(eventTickers(event), filter.Ticker)
+		}
+	case domain.FeedFollowing:
+		// The followed users and tickers are captured when the stream opens,
+		// clients reconnect after following someone or something new
+		lookupCtx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+		followingIds, err := uc.followerRepository.GetFollowingIds(lookupCtx, userId)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		symbols, err := uc.tickerFollowerRepo.GetFollowedSymbols(lookupCtx, userId)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		following := make(map[int]bool, len(followingIds))
+		for _, id := range followingIds {
+			following[id] = true
+		}
+		followedTickers := make(map[string]bool, len(symbols))
+		for _, symbol := range symbols {
+			followedTickers[symbol] = true
+		}
+		match = func(event *domain.FeedEvent) bool {
+			if following[event.PostAuthorId] {
+				return true
+			}
+			if event.PostAuthorId == userId {
+				return false
+			}
+			return slices.ContainsFunc(eventTickers(event), func(symbol string) bool { return followedTickers[symbol] })
+		}
+	default:
+		return nil, errors.New("feed must be one of global, following or ticker")
+	}
+
+	events, err := uc.feedEventRepository.Subscribe(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan *domain.FeedEvent)
+	go func() {
+		defer close(out)
+		for event := range events {
+			if !match(event) {
+				continue
+			}
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// publishFeedEvent pushes an event to live feed subscribers. Streams are a
+// best-effort convenience, so a failure is logged and never fails the write.
+func publishFeedEvent(ctx context.Context, feedEventRepository repository.FeedEventRepository, event *domain.FeedEvent) {
+	if err := feedEventRepository.Publish(ctx, event); err != nil {
+		log.Warnf("Failed to publish %s event for post %d: %v", event.Type, event.PostId, err)
+	}
+}
+
+// eventTickers lists the tickers the post of an event is listed under
+func eventTickers(event *domain.FeedEvent) []string {
+	if len(event.Tickers) == 0 {
+		return []string{event.Ticker}
+	}
+	return event.Tickers
+}
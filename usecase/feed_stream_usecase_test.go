@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFeedEventRepo replays a fixed set of events to each subscriber, then ends the stream
+type fakeFeedEventRepo struct {
+	events []*domain.FeedEvent
+}
+
+func (f *fakeFeedEventRepo) Publish(ctx context.Context, event *domain.FeedEvent) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+func (f *fakeFeedEventRepo) Subscribe(ctx context.Context) (<-chan *domain.FeedEvent, error) {
+	events := make(chan *domain.FeedEvent, len(f.events))
+	for _, event := range f.events {
+		events <- event
+	}
+	close(events)
+	return events, nil
+}
+
+// fakeFeedFollowerRepo knows whom the viewer follows, the other methods are left to the nil interface
+type fakeFeedFollowerRepo struct {
+	repository.FollowerRepository
+	followingIds []int
+}
+
+func (f *fakeFeedFollowerRepo) GetFollowingIds(ctx context.Context, userId int) ([]int, error) {
+	return f.followingIds, nil
+}
+
+// fakeFeedTickerFollowerRepo knows which tickers the viewer follows
+type fakeFeedTickerFollowerRepo struct {
+	repository.TickerFollowerRepository
+	symbols []string
+}
+
+func (f *fakeFeedTickerFollowerRepo) GetFollowedSymbols(ctx context.Context, userId int) ([]string, error) {
+	return f.symbols, nil
+}
+
+func TestFeedStreamFilters(t *testing.T) {
+	const viewer, followed, stranger = 1, 2, 3
+	events := []*domain.FeedEvent{
+		{Type: domain.FeedEventPostCreated, PostId: 1, Ticker: "AAPL", PostAuthorId: stranger},
+		{Type: domain.FeedEventPostCreated, PostId: 2, Ticker: "TSLA", PostAuthorId: viewer},
+		{Type: domain.FeedEventLikesChanged, PostId: 3, Ticker: "TSLA", PostAuthorId: viewer},
+		{Type: domain.FeedEventPostCreated, PostId: 4, Ticker: "MSFT", PostAuthorId: followed},
+		// Listed under a second ticker
+		{Type: domain.FeedEventCommentCreated, PostId: 5, Ticker: "NVDA", Tickers: []string{"NVDA", "AAPL"}, PostAuthorId: stranger},
+		{Type: domain.FeedEventPostDeleted, PostId: 6, Ticker: "GME", PostAuthorId: stranger},
+	}
+
+	tests := []struct {
+		name    string
+		filter  domain.FeedFilter
+		postIds []int
+	}{
+		{
+			name:    "global leaves out the viewer's new posts only",
+			filter:  domain.FeedFilter{Feed: domain.FeedGlobal},
+			postIds: []int{1, 3, 4, 5, 6},
+		},
+		{
+			name:    "no feed is global",
+			filter:  domain.FeedFilter{},
+			postIds: []int{1, 3, 4, 5, 6},
+		},
+		{
+			name:    "ticker matches every ticker a post is listed under",
+			filter:  domain.FeedFilter{Feed: domain.FeedTicker, Ticker: " aapl "},
+			postIds: []int{1, 5},
+		},
+		{
+			name:    "a ticker alone picks the ticker feed",
+			filter:  domain.FeedFilter{Ticker: "tsla"},
+			postIds: []int{2, 3},
+		},
+		{
+			name:    "following matches followed users and tickers, not the viewer",
+			filter:  domain.FeedFilter{Feed: domain.FeedFollowing},
+			postIds: []int{4, 6},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewFeedStreamUseCase(
+				&fakeFeedEventRepo{events: events},
+				&fakeFeedFollowerRepo{followingIds: []int{followed}},
+				&fakeFeedTickerFollowerRepo{symbols: []string{"GME", "TSLA"}},
+				time.Second,
+			)
+
+			stream, err := uc.Subscribe(context.Background(), viewer, tt.filter)
+			require.NoError(t, err)
+
+			postIds := make([]int, 0)
+			for event := range stream {
+				postIds = append(postIds, event.PostId)
+			}
+			assert.Equal(t, tt.postIds, postIds)
+		})
+	}
+}
+
+func TestFeedStreamRejectsInvalidFilters(t *testing.T) {
+	uc := NewFeedStreamUseCase(&fakeFeedEventRepo{}, &fakeFeedFollowerRepo{}, &fakeFeedTickerFollowerRepo{}, time.Second)
+
+	_, err := uc.Subscribe(context.Background(), 1, domain.FeedFilter{Feed: domain.FeedTicker})
+	assert.Error(t, err)
+	_, err = uc.Subscribe(context.Background(), 1, domain.FeedFilter{Feed: "popular"})
+	assert.Error(t, err)
+}
@@ -7,23 +7,31 @@ import (
 
 	"github.com/Pro100-Almaz/trading-chat/domain"
 	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	log "github.com/sirupsen/logrus"
 )
 
 type followerUseCase struct {
-	followerRepository repository.FollowerRepository
-	userRepository     repository.UserRepository
-	contextTimeout     time.Duration
+	followerRepository  repository.FollowerRepository
+	userRepository      repository.UserRepository
+	timelineUseCase     domain.TimelineUseCase
+	notificationUseCase domain.NotificationUseCase
+	contextTimeout      time.Duration
 }
 
 func NewFollowerUseCase(
 	followerRepo repository.FollowerRepository,
 	userRepo repository.UserRepository,
+	timelineUseCase domain.TimelineUseCase,
+	notificationUseCase domain.NotificationUseCase,
 	timeout time.Duration,
 ) domain.FollowerUseCase {
 	return &followerUseCase{
-		followerRepository: followerRepo,
-		userRepository:     userRepo,
-		contextTimeout:     timeout,
+		followerRepository:  followerRepo,
+		userRepository:      userRepo,
+		timelineUseCase:     timelineUseCase,
+		notificationUseCase: notificationUseCase,
+		contextTimeout:      timeout,
 	}
 }
 
@@ -41,29 +49,46 @@ func (uc *followerUseCase) Follow(ctx context.Context, followerId, followingId i
 		return errors.New("user not found")
 	}
 
-	return uc.followerRepository.Follow(ctx, followerId, followingId)
+	if err := uc.followerRepository.Follow(ctx, followerId, followingId); err != nil {
+		return err
+	}
+
+	// The follow itself succeeded, a stale timeline is not worth failing it for
+	if err := uc.timelineUseCase.Follow(ctx, followerId, followingId); err != nil {
+		log.Warnf("Failed to backfill timeline of user %d: %v", followerId, err)
+	}
+
+	notifyAsync(uc.notificationUseCase, &domain.Notification{
+		UserId:  followingId,
+		ActorId: &followerId,
+		Type:    domain.NotificationFollow,
+	})
+	return nil
 }
 
 func (uc *followerUseCase) Unfollow(ctx context.Context, followerId, followingId int) error {
 	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
 	defer cancel()
 
-	return uc.followerRepository.Unfollow(ctx, followerId, followingId)
+	if err := uc.followerRepository.Unfollow(ctx, followerId, followingId); err != nil {
+		return err
+	}
+
+	if err := uc.timelineUseCase.Unfollow(ctx, followerId, followingId); err != nil {
+		log.Warnf("Failed to trim timeline of user %d: %v", followerId, err)
+	}
+	return nil
 }
 
-func (uc *followerUseCase) GetFollowers(ctx context.Context, userId, limit, offset int) (*domain.PaginatedResponse, error) {
+func (uc *followerUseCase) GetFollowers(ctx context.Context, userId int, page domain.PaginationParams) (*domain.PaginatedResponse, error) {
 	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
 	defer cancel()
 
-	users, err := uc.followerRepository.GetFollowers(ctx, userId, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-
-	total, err := uc.followerRepository.GetFollowersCount(ctx, userId)
+	users, err := uc.followerRepository.GetFollowers(ctx, userId, fetchPage(page))
 	if err != nil {
 		return nil, err
 	}
+	users, next := trimPage(users, page.Limit, followCursor)
 
 	responses := make([]*domain.FollowUserResponse, 0, len(users))
 	for _, user := range users {
@@ -74,22 +99,20 @@ func (uc *followerUseCase) GetFollowers(ctx context.Context, userId, limit, offs
 		})
 	}
 
-	return domain.NewPaginatedResponse(responses, total, limit, offset), nil
+	return newPageResponse(responses, page, next, func() (int, error) {
+		return uc.followerRepository.GetFollowersCount(ctx, userId)
+	})
 }
 
-func (uc *followerUseCase) GetFollowing(ctx context.Context, userId, limit, offset int) (*domain.PaginatedResponse, error) {
+func (uc *followerUseCase) GetFollowing(ctx context.Context, userId int, page domain.PaginationParams) (*domain.PaginatedResponse, error) {
 	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
 	defer cancel()
 
-	users, err := uc.followerRepository.GetFollowing(ctx, userId, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-
-	total, err := uc.followerRepository.GetFollowingCount(ctx, userId)
+	users, err := uc.followerRepository.GetFollowing(ctx, userId, fetchPage(page))
 	if err != nil {
 		return nil, err
 	}
+	users, next := trimPage(users, page.Limit, followCursor)
 
 	responses := make([]*domain.FollowUserResponse, 0, len(users))
 	for _, user := range users {
@@ -100,7 +123,9 @@ func (uc *followerUseCase) GetFollowing(ctx context.Context, userId, limit, offs
 		})
 	}
 
-	return domain.NewPaginatedResponse(responses, total, limit, offset), nil
+	return newPageResponse(responses, page, next, func() (int, error) {
+		return uc.followerRepository.GetFollowingCount(ctx, userId)
+	})
 }
 
 func (uc *followerUseCase) IsFollowing(ctx context.Context, followerId, followingId int) (bool, error) {
@@ -109,3 +134,8 @@ func (uc *followerUseCase) IsFollowing(ctx context.Context, followerId, followin
 
 	return uc.followerRepository.IsFollowing(ctx, followerId, followingId)
 }
+
+// followCursor positions follow lists on the follow, not on the user
+func followCursor(entry *domain.FollowListEntry) *domain.Cursor {
+	return &domain.Cursor{CreatedAt: entry.FollowedAt, Id: entry.FollowId}
+}
@@ -13,7 +13,6 @@ import (
 
 	"github.com/Pro100-Almaz/trading-chat/bootstrap"
 	"github.com/Pro100-Almaz/trading-chat/domain"
-	"github.com/Pro100-Almaz/trading-chat/internal/tokenutil"
 	"github.com/Pro100-Almaz/trading-chat/repository"
 
 	log "github.com/sirupsen/logrus"
@@ -21,18 +20,22 @@ import (
 )
 
 type googleUseCase struct {
-	userRepository repository.UserRepository
-	contextTimeout time.Duration
+	userRepository      repository.UserRepository
+	sessionRepository   repository.SessionRepository
+	twoFactorRepository repository.TwoFactorRepository
+	contextTimeout      time.Duration
 }
 
-func NewGoogleUseCase(userRepository repository.UserRepository, timeout time.Duration) domain.GoogleUseCase {
+func NewGoogleUseCase(userRepository repository.UserRepository, sessionRepository repository.SessionRepository, twoFactorRepository repository.TwoFactorRepository, timeout time.Duration) domain.GoogleUseCase {
 	return &googleUseCase{
-		userRepository: userRepository,
-		contextTimeout: timeout,
+		userRepository:      userRepository,
+		sessionRepository:   sessionRepository,
+		twoFactorRepository: twoFactorRepository,
+		contextTimeout:      timeout,
 	}
 }
 
-func (lu *googleUseCase) GoogleLogin(ctx context.Context, data []byte, env *bootstrap.Env) (accessToken string, refreshToken string, err error) {
+func (lu *googleUseCase) GoogleLogin(ctx context.Context, data []byte, client domain.SessionClient, env *bootstrap.Env) (response *domain.LoginResponse, err error) {
 	var googleUser *domain.GoogleUser
 	err = json.Unmarshal(data, &googleUser)
 	if err != nil {
@@ -69,15 +72,8 @@ func (lu *googleUseCase) GoogleLogin(ctx context.Context, data []byte, env *boot
 		user = existingUser
 	}
 
-	// Create access token
-	accessToken, err = tokenutil.CreateAccessToken(user, env.AccessTokenSecret, env.AccessTokenExpiryHour)
-	if err != nil {
-		log.Error(err)
-		return
-	}
-
-	// Create refresh token
-	refreshToken, err = tokenutil.CreateRefreshToken(user, env.RefreshTokenSecret, env.RefreshTokenExpiryHour)
+	// Open a session and create its tokens, or a challenge for the second factor
+	response, err = beginLogin(ctx, lu.twoFactorRepository, lu.sessionRepository, user, client, env)
 	if err != nil {
 		log.Error(err)
 		return
@@ -6,23 +6,31 @@ import (
 
 	"github.com/Pro100-Almaz/trading-chat/domain"
 	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	log "github.com/sirupsen/logrus"
 )
 
 type likeUseCase struct {
-	likeRepository repository.LikeRepository
-	postRepository repository.PostRepository
-	contextTimeout time.Duration
+	likeRepository      repository.LikeRepository
+	postRepository      repository.PostRepository
+	feedEventRepository repository.FeedEventRepository
+	notificationUseCase domain.NotificationUseCase
+	contextTimeout      time.Duration
 }
 
 func NewLikeUseCase(
 	likeRepo repository.LikeRepository,
 	postRepo repository.PostRepository,
+	feedEventRepo repository.FeedEventRepository,
+	notificationUseCase domain.NotificationUseCase,
 	timeout time.Duration,
 ) domain.LikeUseCase {
 	return &likeUseCase{
-		likeRepository: likeRepo,
-		postRepository: postRepo,
-		contextTimeout: timeout,
+		likeRepository:      likeRepo,
+		postRepository:      postRepo,
+		feedEventRepository: feedEventRepo,
+		notificationUseCase: notificationUseCase,
+		contextTimeout:      timeout,
 	}
 }
 
@@ -31,17 +39,56 @@ func (uc *likeUseCase) LikePost(ctx context.Context, userId, postId int) error {
 	defer cancel()
 
 	// Verify post exists
-	_, err := uc.postRepository.GetPostById(ctx, postId)
+	post, err := uc.postRepository.GetPostById(ctx, postId)
 	if err != nil {
 		return err
 	}
 
-	return uc.likeRepository.LikePost(ctx, userId, postId)
+	if err := uc.likeRepository.LikePost(ctx, userId, postId); err != nil {
+		return err
+	}
+
+	uc.publishLikesChanged(ctx, post)
+	notifyAsync(uc.notificationUseCase, &domain.Notification{
+		UserId:  post.UserId,
+		ActorId: &userId,
+		Type:    domain.NotificationLike,
+		PostId:  &post.Id,
+	})
+	return nil
 }
 
 func (uc *likeUseCase) UnlikePost(ctx context.Context, userId, postId int) error {
 	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
 	defer cancel()
 
-	return uc.likeRepository.UnlikePost(ctx, userId, postId)
+	if err := uc.likeRepository.UnlikePost(ctx, userId, postId); err != nil {
+		return err
+	}
+
+	post, err := uc.postRepository.GetPostById(ctx, postId)
+	if err != nil {
+		log.Warnf("Skipping likes event for post %d: %v", postId, err)
+		return nil
+	}
+
+	uc.publishLikesChanged(ctx, post)
+	return nil
+}
+
+func (uc *likeUseCase) publishLikesChanged(ctx context.Context, post *domain.Post) {
+	likesCount, err := uc.likeRepository.GetLikesCount(ctx, post.Id)
+	if err != nil {
+		log.Warnf("Skipping likes event for post %d: %v", post.Id, err)
+		return
+	}
+
+	publishFeedEvent(ctx, uc.feedEventRepository, &domain.FeedEvent{
+		Type:         domain.FeedEventLikesChanged,
+		PostId:       post.Id,
+		Ticker:       post.Ticker,
+		Tickers:      post.Tickers,
+		PostAuthorId: post.UserId,
+		LikesCount:   &likesCount,
+	})
 }
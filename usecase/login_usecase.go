@@ -2,11 +2,13 @@ package usecase
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/Pro100-Almaz/trading-chat/bootstrap"
 	"github.com/Pro100-Almaz/trading-chat/domain"
-	"github.com/Pro100-Almaz/trading-chat/internal/tokenutil"
+	"github.com/Pro100-Almaz/trading-chat/internal/email"
 	"github.com/Pro100-Almaz/trading-chat/repository"
 
 	log "github.com/sirupsen/logrus"
@@ -14,22 +16,43 @@ import (
 )
 
 type loginUseCase struct {
-	userRepository repository.UserRepository
-	contextTimeout time.Duration
+	userRepository      repository.UserRepository
+	sessionRepository   repository.SessionRepository
+	twoFactorRepository repository.TwoFactorRepository
+	attemptLimiter      *attemptLimiter
+	contextTimeout      time.Duration
 }
 
-func NewLoginUseCase(userRepository repository.UserRepository, timeout time.Duration) domain.LoginUseCase {
+func NewLoginUseCase(
+	userRepository repository.UserRepository,
+	sessionRepository repository.SessionRepository,
+	twoFactorRepository repository.TwoFactorRepository,
+	loginAttemptRepository repository.LoginAttemptRepository,
+	emailService *email.EmailService,
+	timeout time.Duration,
+) domain.LoginUseCase {
 	return &loginUseCase{
-		userRepository: userRepository,
-		contextTimeout: timeout,
+		userRepository:      userRepository,
+		sessionRepository:   sessionRepository,
+		twoFactorRepository: twoFactorRepository,
+		attemptLimiter:      newAttemptLimiter(loginAttemptRepository, emailService, "login", accountAttemptPolicy),
+		contextTimeout:      timeout,
 	}
 }
 
-func (lu *loginUseCase) Login(ctx context.Context, request domain.LoginRequest, env *bootstrap.Env) (accessToken string, refreshToken string, err error) {
+func (lu *loginUseCase) Login(ctx context.Context, request domain.LoginRequest, client domain.SessionClient, env *bootstrap.Env) (response *domain.LoginResponse, err error) {
+	if err = lu.attemptLimiter.check(ctx, request.Email, client.Ip); err != nil {
+		log.Warn(err)
+		return
+	}
+
 	var user *domain.User
 	user, err = lu.userRepository.GetUserByEmail(ctx, request.Email)
 	if err != nil {
 		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			lu.attemptLimiter.fail(ctx, request.Email, client.Ip, nil)
+		}
 		return
 	}
 
@@ -41,9 +64,11 @@ func (lu *loginUseCase) Login(ctx context.Context, request domain.LoginRequest,
 
 	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(request.Password)) != nil {
 		log.Error("Invalid password")
+		lu.attemptLimiter.fail(ctx, request.Email, client.Ip, user)
 		err = domain.ErrInvalidPassword
 		return
 	}
+	lu.attemptLimiter.succeed(ctx, request.Email)
 
 	if !user.IsVerified {
 		log.Error("Email not verified")
@@ -51,17 +76,11 @@ func (lu *loginUseCase) Login(ctx context.Context, request domain.LoginRequest,
 		return
 	}
 
-	accessToken, err = tokenutil.CreateAccessToken(user, env.AccessTokenSecret, env.AccessTokenExpiryHour)
-	if err != nil {
-		log.Error(err)
-		return
-	}
-
-	refreshToken, err = tokenutil.CreateRefreshToken(user, env.RefreshTokenSecret, env.RefreshTokenExpiryHour)
+	response, err = beginLogin(ctx, lu.twoFactorRepository, lu.sessionRepository, user, client, env)
 	if err != nil {
 		log.Error(err)
 		return
 	}
 
-	return accessToken, refreshToken, nil
+	return response, nil
 }
@@ -13,15 +13,18 @@ import (
 
 type logoutUseCase struct {
 	tokenBlacklistRepository repository.TokenBlacklistRepository
+	sessionRepository        repository.SessionRepository
 	contextTimeout           time.Duration
 }
 
 func NewLogoutUseCase(
 	tokenBlacklistRepo repository.TokenBlacklistRepository,
+	sessionRepo repository.SessionRepository,
 	timeout time.Duration,
 ) domain.LogoutUseCase {
 	return &logoutUseCase{
 		tokenBlacklistRepository: tokenBlacklistRepo,
+		sessionRepository:        sessionRepo,
 		contextTimeout:           timeout,
 	}
 }
@@ -45,20 +48,33 @@ func (lu *logoutUseCase) Logout(ctx context.Context, userId int, accessToken str
 		return err
 	}
 
-	// Blacklist refresh token if provided
+	// End the session of the access token, and the one of the refresh token
+	// if provided, so that none of their tokens work any longer
+	tokens := []string{accessToken}
 	if refreshToken != "" {
-		refreshTokenExp, err := extractTokenExpiration(refreshToken)
+		tokens = append(tokens, refreshToken)
+	}
+	ended := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		familyId, err := extractTokenFamily(token)
 		if err != nil {
-			log.Error("Failed to extract refresh token expiration: ", err)
-			// Continue anyway, use a default expiration
-			refreshTokenExp = time.Now().Add(168 * time.Hour)
+			log.Error("Failed to extract token session: ", err)
+			return err
 		}
+		if ended[familyId] {
+			continue
+		}
+		ended[familyId] = true
 
-		err = lu.tokenBlacklistRepository.AddToBlacklist(ctx, refreshToken, refreshTokenExp)
+		// Scoped to the user, so that a token of someone else ends nothing
+		revoked, err := lu.sessionRepository.RevokeSession(ctx, userId, familyId)
 		if err != nil {
-			log.Error("Failed to blacklist refresh token: ", err)
+			log.Error("Failed to revoke session: ", err)
 			return err
 		}
+		if !revoked {
+			log.Warnf("User %d logged out of a session that is unknown or already ended", userId)
+		}
 	}
 
 	log.Infof("User %d logged out successfully", userId)
@@ -80,3 +96,19 @@ func extractTokenExpiration(tokenString string) (time.Time, error) {
 
 	return time.Time{}, domain.ErrInvalidToken
 }
+
+// extractTokenFamily extracts the session an access or refresh token belongs to
+func extractTokenFamily(tokenString string) (string, error) {
+	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
+	if err != nil {
+		return "", err
+	}
+
+	if claims, ok := token.Claims.(jwt.MapClaims); ok {
+		if familyId, ok := claims["fid"].(string); ok && familyId != "" {
+			return familyId, nil
+		}
+	}
+
+	return "", domain.ErrLegacyRefreshToken
+}
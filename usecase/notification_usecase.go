@@ -0,0 +1,175 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type notificationUseCase struct {
+	notificationRepository repository.NotificationRepository
+	userRepository         repository.UserRepository
+	contextTimeout         time.Duration
+}
+
+func NewNotificationUseCase(
+	notificationRepo repository.NotificationRepository,
+	userRepo repository.UserRepository,
+	timeout time.Duration,
+) domain.NotificationUseCase {
+	return &notificationUseCase{
+		notificationRepository: notificationRepo,
+		userRepository:         userRepo,
+		contextTimeout:         timeout,
+	}
+}
+
+func (uc *notificationUseCase) Notify(ctx context.Context, notifications ...*domain.Notification) error {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	for _, notification := range notifications {
+		if notification.ActorId != nil && *notification.ActorId == notification.UserId {
+			continue
+		}
+		if err := uc.notificationRepository.CreateNotification(ctx, notification); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (uc *notificationUseCase) GetNotifications(ctx context.Context, userId int, page domain.PaginationParams) (*domain.NotificationsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	groups, err := uc.notificationRepository.GetNotificationGroups(ctx, userId, fetchPage(page))
+	if err != nil {
+		return nil, err
+	}
+	groups, next := trimPage(groups, page.Limit, func(group *domain.NotificationGroup) *domain.Cursor {
+		return &domain.Cursor{CreatedAt: group.CreatedAt, Id: group.Id}
+	})
+
+	actorIds := make([]int, 0)
+	for _, group := range groups {
+		for _, id := range group.ActorIds {
+			actorIds = append(actorIds, int(id))
+		}
+	}
+	actors, err := uc.userRepository.GetUsersByIds(ctx, actorIds)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*domain.NotificationResponse, 0, len(groups))
+	for _, group := range groups {
+		authors := make([]domain.Author, 0, len(group.ActorIds))
+		for _, id := range group.ActorIds {
+			if actor, ok := actors[int(id)]; ok {
+				authors = append(authors, domain.Author{Id: actor.Id, Name: actor.Name, AvatarEmoji: actor.AvatarEmoji})
+			}
+		}
+
+		responses = append(responses, &domain.NotificationResponse{
+			Id:          group.Id,
+			Type:        group.Type,
+			PostId:      group.PostId,
+			CommentId:   group.CommentId,
+			Ticker:      group.Ticker,
+			AlertRuleId: group.AlertRuleId,
+			Detail:      group.Detail,
+			Actors:      authors,
+			ActorsCount: group.ActorsCount,
+			IsRead:      !group.Unread,
+			CreatedAt:   group.CreatedAt,
+		})
+	}
+
+	response, err := newPageResponse(responses, page, next, func() (int, error) {
+		return uc.notificationRepository.GetNotificationGroupsCount(ctx, userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	unreadCount, err := uc.notificationRepository.GetUnreadCount(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.NotificationsResponse{PaginatedResponse: response, UnreadCount: unreadCount}, nil
+}
+
+func (uc *notificationUseCase) GetUnreadCount(ctx context.Context, userId int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	return uc.notificationRepository.GetUnreadCount(ctx, userId)
+}
+
+func (uc *notificationUseCase) MarkRead(ctx context.Context, userId, notificationId int) error {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	return uc.notificationRepository.MarkGroupRead(ctx, userId, notificationId)
+}
+
+func (uc *notificationUseCase) MarkAllRead(ctx context.Context, userId int) error {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	return uc.notificationRepository.MarkAllRead(ctx, userId)
+}
+
+// notifyAsync stores notifications without holding up the request. If it
+// fails, the users miss those notifications.
+func notifyAsync(notificationUseCase domain.NotificationUseCase, notifications ...*domain.Notification) {
+	if len(notifications) == 0 {
+		return
+	}
+	go func() {
+		if err := notificationUseCase.Notify(context.Background(), notifications...); err != nil {
+			log.Warnf("Failed to store %s notifications: %v", notifications[0].Type, err)
+		}
+	}()
+}
+
+// mentionNotifications notifies the users mentioned in a post or comment
+// body, except the ones in skip who are notified otherwise
+func mentionNotifications(actorId int, postId int, commentId *int, entities []*domain.Entity, skip ...int) []*domain.Notification {
+	notified := make(map[int]bool, len(skip))
+	for _, id := range skip {
+		notified[id] = true
+	}
+
+	notifications := make([]*domain.Notification, 0)
+	for _, entity := range entities {
+		if entity.Type != domain.EntityMention || entity.UserId == nil || notified[*entity.UserId] {
+			continue
+		}
+		notified[*entity.UserId] = true
+		notifications = append(notifications, &domain.Notification{
+			UserId:    *entity.UserId,
+			ActorId:   &actorId,
+			Type:      domain.NotificationMention,
+			PostId:    &postId,
+			CommentId: commentId,
+		})
+	}
+	return notifications
+}
+
+// mentionedUserIds lists the users mentioned among entities
+func mentionedUserIds(entities []*domain.Entity) []int {
+	userIds := make([]int, 0)
+	for _, entity := range entities {
+		if entity.Type == domain.EntityMention && entity.UserId != nil {
+			userIds = append(userIds, *entity.UserId)
+		}
+	}
+	return userIds
+}
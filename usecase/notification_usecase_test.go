@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMentionNotifications(t *testing.T) {
+	jane, john, author := 2, 3, 4
+	entities := []*domain.Entity{
+		{Type: domain.EntityMention, Text: "jane", UserId: &jane},
+		{Type: domain.EntityCashtag, Text: "AAPL"},
+		{Type: domain.EntityMention, Text: "Jane", UserId: &jane},
+		// Not resolved to a user
+		{Type: domain.EntityMention, Text: "nobody"},
+		{Type: domain.EntityMention, Text: "john", UserId: &john},
+		{Type: domain.EntityMention, Text: "author", UserId: &author},
+	}
+	commentId := 9
+
+	notifications := mentionNotifications(1, 5, &commentId, entities, author)
+
+	recipients := make([]int, 0, len(notifications))
+	for _, notification := range notifications {
+		recipients = append(recipients, notification.UserId)
+		assert.Equal(t, domain.NotificationMention, notification.Type)
+		assert.Equal(t, 5, *notification.PostId)
+		assert.Equal(t, commentId, *notification.CommentId)
+	}
+	assert.Equal(t, []int{jane, john}, recipients)
+}
+
+// fakeNotificationRepo records the notifications stored and serves fixed groups
+type fakeNotificationRepo struct {
+	repository.NotificationRepository
+	created []*domain.Notification
+	groups  []*domain.NotificationGroup
+	unread  int
+}
+
+func (f *fakeNotificationRepo) CreateNotification(ctx context.Context, notification *domain.Notification) error {
+	f.created = append(f.created, notification)
+	return nil
+}
+
+func (f *fakeNotificationRepo) GetNotificationGroups(ctx context.Context, userId int, page domain.PaginationParams) ([]*domain.NotificationGroup, error) {
+	return f.groups[:min(len(f.groups), page.Limit)], nil
+}
+
+func (f *fakeNotificationRepo) GetNotificationGroupsCount(ctx context.Context, userId int) (int, error) {
+	return len(f.groups), nil
+}
+
+func (f *fakeNotificationRepo) GetUnreadCount(ctx context.Context, userId int) (int, error) {
+	return f.unread, nil
+}
+
+func TestNotifySkipsOwnActions(t *testing.T) {
+	repo := &fakeNotificationRepo{}
+	uc := NewNotificationUseCase(repo, newFakeUserRepo(), time.Second)
+	author, reader, postId := 1, 2, 5
+
+	require.NoError(t, uc.Notify(context.Background(),
+		&domain.Notification{UserId: author, ActorId: &author, Type: domain.NotificationLike, PostId: &postId},
+		&domain.Notification{UserId: author, ActorId: &reader, Type: domain.NotificationLike, PostId: &postId},
+		&domain.Notification{UserId: author, Type: domain.NotificationAlert},
+	))
+
+	require.Len(t, repo.created, 2)
+	assert.Equal(t, &reader, repo.created[0].ActorId)
+	assert.Equal(t, domain.NotificationAlert, repo.created[1].Type)
+}
+
+func TestGetNotificationsDescribesGroups(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	postId := 5
+	repo := &fakeNotificationRepo{
+		groups: []*domain.NotificationGroup{
+			// Actor 9 deleted their account meanwhile
+			{Id: 12, Type: domain.NotificationLike, PostId: &postId, ActorIds: pq.Int64Array{3, 9, 2}, ActorsCount: 4, Unread: true, CreatedAt: base.Add(time.Minute)},
+			{Id: 7, Type: domain.NotificationFollow, ActorIds: pq.Int64Array{2}, ActorsCount: 1, CreatedAt: base},
+		},
+		unread: 1,
+	}
+	users := newFakeUserRepo(&domain.User{Id: 2, Name: "jane"}, &domain.User{Id: 3, Name: "john"})
+	uc := NewNotificationUseCase(repo, users, time.Second)
+
+	response, err := uc.GetNotifications(context.Background(), 1, domain.PaginationParams{Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, response.UnreadCount)
+	assert.True(t, response.HasMore)
+	require.NotNil(t, response.Total)
+	assert.Equal(t, 2, *response.Total)
+
+	notifications := response.Data.([]*domain.NotificationResponse)
+	require.Len(t, notifications, 1)
+	likes := notifications[0]
+	assert.Equal(t, 12, likes.Id)
+	assert.False(t, likes.IsRead)
+	assert.Equal(t, 4, likes.ActorsCount)
+	assert.Equal(t, []domain.Author{{Id: 3, Name: "john"}, {Id: 2, Name: "jane"}}, likes.Actors)
+}
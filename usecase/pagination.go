@@ -0,0 +1,37 @@
+package usecase
+
+import "github.com/Pro100-Almaz/trading-chat/domain"
+
+// fetchPage asks for one row more than the page size, its presence tells that a next page exists
+func fetchPage(page domain.PaginationParams) domain.PaginationParams {
+	page.Limit++
+	return page
+}
+
+// trimPage drops the extra row fetched by fetchPage. When it was there, the
+// cursor of the last item kept is returned to continue from, otherwise nil.
+func trimPage[T any](items []T, limit int, cursorOf func(T) *domain.Cursor) ([]T, *domain.Cursor) {
+	if len(items) <= limit {
+		return items, nil
+	}
+	items = items[:limit]
+	return items, cursorOf(items[limit-1])
+}
+
+// newPageResponse builds the response of a page. The total is only counted
+// for offset pagination, cursor clients page on next_cursor alone.
+func newPageResponse(data interface{}, page domain.PaginationParams, next *domain.Cursor, count func() (int, error)) (*domain.PaginatedResponse, error) {
+	if page.Cursor != nil {
+		return domain.NewCursorPaginatedResponse(data, page.Limit, next), nil
+	}
+
+	total, err := count()
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewPaginatedResponse(data, total, page.Limit, page.Offset).WithNextCursor(next), nil
+}
+
+func postCursor(post *domain.Post) *domain.Cursor {
+	return &domain.Cursor{CreatedAt: post.CreatedAt, Id: post.Id}
+}
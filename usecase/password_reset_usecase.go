@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/internal/email"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	resetTokenTTL = 30 * time.Minute
+	// A user is sent at most maxResetRequests reset tokens per resetRequestWindow
+	maxResetRequests   = 3
+	resetRequestWindow = time.Hour
+	// And an address asks for at most maxIpResetRequests, whoever they are for
+	maxIpResetRequests = 20
+	minPasswordLength  = 8
+)
+
+type passwordResetUseCase struct {
+	userRepository          repository.UserRepository
+	passwordResetRepository repository.PasswordResetRepository
+	loginAttemptRepository  repository.LoginAttemptRepository
+	emailService            *email.EmailService
+	contextTimeout          time.Duration
+}
+
+func NewPasswordResetUseCase(
+	userRepo repository.UserRepository,
+	passwordResetRepo repository.PasswordResetRepository,
+	loginAttemptRepo repository.LoginAttemptRepository,
+	emailService *email.EmailService,
+	timeout time.Duration,
+) domain.PasswordResetUseCase {
+	return &passwordResetUseCase{
+		userRepository:          userRepo,
+		passwordResetRepository: passwordResetRepo,
+		loginAttemptRepository:  loginAttemptRepo,
+		emailService:            emailService,
+		contextTimeout:          timeout,
+	}
+}
+
+func (pu *passwordResetUseCase) ForgotPassword(ctx context.Context, userEmail, clientIp string) error {
+	ctx, cancel := context.WithTimeout(ctx, pu.contextTimeout)
+	defer cancel()
+
+	if err := pu.limitRequests(ctx, clientIp); err != nil {
+		log.Warn(err)
+		return err
+	}
+
+	// Looking the account up and mailing it happen after the response, which
+	// would otherwise take longer for emails that have an account
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), pu.contextTimeout)
+		defer cancel()
+
+		if err := pu.sendResetToken(ctx, userEmail); err != nil {
+			log.Error("Failed to send password reset token: ", err)
+		}
+	}()
+	return nil
+}
+
+// limitRequests counts a reset request from the address and returns a
+// TooManyAttemptsError while it has to wait. Requests go through when Redis is
+// down rather than block everyone.
+func (pu *passwordResetUseCase) limitRequests(ctx context.Context, clientIp string) error {
+	subject := "reset:ip:" + clientIp
+	retryAfter, err := pu.loginAttemptRepository.GetRetryAfter(ctx, subject)
+	if err != nil {
+		log.Error("Failed to check password reset requests: ", err)
+		return nil
+	}
+	if retryAfter > 0 {
+		return &domain.TooManyAttemptsError{RetryAfter: retryAfter}
+	}
+
+	// Every request counts, not only failed ones
+	requests, _, err := pu.loginAttemptRepository.RecordFailure(ctx, subject, resetRequestWindow)
+	if err != nil {
+		log.Error("Failed to record password reset request: ", err)
+		return nil
+	}
+	if requests >= maxIpResetRequests {
+		if err := pu.loginAttemptRepository.Lock(ctx, subject, resetRequestWindow, false); err != nil {
+			log.Error("Failed to limit password reset requests: ", err)
+		}
+	}
+	return nil
+}
+
+// sendResetToken emails a new reset token to the user with the email, unless
+// there is none with a password or they were sent enough already
+func (pu *passwordResetUseCase) sendResetToken(ctx context.Context, userEmail string) error {
+	user, err := pu.userRepository.GetUserByEmail(ctx, userEmail)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Info("Password reset asked for an unknown email")
+			return nil
+		}
+		return err
+	}
+
+	// Accounts signed up with Google have no password to reset
+	if user.GoogleId != "" {
+		log.Infof("Password reset asked for user %d, who logs in with Google", user.Id)
+		return nil
+	}
+
+	sent, err := pu.passwordResetRepository.CountResetTokens(ctx, user.Id, time.Now().Add(-resetRequestWindow))
+	if err != nil {
+		return err
+	}
+	if sent >= maxResetRequests {
+		log.Warnf("Password reset asked for user %d too often, not sending another", user.Id)
+		return nil
+	}
+
+	token, err := newSecretToken()
+	if err != nil {
+		return err
+	}
+
+	err = pu.passwordResetRepository.CreateResetToken(ctx, user.Id, hashToken(token), time.Now().Add(resetTokenTTL))
+	if err != nil {
+		return err
+	}
+
+	if err := pu.emailService.SendPasswordResetToken(user.Email, token); err != nil {
+		log.Error("Failed to send password reset email: ", err)
+	}
+
+	return nil
+}
+
+func (pu *passwordResetUseCase) ResetPassword(ctx context.Context, token, password string) error {
+	ctx, cancel := context.WithTimeout(ctx, pu.contextTimeout)
+	defer cancel()
+
+	if len(password) < minPasswordLength {
+		return domain.ErrPasswordTooShort
+	}
+
+	encryptedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	userId, err := pu.passwordResetRepository.ResetPassword(ctx, hashToken(token), string(encryptedPassword))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrInvalidResetToken
+		}
+		log.Error("Failed to reset password: ", err)
+		return err
+	}
+
+	log.Infof("User %d reset their password, all their sessions were revoked", userId)
+	return nil
+}
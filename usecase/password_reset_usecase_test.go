@@ -0,0 +1,205 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/internal/email"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakePasswordResetRepo holds reset tokens in memory by hash. Like the table
+// it stands for, redeeming a token sets the password and revokes the sessions
+// of its user in one go.
+type fakePasswordResetRepo struct {
+	mu       sync.Mutex
+	users    *fakeUserRepo
+	sessions *fakeSessionRepo
+	tokens   map[string]*fakeResetToken
+	// now is the time tokens are checked against
+	now time.Time
+}
+
+type fakeResetToken struct {
+	userId    int
+	createdAt time.Time
+	expiresAt time.Time
+	used      bool
+}
+
+func (f *fakePasswordResetRepo) CreateResetToken(ctx context.Context, userId int, tokenHash string, expiresAt time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, token := range f.tokens {
+		if token.userId == userId {
+			token.used = true
+		}
+	}
+	f.tokens[tokenHash] = &fakeResetToken{userId: userId, createdAt: time.Now(), expiresAt: expiresAt}
+	return nil
+}
+
+func (f *fakePasswordResetRepo) CountResetTokens(ctx context.Context, userId int, since time.Time) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for _, token := range f.tokens {
+		if token.userId == userId && token.createdAt.After(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakePasswordResetRepo) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	token, ok := f.tokens[tokenHash]
+	if !ok || token.used || !token.expiresAt.After(f.now) {
+		return 0, sql.ErrNoRows
+	}
+	token.used = true
+
+	user, err := f.users.GetUserById(ctx, token.userId)
+	if err != nil {
+		return 0, err
+	}
+	user.Password = passwordHash
+	f.sessions.revokeAll(token.userId)
+	return token.userId, nil
+}
+
+// tokenCount returns how many reset tokens were created
+func (f *fakePasswordResetRepo) tokenCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.tokens)
+}
+
+func newTestPasswordResetUseCase(t *testing.T) (*passwordResetUseCase, *fakePasswordResetRepo) {
+	repo := &fakePasswordResetRepo{
+		users:    newFakeUserRepo(&domain.User{Id: 1, Email: "jane@example.com"}),
+		sessions: newFakeSessionRepo(),
+		tokens:   make(map[string]*fakeResetToken),
+		now:      time.Now(),
+	}
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	uc := NewPasswordResetUseCase(
+		repo.users,
+		repo,
+		repository.NewLoginAttemptRedisRepository(client),
+		email.NewEmailService(&bootstrap.Env{}),
+		time.Second,
+	).(*passwordResetUseCase)
+	return uc, repo
+}
+
+func TestResetPasswordOnce(t *testing.T) {
+	uc, repo := newTestPasswordResetUseCase(t)
+	ctx := context.Background()
+	user, _ := repo.users.GetUserById(ctx, 1)
+
+	for i := 0; i < 2; i++ {
+		_, _, err := startSession(ctx, repo.sessions, user, domain.SessionClient{}, testTokenEnv)
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.CreateResetToken(ctx, 1, hashToken("token"), time.Now().Add(resetTokenTTL)))
+
+	require.NoError(t, uc.ResetPassword(ctx, "token", "new password"))
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("new password")))
+
+	// Every session of the user is over
+	live, err := repo.sessions.GetSessions(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, live)
+
+	err = uc.ResetPassword(ctx, "token", "another password")
+	assert.ErrorIs(t, err, domain.ErrInvalidResetToken)
+}
+
+func TestResetTokenExpires(t *testing.T) {
+	uc, repo := newTestPasswordResetUseCase(t)
+	ctx := context.Background()
+
+	require.NoError(t, uc.sendResetToken(ctx, "jane@example.com"))
+	require.Len(t, repo.tokens, 1)
+	for _, token := range repo.tokens {
+		assert.WithinDuration(t, time.Now().Add(30*time.Minute), token.expiresAt, time.Minute)
+	}
+
+	require.NoError(t, repo.CreateResetToken(ctx, 1, hashToken("token"), time.Now().Add(resetTokenTTL)))
+	repo.now = time.Now().Add(resetTokenTTL + time.Second)
+	err := uc.ResetPassword(ctx, "token", "new password")
+	assert.ErrorIs(t, err, domain.ErrInvalidResetToken)
+}
+
+func TestForgotPasswordLimitsTokens(t *testing.T) {
+	uc, repo := newTestPasswordResetUseCase(t)
+	ctx := context.Background()
+
+	// The fourth request within the hour is accepted but sends nothing
+	for i := 0; i < 4; i++ {
+		require.NoError(t, uc.sendResetToken(ctx, "jane@example.com"))
+	}
+	assert.Len(t, repo.tokens, 3)
+
+	// Older tokens stop counting once the hour is over
+	for _, token := range repo.tokens {
+		token.createdAt = token.createdAt.Add(-time.Hour)
+	}
+	require.NoError(t, uc.sendResetToken(ctx, "jane@example.com"))
+	assert.Len(t, repo.tokens, 4)
+
+	// Only the latest token works
+	live := 0
+	for _, token := range repo.tokens {
+		if !token.used {
+			live++
+		}
+	}
+	assert.Equal(t, 1, live)
+}
+
+func TestForgotPasswordSendsInBackground(t *testing.T) {
+	uc, repo := newTestPasswordResetUseCase(t)
+	ctx := context.Background()
+
+	// Known or not, the answer is the same
+	require.NoError(t, uc.ForgotPassword(ctx, "nobody@example.com", "203.0.113.7"))
+	require.NoError(t, uc.ForgotPassword(ctx, "jane@example.com", "203.0.113.7"))
+	assert.Eventually(t, func() bool { return repo.tokenCount() == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestForgotPasswordLimitsAddress(t *testing.T) {
+	uc, _ := newTestPasswordResetUseCase(t)
+	ctx := context.Background()
+
+	// Different emails from one address add up
+	for i := 0; i < maxIpResetRequests; i++ {
+		require.NoError(t, uc.ForgotPassword(ctx, fmt.Sprintf("user%d@example.com", i), "203.0.113.7"))
+	}
+
+	var tooMany *domain.TooManyAttemptsError
+	err := uc.ForgotPassword(ctx, "jane@example.com", "203.0.113.7")
+	require.ErrorAs(t, err, &tooMany)
+	assert.Greater(t, tooMany.RetryAfter, time.Duration(0))
+
+	// Other addresses are not held up
+	assert.NoError(t, uc.ForgotPassword(ctx, "jane@example.com", "198.51.100.1"))
+}
@@ -3,22 +3,42 @@ package usecase
 import (
 	"context"
 	"errors"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/internal/marketdata"
 	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	log "github.com/sirupsen/logrus"
 )
 
 type postUseCase struct {
-	postRepository     repository.PostRepository
-	userRepository     repository.UserRepository
-	likeRepository     repository.LikeRepository
-	commentRepository  repository.CommentRepository
-	viewsRedisRepo     repository.PostViewsRedisRepository
-	viewsDBRepo        repository.PostViewsDBRepository
-	contextTimeout     time.Duration
+	postRepository      repository.PostRepository
+	userRepository      repository.UserRepository
+	likeRepository      repository.LikeRepository
+	commentRepository   repository.CommentRepository
+	viewsRedisRepo      repository.PostViewsRedisRepository
+	viewsDBRepo         repository.PostViewsDBRepository
+	feedEventRepo       repository.FeedEventRepository
+	timelineUseCase     domain.TimelineUseCase
+	tickerUseCase       domain.TickerUseCase
+	predictionRepo      repository.PredictionRepository
+	marketData          marketdata.Provider
+	notificationUseCase domain.NotificationUseCase
+	contextTimeout      time.Duration
 }
 
+const (
+	// Furthest a prediction may expire from when it is made
+	maxPredictionHorizon = 365 * 24 * time.Hour
+	// How long posting waits for a quote before storing the post without a price
+	quoteTimeout = 2 * time.Second
+	// How long a page of posts waits for current prices before showing no change
+	pageQuotesTimeout = 500 * time.Millisecond
+)
+
 func NewPostUseCase(
 	postRepo repository.PostRepository,
 	userRepo repository.UserRepository,
@@ -26,83 +46,117 @@ func NewPostUseCase(
 	commentRepo repository.CommentRepository,
 	viewsRedisRepo repository.PostViewsRedisRepository,
 	viewsDBRepo repository.PostViewsDBRepository,
+	feedEventRepo repository.FeedEventRepository,
+	timelineUseCase domain.TimelineUseCase,
+	tickerUseCase domain.TickerUseCase,
+	predictionRepo repository.PredictionRepository,
+	marketData marketdata.Provider,
+	notificationUseCase domain.NotificationUseCase,
 	timeout time.Duration,
 ) domain.PostUseCase {
 	return &postUseCase{
-		postRepository:     postRepo,
-		userRepository:     userRepo,
-		likeRepository:     likeRepo,
-		commentRepository:  commentRepo,
-		viewsRedisRepo:     viewsRedisRepo,
-		viewsDBRepo:        viewsDBRepo,
-		contextTimeout:     timeout,
+		postRepository:      postRepo,
+		userRepository:      userRepo,
+		likeRepository:      likeRepo,
+		commentRepository:   commentRepo,
+		viewsRedisRepo:      viewsRedisRepo,
+		viewsDBRepo:         viewsDBRepo,
+		feedEventRepo:       feedEventRepo,
+		timelineUseCase:     timelineUseCase,
+		tickerUseCase:       tickerUseCase,
+		predictionRepo:      predictionRepo,
+		marketData:          marketData,
+		notificationUseCase: notificationUseCase,
+		contextTimeout:      timeout,
 	}
 }
 
-func (uc *postUseCase) GetGlobalFeed(ctx context.Context, userId, limit, offset int) (*domain.PaginatedResponse, error) {
+func (uc *postUseCase) GetGlobalFeed(ctx context.Context, userId int, page domain.PaginationParams) (*domain.PaginatedResponse, error) {
 	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
 	defer cancel()
 
-	posts, err := uc.postRepository.GetPosts(ctx, userId, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-
-	total, err := uc.postRepository.GetPostsCount(ctx)
+	posts, err := uc.postRepository.GetPosts(ctx, userId, fetchPage(page))
 	if err != nil {
 		return nil, err
 	}
+	posts, next := trimPage(posts, page.Limit, postCursor)
 
 	responses, err := uc.enrichPosts(ctx, posts, userId)
 	if err != nil {
 		return nil, err
 	}
 
-	return domain.NewPaginatedResponse(responses, total, limit, offset), nil
+	return newPageResponse(responses, page, next, func() (int, error) {
+		return uc.postRepository.GetPostsCount(ctx)
+	})
 }
 
-func (uc *postUseCase) GetFollowingFeed(ctx context.Context, userId, limit, offset int) (*domain.PaginatedResponse, error) {
+func (uc *postUseCase) GetFollowingFeed(ctx context.Context, userId int, page domain.PaginationParams) (*domain.PaginatedResponse, error) {
 	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
 	defer cancel()
 
-	posts, err := uc.postRepository.GetFollowingPosts(ctx, userId, limit, offset)
-	if err != nil {
-		return nil, err
+	var posts []*domain.Post
+	var err error
+	if page.Cursor == nil && page.Offset > 0 {
+		// Offset clients page through the database, the timeline is keyset only
+		posts, err = uc.postRepository.GetFollowingPosts(ctx, userId, fetchPage(page))
+	} else {
+		posts, err = uc.timelineUseCase.GetTimeline(ctx, userId, fetchPage(page))
 	}
-
-	total, err := uc.postRepository.GetFollowingPostsCount(ctx, userId)
 	if err != nil {
 		return nil, err
 	}
+	posts, next := trimPage(posts, page.Limit, postCursor)
 
 	responses, err := uc.enrichPosts(ctx, posts, userId)
 	if err != nil {
 		return nil, err
 	}
 
-	return domain.NewPaginatedResponse(responses, total, limit, offset), nil
+	return newPageResponse(responses, page, next, func() (int, error) {
+		return uc.postRepository.GetFollowingPostsCount(ctx, userId)
+	})
 }
 
-func (uc *postUseCase) GetUserPosts(ctx context.Context, currentUserId, targetUserId, limit, offset int) (*domain.PaginatedResponse, error) {
+func (uc *postUseCase) GetUserPosts(ctx context.Context, currentUserId, targetUserId int, page domain.PaginationParams) (*domain.PaginatedResponse, error) {
 	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
 	defer cancel()
 
-	posts, err := uc.postRepository.GetPostsByUserId(ctx, targetUserId, limit, offset)
+	posts, err := uc.postRepository.GetPostsByUserId(ctx, targetUserId, fetchPage(page))
 	if err != nil {
 		return nil, err
 	}
+	posts, next := trimPage(posts, page.Limit, postCursor)
 
-	total, err := uc.postRepository.GetUserPostsCount(ctx, targetUserId)
+	responses, err := uc.enrichPosts(ctx, posts, currentUserId)
 	if err != nil {
 		return nil, err
 	}
 
-	responses, err := uc.enrichPosts(ctx, posts, currentUserId)
+	return newPageResponse(responses, page, next, func() (int, error) {
+		return uc.postRepository.GetUserPostsCount(ctx, targetUserId)
+	})
+}
+
+func (uc *postUseCase) GetTickerPosts(ctx context.Context, userId int, symbol string, page domain.PaginationParams) (*domain.PaginatedResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	symbol = normalizeTicker(symbol)
+	posts, err := uc.postRepository.GetPostsByTickers(ctx, []string{symbol}, fetchPage(page))
+	if err != nil {
+		return nil, err
+	}
+	posts, next := trimPage(posts, page.Limit, postCursor)
+
+	responses, err := uc.enrichPosts(ctx, posts, userId)
 	if err != nil {
 		return nil, err
 	}
 
-	return domain.NewPaginatedResponse(responses, total, limit, offset), nil
+	return newPageResponse(responses, page, next, func() (int, error) {
+		return uc.postRepository.GetTickerPostsCount(ctx, symbol)
+	})
 }
 
 func (uc *postUseCase) GetPostById(ctx context.Context, userId, postId int) (*domain.PostResponse, error) {
@@ -117,29 +171,200 @@ func (uc *postUseCase) GetPostById(ctx context.Context, userId, postId int) (*do
 	return uc.enrichPost(ctx, post, userId)
 }
 
-func (uc *postUseCase) CreatePost(ctx context.Context, userId int, request *domain.CreatePostRequest) (*domain.PostResponse, error) {
+// GetPostsByIds returns the posts that still exist among the given ids, in the order of the ids
+func (uc *postUseCase) GetPostsByIds(ctx context.Context, userId int, postIds []int) ([]*domain.PostResponse, error) {
 	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
 	defer cancel()
 
-	if request.Ticker == "" {
-		return nil, errors.New("ticker is required")
+	found, err := uc.postRepository.GetPostsByIds(ctx, postIds)
+	if err != nil {
+		return nil, err
+	}
+
+	byId := make(map[int]*domain.Post, len(found))
+	for _, post := range found {
+		byId[post.Id] = post
+	}
+	posts := make([]*domain.Post, 0, len(found))
+	for _, postId := range postIds {
+		if post, ok := byId[postId]; ok {
+			posts = append(posts, post)
+		}
 	}
+
+	return uc.enrichPosts(ctx, posts, userId)
+}
+
+func (uc *postUseCase) CreatePost(ctx context.Context, userId int, request *domain.CreatePostRequest) (*domain.PostResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
 	if request.Body == "" {
 		return nil, errors.New("body is required")
 	}
 
+	ticker, err := uc.tickerUseCase.Resolve(ctx, request.Ticker)
+	if err != nil {
+		return nil, err
+	}
+
 	post := &domain.Post{
 		UserId: userId,
-		Ticker: request.Ticker,
+		Ticker: ticker.Symbol,
 		Body:   request.Body,
 	}
+	if request.Sentiment != "" {
+		sentiment := strings.ToLower(strings.TrimSpace(request.Sentiment))
+		if !isSentiment(sentiment) {
+			return nil, domain.ErrInvalidSentiment
+		}
+		post.Sentiment = &sentiment
+	}
 
-	createdPost, err := uc.postRepository.CreatePost(ctx, post)
+	entities, err := resolveEntities(ctx, uc.tickerUseCase, uc.userRepository, request.Body)
 	if err != nil {
 		return nil, err
 	}
+	post.Entities = entities
+	post.Tickers = postTickers(ticker.Symbol, entities)
+	post.PriceAtPost = uc.getPriceAtPost(ctx, ticker.Symbol)
 
-	return uc.enrichPost(ctx, createdPost, userId)
+	var createdPost *domain.Post
+	if request.Prediction != nil {
+		prediction, err := newPrediction(request.Prediction, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		createdPost, err = uc.postRepository.CreatePostWithPrediction(ctx, post, prediction)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		createdPost, err = uc.postRepository.CreatePost(ctx, post)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	response, err := uc.enrichPost(ctx, createdPost, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fan out without holding up the response. If it fails, the post is missing
+	// from the followers' cached timelines until they are rebuilt.
+	go func() {
+		if err := uc.timelineUseCase.FanOutPost(context.Background(), createdPost); err != nil {
+			log.Warnf("Failed to fan out post %d: %v", createdPost.Id, err)
+		}
+	}()
+
+	// is_liked is viewer specific and always false for a brand new post
+	publishFeedEvent(ctx, uc.feedEventRepo, &domain.FeedEvent{
+		Type:         domain.FeedEventPostCreated,
+		PostId:       createdPost.Id,
+		Ticker:       createdPost.Ticker,
+		Tickers:      createdPost.Tickers,
+		PostAuthorId: createdPost.UserId,
+		Post:         response,
+	})
+
+	notifyAsync(uc.notificationUseCase, mentionNotifications(userId, createdPost.Id, nil, post.Entities)...)
+
+	return response, nil
+}
+
+func (uc *postUseCase) UpdatePost(ctx context.Context, userId, postId int, request *domain.UpdatePostRequest) (*domain.PostResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	if request.Body == "" {
+		return nil, errors.New("body is required")
+	}
+
+	post, err := uc.postRepository.GetPostById(ctx, postId)
+	if err != nil {
+		return nil, err
+	}
+	if post.UserId != userId {
+		return nil, errors.New("you can only edit your own posts")
+	}
+
+	sentiment := post.Sentiment
+	if request.Sentiment != nil {
+		sentiment = nil
+		if value := strings.ToLower(strings.TrimSpace(*request.Sentiment)); value != "" {
+			if !isSentiment(value) {
+				return nil, domain.ErrInvalidSentiment
+			}
+			sentiment = &value
+		}
+	}
+	if request.Body == post.Body && equalStrings(sentiment, post.Sentiment) {
+		return uc.enrichPost(ctx, post, userId)
+	}
+
+	previous, err := uc.postRepository.GetPostEntities(ctx, []int{post.Id})
+	if err != nil {
+		return nil, err
+	}
+	entities, err := resolveEntities(ctx, uc.tickerUseCase, uc.userRepository, request.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	edited := *post
+	edited.Body = request.Body
+	edited.Sentiment = sentiment
+	edited.Entities = entities
+	edited.Tickers = postTickers(post.Ticker, entities)
+
+	updatedPost, err := uc.postRepository.UpdatePost(ctx, &edited)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := uc.enrichPost(ctx, updatedPost, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Timelines already holding the post are left as they are, it only joins
+	// the ones of the tickers it now mentions. Swapping one ticker for another
+	// adds one too.
+	if len(addedTickers(postListedTickers(post), postListedTickers(updatedPost))) > 0 {
+		go func() {
+			if err := uc.timelineUseCase.FanOutPost(context.Background(), updatedPost); err != nil {
+				log.Warnf("Failed to fan out edited post %d: %v", updatedPost.Id, err)
+			}
+		}()
+	}
+
+	// is_liked is viewer specific, subscribers keep their own
+	publishFeedEvent(ctx, uc.feedEventRepo, &domain.FeedEvent{
+		Type:         domain.FeedEventPostEdited,
+		PostId:       updatedPost.Id,
+		Ticker:       updatedPost.Ticker,
+		Tickers:      updatedPost.Tickers,
+		PostAuthorId: updatedPost.UserId,
+		Post:         response,
+	})
+
+	// Only the users the edit newly mentions hear of it
+	notifyAsync(uc.notificationUseCase,
+		mentionNotifications(userId, updatedPost.Id, nil, entities, mentionedUserIds(previous[post.Id])...)...)
+
+	return response, nil
+}
+
+func (uc *postUseCase) GetPostRevisions(ctx context.Context, postId int) ([]*domain.PostRevision, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	if _, err := uc.postRepository.GetPostById(ctx, postId); err != nil {
+		return nil, err
+	}
+	return uc.postRepository.GetPostRevisions(ctx, postId)
 }
 
 func (uc *postUseCase) DeletePost(ctx context.Context, userId, postId int) error {
@@ -155,60 +380,312 @@ func (uc *postUseCase) DeletePost(ctx context.Context, userId, postId int) error
 		return errors.New("you can only delete your own posts")
 	}
 
-	return uc.postRepository.DeletePost(ctx, postId)
+	if err := uc.postRepository.DeletePost(ctx, postId); err != nil {
+		return err
+	}
+
+	go func() {
+		if err := uc.timelineUseCase.RemovePost(context.Background(), post); err != nil {
+			log.Warnf("Failed to remove post %d from timelines: %v", post.Id, err)
+		}
+	}()
+
+	publishFeedEvent(ctx, uc.feedEventRepo, &domain.FeedEvent{
+		Type:         domain.FeedEventPostDeleted,
+		PostId:       post.Id,
+		Ticker:       post.Ticker,
+		Tickers:      post.Tickers,
+		PostAuthorId: post.UserId,
+	})
+
+	return nil
 }
 
+// enrichPosts hydrates a page of posts with authors, counters, the viewer's
+// like flags and view counts and the change since each post's price. It runs
+// a fixed number of queries and one batch of quotes regardless of the page size.
 func (uc *postUseCase) enrichPosts(ctx context.Context, posts []*domain.Post, userId int) ([]*domain.PostResponse, error) {
+	if len(posts) == 0 {
+		return []*domain.PostResponse{}, nil
+	}
+
+	postIds := make([]int, 0, len(posts))
+	authorIds := make([]int, 0, len(posts))
+	for _, post := range posts {
+		postIds = append(postIds, post.Id)
+		authorIds = append(authorIds, post.UserId)
+	}
+
+	users, err := uc.userRepository.GetUsersByIds(ctx, authorIds)
+	if err != nil {
+		return nil, err
+	}
+
+	likesCounts, err := uc.likeRepository.GetLikesCounts(ctx, postIds)
+	if err != nil {
+		return nil, err
+	}
+
+	commentsCounts, err := uc.commentRepository.GetCommentsCounts(ctx, postIds)
+	if err != nil {
+		return nil, err
+	}
+
+	liked, err := uc.likeRepository.GetLikedPostIds(ctx, userId, postIds)
+	if err != nil {
+		return nil, err
+	}
+
+	viewsCounts, err := uc.getViewCounts(ctx, postIds)
+	if err != nil {
+		return nil, err
+	}
+
+	predictions, err := uc.predictionRepo.GetPredictionsByPostIds(ctx, postIds)
+	if err != nil {
+		return nil, err
+	}
+
+	entities, err := uc.postRepository.GetPostEntities(ctx, postIds)
+	if err != nil {
+		return nil, err
+	}
+
+	prices := uc.getCurrentPrices(ctx, posts)
+
 	responses := make([]*domain.PostResponse, 0, len(posts))
 	for _, post := range posts {
-		response, err := uc.enrichPost(ctx, post, userId)
-		if err != nil {
-			return nil, err
+		user, ok := users[post.UserId]
+		if !ok {
+			return nil, domain.ErrUserNotFound
 		}
-		responses = append(responses, response)
+
+		responses = append(responses, &domain.PostResponse{
+			Id:              post.Id,
+			Ticker:          post.Ticker,
+			Body:            post.Body,
+			Sentiment:       post.Sentiment,
+			PriceAtPost:     post.PriceAtPost,
+			ChangeSincePost: changeSincePost(post.PriceAtPost, prices[post.Ticker]),
+			CreatedAt:       post.CreatedAt,
+			EditedAt:        post.UpdatedAt,
+			Author:          domain.Author{Id: user.Id, Name: user.Name, AvatarEmoji: user.AvatarEmoji},
+			LikesCount:      likesCounts[post.Id],
+			CommentsCount:   commentsCounts[post.Id],
+			ViewsCount:      viewsCounts[post.Id],
+			IsLiked:         liked[post.Id],
+			Entities:        entityList(entities[post.Id]),
+			Prediction:      newPredictionResponse(predictions[post.Id]),
+		})
 	}
 	return responses, nil
 }
 
-func (uc *postUseCase) enrichPost(ctx context.Context, post *domain.Post, userId int) (*domain.PostResponse, error) {
-	user, err := uc.userRepository.GetUserById(ctx, post.UserId)
+// getPriceAtPost quotes a ticker for a new post. Posting doesn't depend on
+// market data, without a quote the post is stored without a price.
+func (uc *postUseCase) getPriceAtPost(ctx context.Context, symbol string) *float64 {
+	ctx, cancel := context.WithTimeout(ctx, quoteTimeout)
+	defer cancel()
+
+	quote, err := uc.marketData.Quote(ctx, symbol)
 	if err != nil {
-		return nil, err
+		if !errors.Is(err, domain.ErrNoPrice) {
+			log.Warnf("Failed to quote %s: %v", symbol, err)
+		}
+		return nil
+	}
+	return &quote.Price
+}
+
+// getCurrentPrices quotes the tickers of the posts that have a price in one
+// batch, waiting at most pageQuotesTimeout. Tickers without a quote are left
+// out, their posts show no change.
+func (uc *postUseCase) getCurrentPrices(ctx context.Context, posts []*domain.Post) map[string]float64 {
+	prices := make(map[string]float64)
+	symbols := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, post := range posts {
+		if post.PriceAtPost != nil && !seen[post.Ticker] {
+			seen[post.Ticker] = true
+			symbols = append(symbols, post.Ticker)
+		}
+	}
+	if len(symbols) == 0 {
+		return prices
 	}
 
-	likesCount, err := uc.likeRepository.GetLikesCount(ctx, post.Id)
+	ctx, cancel := context.WithTimeout(ctx, pageQuotesTimeout)
+	defer cancel()
+
+	quotes, err := uc.marketData.Quotes(ctx, symbols)
 	if err != nil {
-		return nil, err
+		log.Warnf("Failed to quote %d tickers: %v", len(symbols), err)
+		return prices
+	}
+	for _, symbol := range symbols {
+		if quote, ok := quotes[strings.ToUpper(symbol)]; ok {
+			prices[symbol] = quote.Price
+		}
+	}
+	return prices
+}
+
+// changeSincePost is the percent the price moved since the post, rounded to
+// hundredths, or nil when either price is unknown
+func changeSincePost(priceAtPost *float64, price float64) *float64 {
+	if priceAtPost == nil || *priceAtPost <= 0 || price <= 0 {
+		return nil
 	}
+	change := math.Round((price-*priceAtPost) / *priceAtPost * 10000) / 100
+	return &change
+}
 
-	commentsCount, err := uc.commentRepository.GetCommentsCount(ctx, post.Id)
+// getViewCounts merges the totals already flushed to PostgreSQL with the
+// increments still waiting in Redis for the next worker sync
+func (uc *postUseCase) getViewCounts(ctx context.Context, postIds []int) (map[int]int64, error) {
+	viewCounts, err := uc.viewsDBRepo.GetMultipleViewCounts(ctx, postIds)
 	if err != nil {
 		return nil, err
 	}
 
-	isLiked, err := uc.likeRepository.IsLiked(ctx, userId, post.Id)
+	// Pending views are a small correction, serve the flushed totals if Redis is unavailable
+	pending, err := uc.viewsRedisRepo.GetViewCounts(ctx, postIds)
+	if err != nil {
+		log.Warnf("Failed to get pending view counts from Redis: %v", err)
+		return viewCounts, nil
+	}
+
+	for postId, count := range pending {
+		viewCounts[postId] += count
+	}
+	return viewCounts, nil
+}
+
+func (uc *postUseCase) enrichPost(ctx context.Context, post *domain.Post, userId int) (*domain.PostResponse, error) {
+	responses, err := uc.enrichPosts(ctx, []*domain.Post{post}, userId)
 	if err != nil {
 		return nil, err
 	}
+	return responses[0], nil
+}
 
-	return &domain.PostResponse{
-		Id:            post.Id,
-		Ticker:        post.Ticker,
-		Body:          post.Body,
-		CreatedAt:     post.CreatedAt,
-		Author:        domain.Author{Id: user.Id, Name: user.Name, AvatarEmoji: user.AvatarEmoji},
-		LikesCount:    likesCount,
-		CommentsCount: commentsCount,
-		IsLiked:       isLiked,
-	}, nil
+// TrackBatchViews tracks view events for multiple posts in Redis. A user is
+// counted once per post per day however often they scroll past it.
+func (uc *postUseCase) TrackBatchViews(ctx context.Context, userId int, postIds []int) error {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	// Track views in Redis (fast, asynchronous)
+	_, err := uc.viewsRedisRepo.TrackViews(ctx, userId, postIds)
+	return err
 }
 
-// TrackBatchViews tracks view events for multiple posts in Redis
-func (uc *postUseCase) TrackBatchViews(ctx context.Context, postIds []int) error {
+// GetPostViewsHistory returns the daily views of a post over the last days,
+// including today, with days without views filled in as zero
+func (uc *postUseCase) GetPostViewsHistory(ctx context.Context, userId, postId, days int) ([]*domain.PostViewsDay, error) {
 	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
 	defer cancel()
 
-	// Increment views in Redis (fast, asynchronous)
-	return uc.viewsRedisRepo.IncrementViews(ctx, postIds)
+	post, err := uc.postRepository.GetPostById(ctx, postId)
+	if err != nil {
+		return nil, err
+	}
+
+	if post.UserId != userId {
+		return nil, errors.New("you can only view the stats of your own posts")
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from := today.AddDate(0, 0, -(days - 1))
+
+	stored, err := uc.viewsDBRepo.GetDailyViews(ctx, postId, from)
+	if err != nil {
+		return nil, err
+	}
+
+	byDate := make(map[string]*domain.PostViewsDay, len(stored))
+	for _, day := range stored {
+		byDate[day.Date] = day
+	}
+
+	// Today and yesterday may still have views the worker has not synced yet
+	recentDates := []string{today.Format("2006-01-02")}
+	if days > 1 {
+		recentDates = append(recentDates, today.AddDate(0, 0, -1).Format("2006-01-02"))
+	}
+	live, err := uc.viewsRedisRepo.GetDailyViews(ctx, postId, recentDates)
+	if err != nil {
+		log.Warnf("Failed to get live daily views from Redis: %v", err)
+	}
+	for date, day := range live {
+		if existing, ok := byDate[date]; ok {
+			day.UniqueViewers = max(day.UniqueViewers, existing.UniqueViewers)
+			day.Impressions = max(day.Impressions, existing.Impressions)
+		}
+		byDate[date] = day
+	}
+
+	history := make([]*domain.PostViewsDay, 0, days)
+	for d := from; !d.After(today); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		if day, ok := byDate[date]; ok {
+			history = append(history, day)
+			continue
+		}
+		history = append(history, &domain.PostViewsDay{PostId: postId, Date: date})
+	}
+	return history, nil
 }
 
+func equalStrings(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func isSentiment(sentiment string) bool {
+	switch sentiment {
+	case domain.SentimentBullish, domain.SentimentBearish, domain.SentimentNeutral:
+		return true
+	}
+	return false
+}
+
+// newPrediction validates a prediction request made at the given time
+func newPrediction(request *domain.PredictionRequest, now time.Time) (*domain.Prediction, error) {
+	direction := strings.ToLower(strings.TrimSpace(request.Direction))
+	if direction != domain.PredictionUp && direction != domain.PredictionDown {
+		return nil, errors.New("prediction direction must be up or down")
+	}
+	if request.TargetPrice <= 0 {
+		return nil, errors.New("prediction target price must be positive")
+	}
+	if !request.ExpiresAt.After(now) {
+		return nil, errors.New("prediction expiry must be in the future")
+	}
+	if request.ExpiresAt.After(now.Add(maxPredictionHorizon)) {
+		return nil, errors.New("prediction expiry must be within a year")
+	}
+
+	return &domain.Prediction{
+		Direction:   direction,
+		TargetPrice: request.TargetPrice,
+		ExpiresAt:   request.ExpiresAt.UTC(),
+		Status:      domain.PredictionPending,
+	}, nil
+}
+
+func newPredictionResponse(prediction *domain.Prediction) *domain.PredictionResponse {
+	if prediction == nil {
+		return nil
+	}
+	return &domain.PredictionResponse{
+		Direction:     prediction.Direction,
+		TargetPrice:   prediction.TargetPrice,
+		ExpiresAt:     prediction.ExpiresAt,
+		Status:        prediction.Status,
+		ResolvedPrice: prediction.ResolvedPrice,
+		ResolvedAt:    prediction.ResolvedAt,
+	}
+}
@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/internal/marketdata"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+	"github.com/Pro100-Almaz/trading-chat/utils"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
+)
+
+// These benchmarks need a throwaway PostgreSQL database, for example:
+//
+//	BENCH_DB_DSN="host=localhost port=5433 user=postgres password=postgres dbname=trading_chat_bench sslmode=disable" \
+//	go test ./usecase -run '^$' -bench EnrichPosts
+//
+// The schema is migrated and seeded on start and the seeded rows are removed afterwards.
+
+const (
+	benchUsers           = 50
+	benchPosts           = 500
+	benchLikesPerPost    = 20
+	benchCommentsPerPost = 5
+	benchPageSize        = 50
+)
+
+func setupEnrichBenchmark(b *testing.B) (*postUseCase, []*domain.Post, int) {
+	dsn := os.Getenv("BENCH_DB_DSN")
+	if dsn == "" {
+		b.Skip("BENCH_DB_DSN is not set")
+	}
+
+	db, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	utils.MigrateDB(db)
+
+	ctx := context.Background()
+	prefix := fmt.Sprintf("bench-%d-", time.Now().UnixNano())
+	b.Cleanup(func() {
+		db.MustExec(`DELETE FROM users WHERE email LIKE $1`, prefix+"%")
+		db.Close()
+	})
+
+	userIds := make([]int, 0, benchUsers)
+	for i := 0; i < benchUsers; i++ {
+		var id int
+		err := db.QueryRowContext(ctx,
+			`INSERT INTO users (email, name) VALUES ($1, $2) RETURNING id`,
+			fmt.Sprintf("%s%d@example.com", prefix, i), fmt.Sprintf("Bench %d", i)).Scan(&id)
+		if err != nil {
+			b.Fatal(err)
+		}
+		userIds = append(userIds, id)
+	}
+
+	for i := 0; i < benchPosts; i++ {
+		var postId int
+		err := db.QueryRowContext(ctx,
+			`INSERT INTO posts (user_id, ticker, body) VALUES ($1, 'BENCH', 'benchmark post') RETURNING id`,
+			userIds[i%benchUsers]).Scan(&postId)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for j := 0; j < benchLikesPerPost; j++ {
+			db.MustExec(`INSERT INTO likes (user_id, post_id) VALUES ($1, $2)`, userIds[(i+j)%benchUsers], postId)
+		}
+		for j := 0; j < benchCommentsPerPost; j++ {
+			db.MustExec(`INSERT INTO comments (user_id, post_id, body) VALUES ($1, $2, 'benchmark comment')`, userIds[(i+j)%benchUsers], postId)
+		}
+	}
+
+	// Redis only backs the counters and events around a page, an in-process one is enough
+	redisServer := miniredis.RunT(b)
+	redisClient := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	b.Cleanup(func() { redisClient.Close() })
+
+	// Built like the router builds it, so a new dependency can't be left out here
+	uc := NewPostUseCase(
+		repository.NewPostRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewLikeRepository(db),
+		repository.NewCommentRepository(db),
+		repository.NewPostViewsRedisRepository(redisClient),
+		repository.NewPostViewsDBRepository(db),
+		repository.NewFeedEventRepository(redisClient),
+		NewTimelineUseCase(repository.NewTimelineRepository(redisClient), repository.NewPostRepository(db), repository.NewFollowerRepository(db), repository.NewTickerFollowerRepository(db), 10*time.Second),
+		NewTickerUseCase(repository.NewTickerRepository(db), repository.NewTickerFollowerRepository(db), 10*time.Second),
+		repository.NewPredictionRepository(db),
+		marketdata.NewCachedProvider(marketdata.NewMemoryProvider(), redisClient),
+		NewNotificationUseCase(repository.NewNotificationRepository(db), repository.NewUserRepository(db), 10*time.Second),
+		10*time.Second,
+	).(*postUseCase)
+
+	viewerId := userIds[0]
+	page, err := uc.postRepository.GetPosts(ctx, viewerId, domain.PaginationParams{Limit: benchPageSize})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return uc, page, viewerId
+}
+
+// BenchmarkEnrichPostsPerPost hydrates the page one post at a time, which is
+// what the feed did before batching: four queries per post.
+func BenchmarkEnrichPostsPerPost(b *testing.B) {
+	uc, page, viewerId := setupEnrichBenchmark(b)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, post := range page {
+			if _, err := uc.enrichPosts(ctx, []*domain.Post{post}, viewerId); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkEnrichPostsBatched hydrates the whole page in four queries
+func BenchmarkEnrichPostsBatched(b *testing.B) {
+	uc, page, viewerId := setupEnrichBenchmark(b)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := uc.enrichPosts(ctx, page, viewerId); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/internal/marketdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPrediction(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+
+	prediction, err := newPrediction(&domain.PredictionRequest{
+		Direction:   " UP ",
+		TargetPrice: 200,
+		ExpiresAt:   now.Add(7 * 24 * time.Hour),
+	}, now)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PredictionUp, prediction.Direction)
+	assert.Equal(t, domain.PredictionPending, prediction.Status)
+
+	invalid := map[string]*domain.PredictionRequest{
+		"direction":   {Direction: "sideways", TargetPrice: 200, ExpiresAt: now.Add(time.Hour)},
+		"target":      {Direction: "down", TargetPrice: 0, ExpiresAt: now.Add(time.Hour)},
+		"past expiry": {Direction: "down", TargetPrice: 200, ExpiresAt: now},
+		"too far":     {Direction: "down", TargetPrice: 200, ExpiresAt: now.Add(2 * maxPredictionHorizon)},
+	}
+	for name, request := range invalid {
+		_, err := newPrediction(request, now)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestChangeSincePost(t *testing.T) {
+	priceAtPost := 200.0
+
+	change := changeSincePost(&priceAtPost, 213.5)
+	require.NotNil(t, change)
+	assert.Equal(t, 6.75, *change)
+
+	change = changeSincePost(&priceAtPost, 150)
+	require.NotNil(t, change)
+	assert.Equal(t, -25.0, *change)
+
+	assert.Nil(t, changeSincePost(nil, 213.5))
+	assert.Nil(t, changeSincePost(&priceAtPost, 0))
+}
+
+// batchQuoteProvider counts the batches of quotes asked of it, and with hang
+// set waits for the caller to give up instead of answering
+type batchQuoteProvider struct {
+	marketdata.Provider
+	batches int
+	hang    bool
+}
+
+func (p *batchQuoteProvider) Quotes(ctx context.Context, symbols []string) (map[string]*marketdata.Quote, error) {
+	p.batches++
+	if p.hang {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return p.Provider.Quotes(ctx, symbols)
+}
+
+func TestGetCurrentPricesQuotesOnce(t *testing.T) {
+	memory := marketdata.NewMemoryProvider()
+	memory.AddPrice("AAPL", time.Now().Add(-time.Minute), 200)
+	memory.AddPrice("TSLA", time.Now().Add(-time.Minute), 250)
+	provider := &batchQuoteProvider{Provider: memory}
+	uc := &postUseCase{marketData: provider}
+
+	price := 180.0
+	posts := []*domain.Post{
+		{Id: 1, Ticker: "AAPL", PriceAtPost: &price},
+		{Id: 2, Ticker: "TSLA", PriceAtPost: &price},
+		{Id: 3, Ticker: "AAPL", PriceAtPost: &price},
+		{Id: 4, Ticker: "MSFT", PriceAtPost: &price},
+		// Posted without a price, so never quoted
+		{Id: 5, Ticker: "NVDA"},
+	}
+
+	prices := uc.getCurrentPrices(context.Background(), posts)
+	assert.Equal(t, map[string]float64{"AAPL": 200, "TSLA": 250}, prices)
+	assert.Equal(t, 1, provider.batches)
+}
+
+func TestGetCurrentPricesGivesUpOnSlowQuotes(t *testing.T) {
+	provider := &batchQuoteProvider{hang: true}
+	uc := &postUseCase{marketData: provider}
+
+	price := 180.0
+	start := time.Now()
+	prices := uc.getCurrentPrices(context.Background(), []*domain.Post{{Id: 1, Ticker: "AAPL", PriceAtPost: &price}})
+	assert.Empty(t, prices)
+	assert.Less(t, time.Since(start), 2*pageQuotesTimeout)
+}
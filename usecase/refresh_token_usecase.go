@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/Pro100-Almaz/trading-chat/bootstrap"
@@ -13,43 +15,84 @@ import (
 )
 
 type refreshTokenUseCase struct {
-	userRepository repository.UserRepository
-	contextTimeout time.Duration
+	userRepository    repository.UserRepository
+	sessionRepository repository.SessionRepository
+	contextTimeout    time.Duration
 }
 
-func NewRefreshTokenUseCase(userRepository repository.UserRepository, timeout time.Duration) domain.RefreshTokenUseCase {
+func NewRefreshTokenUseCase(userRepository repository.UserRepository, sessionRepository repository.SessionRepository, timeout time.Duration) domain.RefreshTokenUseCase {
 	return &refreshTokenUseCase{
-		userRepository: userRepository,
-		contextTimeout: timeout,
+		userRepository:    userRepository,
+		sessionRepository: sessionRepository,
+		contextTimeout:    timeout,
 	}
 }
 
-func (rtu *refreshTokenUseCase) RefreshToken(ctx context.Context, request domain.RefreshTokenRequest, env *bootstrap.Env) (accessToken string, refreshToken string, err error) {
-	var id int
-	id, err = tokenutil.ExtractIDFromToken(request.RefreshToken, env.RefreshTokenSecret)
+// RefreshToken redeems the latest refresh token of a session for a new pair,
+// rotating it. An earlier token of the session, one that was already redeemed,
+// revokes the session for good.
+func (rtu *refreshTokenUseCase) RefreshToken(ctx context.Context, request domain.RefreshTokenRequest, client domain.SessionClient, env *bootstrap.Env) (accessToken string, refreshToken string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, rtu.contextTimeout)
+	defer cancel()
+
+	claims, err := tokenutil.ParseRefreshToken(request.RefreshToken, env.RefreshTokenSecret)
 	if err != nil {
 		log.Error(err)
-		return
+		return "", "", err
+	}
+	if claims.FamilyId == "" || claims.RegisteredClaims.ID == "" {
+		return "", "", domain.ErrLegacyRefreshToken
 	}
 
-	var user *domain.User
-	user, err = rtu.userRepository.GetUserById(ctx, id)
+	session, err := rtu.sessionRepository.GetSessionByFamilyId(ctx, claims.FamilyId)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", domain.ErrSessionNotFound
+		}
 		log.Error(err)
-		return
+		return "", "", err
+	}
+	if session.UserId != claims.ID {
+		return "", "", domain.ErrInvalidToken
+	}
+	if session.RevokedAt != nil {
+		return "", "", domain.ErrSessionRevoked
 	}
 
-	accessToken, err = tokenutil.CreateAccessToken(user, env.AccessTokenSecret, env.AccessTokenExpiryHour)
+	nextTokenId, err := newTokenId()
 	if err != nil {
-		log.Error(err)
-		return
+		return "", "", err
+	}
+	next := clientSession(&domain.Session{
+		FamilyId:  session.FamilyId,
+		TokenId:   nextTokenId,
+		ExpiresAt: refreshTokenExpiry(env),
+	}, client)
+
+	rotated := false
+	if session.TokenId == claims.RegisteredClaims.ID {
+		rotated, err = rtu.sessionRepository.RotateToken(ctx, claims.RegisteredClaims.ID, next)
+		if err != nil {
+			log.Error(err)
+			return "", "", err
+		}
+	}
+	// Either the token was rotated away already or a concurrent refresh just
+	// redeemed it, both mean two parties hold it
+	if !rotated {
+		log.Warnf("Refresh token of session %d of user %d was reused, revoking the session", session.Id, session.UserId)
+		if _, err := rtu.sessionRepository.RevokeSession(ctx, session.UserId, session.FamilyId); err != nil {
+			log.Error(err)
+			return "", "", err
+		}
+		return "", "", domain.ErrRefreshTokenReused
 	}
 
-	refreshToken, err = tokenutil.CreateRefreshToken(user, env.RefreshTokenSecret, env.RefreshTokenExpiryHour)
+	user, err := rtu.userRepository.GetUserById(ctx, session.UserId)
 	if err != nil {
 		log.Error(err)
-		return
+		return "", "", err
 	}
 
-	return accessToken, refreshToken, nil
+	return issueTokens(user, session.FamilyId, nextTokenId, env)
 }
@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTokenEnv = &bootstrap.Env{
+	AccessTokenExpiryHour:  1,
+	RefreshTokenExpiryHour: 24,
+	AccessTokenSecret:      "access-secret",
+	RefreshTokenSecret:     "refresh-secret",
+}
+
+func TestRefreshTokenRotates(t *testing.T) {
+	ctx := context.Background()
+	user := &domain.User{Id: 1, Email: "jane@example.com"}
+	sessions := newFakeSessionRepo()
+	uc := NewRefreshTokenUseCase(newFakeUserRepo(user), sessions, time.Second)
+	client := domain.SessionClient{UserAgent: "okhttp/4.12.0", Ip: "203.0.113.7"}
+
+	_, first, err := startSession(ctx, sessions, user, client, testTokenEnv)
+	require.NoError(t, err)
+
+	_, second, err := uc.RefreshToken(ctx, domain.RefreshTokenRequest{RefreshToken: first}, client, testTokenEnv)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	_, third, err := uc.RefreshToken(ctx, domain.RefreshTokenRequest{RefreshToken: second}, client, testTokenEnv)
+	require.NoError(t, err)
+	assert.NotEqual(t, second, third)
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	ctx := context.Background()
+	user := &domain.User{Id: 1, Email: "jane@example.com"}
+	sessions := newFakeSessionRepo()
+	uc := NewRefreshTokenUseCase(newFakeUserRepo(user), sessions, time.Second)
+	client := domain.SessionClient{Ip: "203.0.113.7"}
+
+	_, stolen, err := startSession(ctx, sessions, user, client, testTokenEnv)
+	require.NoError(t, err)
+	// Another session of the user, which stays live
+	_, other, err := startSession(ctx, sessions, user, client, testTokenEnv)
+	require.NoError(t, err)
+
+	// The session moves on to a new token, then the rotated one shows up again
+	_, latest, err := uc.RefreshToken(ctx, domain.RefreshTokenRequest{RefreshToken: stolen}, client, testTokenEnv)
+	require.NoError(t, err)
+	_, _, err = uc.RefreshToken(ctx, domain.RefreshTokenRequest{RefreshToken: stolen}, client, testTokenEnv)
+	assert.ErrorIs(t, err, domain.ErrRefreshTokenReused)
+
+	// So the latest token of the family, whoever holds it, is no good either
+	_, _, err = uc.RefreshToken(ctx, domain.RefreshTokenRequest{RefreshToken: latest}, client, testTokenEnv)
+	assert.ErrorIs(t, err, domain.ErrSessionRevoked)
+
+	live, err := sessions.GetSessions(ctx, user.Id)
+	require.NoError(t, err)
+	assert.Len(t, live, 1)
+
+	_, _, err = uc.RefreshToken(ctx, domain.RefreshTokenRequest{RefreshToken: other}, client, testTokenEnv)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+)
+
+type sessionUseCase struct {
+	sessionRepository repository.SessionRepository
+	contextTimeout    time.Duration
+}
+
+func NewSessionUseCase(sessionRepository repository.SessionRepository, timeout time.Duration) domain.SessionUseCase {
+	return &sessionUseCase{
+		sessionRepository: sessionRepository,
+		contextTimeout:    timeout,
+	}
+}
+
+func (su *sessionUseCase) GetSessions(ctx context.Context, userId, currentSessionId int) ([]*domain.SessionResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, su.contextTimeout)
+	defer cancel()
+
+	sessions, err := su.sessionRepository.GetSessions(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*domain.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		responses = append(responses, &domain.SessionResponse{
+			Id:         session.Id,
+			Device:     session.Device,
+			UserAgent:  session.UserAgent,
+			Ip:         session.Ip,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.Id == currentSessionId,
+		})
+	}
+	return responses, nil
+}
+
+func (su *sessionUseCase) RevokeSession(ctx context.Context, userId, sessionId int) error {
+	ctx, cancel := context.WithTimeout(ctx, su.contextTimeout)
+	defer cancel()
+
+	revoked, err := su.sessionRepository.RevokeSessionById(ctx, userId, sessionId)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return domain.ErrSessionNotFound
+	}
+	return nil
+}
+
+func (su *sessionUseCase) RevokeOtherSessions(ctx context.Context, userId, currentSessionId int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, su.contextTimeout)
+	defer cancel()
+
+	return su.sessionRepository.RevokeOtherSessions(ctx, userId, currentSessionId)
+}
@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSessions opens sessions a and b for user 1 and c for user 2
+func newTestSessions(t *testing.T) (*fakeSessionRepo, map[string]*domain.Session) {
+	repo := newFakeSessionRepo()
+	sessions := make(map[string]*domain.Session)
+	for familyId, userId := range map[string]int{"a": 1, "b": 1, "c": 2} {
+		session, err := repo.CreateSession(context.Background(), &domain.Session{
+			UserId:    userId,
+			FamilyId:  familyId,
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+		sessions[familyId] = session
+	}
+	return repo, sessions
+}
+
+func liveFamilies(t *testing.T, repo *fakeSessionRepo, userId int) []string {
+	sessions, err := repo.GetSessions(context.Background(), userId)
+	require.NoError(t, err)
+	families := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		families = append(families, session.FamilyId)
+	}
+	return families
+}
+
+func TestRevokeSession(t *testing.T) {
+	repo, sessions := newTestSessions(t)
+	uc := NewSessionUseCase(repo, time.Second)
+	ctx := context.Background()
+
+	require.NoError(t, uc.RevokeSession(ctx, 1, sessions["b"].Id))
+	assert.ElementsMatch(t, []string{"a"}, liveFamilies(t, repo, 1))
+
+	// Already revoked
+	assert.ErrorIs(t, uc.RevokeSession(ctx, 1, sessions["b"].Id), domain.ErrSessionNotFound)
+	// Someone else's session is not found rather than revoked
+	assert.ErrorIs(t, uc.RevokeSession(ctx, 1, sessions["c"].Id), domain.ErrSessionNotFound)
+	assert.ElementsMatch(t, []string{"c"}, liveFamilies(t, repo, 2))
+}
+
+func TestRevokeOtherSessions(t *testing.T) {
+	repo, sessions := newTestSessions(t)
+	uc := NewSessionUseCase(repo, time.Second)
+	ctx := context.Background()
+
+	revoked, err := uc.RevokeOtherSessions(ctx, 1, sessions["a"].Id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, revoked)
+	assert.ElementsMatch(t, []string{"a"}, liveFamilies(t, repo, 1))
+	// Other users keep theirs
+	assert.ElementsMatch(t, []string{"c"}, liveFamilies(t, repo, 2))
+
+	revoked, err = uc.RevokeOtherSessions(ctx, 1, sessions["a"].Id)
+	require.NoError(t, err)
+	assert.Zero(t, revoked)
+}
+
+func TestGetSessionsMarksCurrent(t *testing.T) {
+	repo, sessions := newTestSessions(t)
+	uc := NewSessionUseCase(repo, time.Second)
+
+	responses, err := uc.GetSessions(context.Background(), 1, sessions["b"].Id)
+	require.NoError(t, err)
+	require.Len(t, responses, 2)
+	for _, response := range responses {
+		assert.Equal(t, response.Id == sessions["b"].Id, response.Current)
+	}
+}
@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/internal/tokenutil"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+)
+
+// Longest user agent kept with a session
+const maxUserAgentLength = 512
+
+// newTokenId returns a random id for a session or one of its refresh tokens
+func newTokenId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// newSecretToken returns a random token to hand out by email or in place of a
+// login, stored only as its hashToken
+func newSecretToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken is what a secret token is stored and looked up as
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func refreshTokenExpiry(env *bootstrap.Env) time.Time {
+	return time.Now().Add(time.Hour * time.Duration(env.RefreshTokenExpiryHour))
+}
+
+// clientSession fills in a session with the device it is used from
+func clientSession(session *domain.Session, client domain.SessionClient) *domain.Session {
+	session.UserAgent = client.UserAgent
+	if len(session.UserAgent) > maxUserAgentLength {
+		session.UserAgent = session.UserAgent[:maxUserAgentLength]
+	}
+	session.Ip = client.Ip
+	session.Device = deviceName(client.UserAgent)
+	return session
+}
+
+// beginLogin opens a session for a user who proved their password or Google
+// account. With two-factor authentication on it returns a challenge instead,
+// redeemed for the session by the second factor.
+func beginLogin(ctx context.Context, twoFactorRepository repository.TwoFactorRepository, sessionRepository repository.SessionRepository, user *domain.User, client domain.SessionClient, env *bootstrap.Env) (*domain.LoginResponse, error) {
+	twoFactor, err := twoFactorRepository.GetTwoFactor(ctx, user.Id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if err == nil && twoFactor.EnabledAt != nil {
+		challengeToken, err := newSecretToken()
+		if err != nil {
+			return nil, err
+		}
+		err = twoFactorRepository.CreateChallenge(ctx, user.Id, hashToken(challengeToken), time.Now().Add(challengeTTL))
+		if err != nil {
+			return nil, err
+		}
+		return &domain.LoginResponse{TwoFactorRequired: true, ChallengeToken: challengeToken}, nil
+	}
+
+	accessToken, refreshToken, err := startSession(ctx, sessionRepository, user, client, env)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.LoginResponse{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// startSession opens a session for the user and issues its first pair of tokens
+func startSession(ctx context.Context, sessionRepository repository.SessionRepository, user *domain.User, client domain.SessionClient, env *bootstrap.Env) (accessToken string, refreshToken string, err error) {
+	familyId, err := newTokenId()
+	if err != nil {
+		return "", "", err
+	}
+	tokenId, err := newTokenId()
+	if err != nil {
+		return "", "", err
+	}
+
+	_, err = sessionRepository.CreateSession(ctx, clientSession(&domain.Session{
+		UserId:    user.Id,
+		FamilyId:  familyId,
+		TokenId:   tokenId,
+		ExpiresAt: refreshTokenExpiry(env),
+	}, client))
+	if err != nil {
+		return "", "", err
+	}
+
+	return issueTokens(user, familyId, tokenId, env)
+}
+
+// issueTokens creates an access token and the refresh token tokenId of the session familyId
+func issueTokens(user *domain.User, familyId, tokenId string, env *bootstrap.Env) (accessToken string, refreshToken string, err error) {
+	accessToken, err = tokenutil.CreateAccessToken(user, familyId, env.AccessTokenSecret, env.AccessTokenExpiryHour)
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshToken, err = tokenutil.CreateRefreshToken(user, familyId, tokenId, env.RefreshTokenSecret, env.RefreshTokenExpiryHour)
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// Checked in order, as most browsers also name the ones they are built on
+var (
+	userAgentBrowsers = []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"SamsungBrowser/", "Samsung Internet"},
+		{"Firefox/", "Firefox"},
+		{"FxiOS/", "Firefox"},
+		{"CriOS/", "Chrome"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	}
+	userAgentSystems = []struct{ token, name string }{
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// deviceName describes the browser and operating system of a user agent,
+// e.g. "Chrome on macOS"
+func deviceName(userAgent string) string {
+	var browser, system string
+	for _, b := range userAgentBrowsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, s := range userAgentSystems {
+		if strings.Contains(userAgent, s.token) {
+			system = s.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case system != "":
+		return system
+	case browser != "":
+		return browser
+	}
+
+	// Apps and scripts usually lead with their own name, e.g. "okhttp/4.12.0"
+	if product, _, _ := strings.Cut(userAgent, "/"); product != "" && !strings.Contains(product, " ") {
+		return product
+	}
+	return "Unknown device"
+}
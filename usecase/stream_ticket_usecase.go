@@ -0,0 +1,40 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+)
+
+// Long enough to open the connection right after asking for the ticket. URLs
+// end up in access logs, a ticket found there is useless by then.
+const streamTicketTTL = 30 * time.Second
+
+type streamTicketUseCase struct {
+	streamTicketRepository repository.StreamTicketRepository
+	contextTimeout         time.Duration
+}
+
+func NewStreamTicketUseCase(streamTicketRepository repository.StreamTicketRepository, timeout time.Duration) domain.StreamTicketUseCase {
+	return &streamTicketUseCase{
+		streamTicketRepository: streamTicketRepository,
+		contextTimeout:         timeout,
+	}
+}
+
+func (su *streamTicketUseCase) IssueTicket(ctx context.Context, userId int, familyId string) (*domain.StreamTicketResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, su.contextTimeout)
+	defer cancel()
+
+	ticket, err := newSecretToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := su.streamTicketRepository.CreateTicket(ctx, ticket, userId, familyId, streamTicketTTL); err != nil {
+		return nil, err
+	}
+
+	return &domain.StreamTicketResponse{Ticket: ticket, ExpiresIn: int(streamTicketTTL.Seconds())}, nil
+}
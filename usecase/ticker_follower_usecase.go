@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type tickerFollowerUseCase struct {
+	tickerFollowerRepo repository.TickerFollowerRepository
+	tickerUseCase      domain.TickerUseCase
+	timelineUseCase    domain.TimelineUseCase
+	contextTimeout     time.Duration
+}
+
+func NewTickerFollowerUseCase(
+	tickerFollowerRepo repository.TickerFollowerRepository,
+	tickerUseCase domain.TickerUseCase,
+	timelineUseCase domain.TimelineUseCase,
+	timeout time.Duration,
+) domain.TickerFollowerUseCase {
+	return &tickerFollowerUseCase{
+		tickerFollowerRepo: tickerFollowerRepo,
+		tickerUseCase:      tickerUseCase,
+		timelineUseCase:    timelineUseCase,
+		contextTimeout:     timeout,
+	}
+}
+
+func (uc *tickerFollowerUseCase) Follow(ctx context.Context, userId int, symbol string) error {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	ticker, err := uc.tickerUseCase.Resolve(ctx, symbol)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.tickerFollowerRepo.Follow(ctx, userId, ticker.Symbol); err != nil {
+		return err
+	}
+
+	if err := uc.timelineUseCase.FollowTicker(ctx, userId, ticker.Symbol); err != nil {
+		log.Warnf("Failed to backfill timeline of user %d: %v", userId, err)
+	}
+	return nil
+}
+
+func (uc *tickerFollowerUseCase) Unfollow(ctx context.Context, userId int, symbol string) error {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	// Not resolved, a ticker that was deactivated can still be unfollowed
+	symbol = normalizeTicker(symbol)
+	if err := uc.tickerFollowerRepo.Unfollow(ctx, userId, symbol); err != nil {
+		return err
+	}
+
+	if err := uc.timelineUseCase.UnfollowTicker(ctx, userId, symbol); err != nil {
+		log.Warnf("Failed to trim timeline of user %d: %v", userId, err)
+	}
+	return nil
+}
+
+func (uc *tickerFollowerUseCase) GetFollowers(ctx context.Context, symbol string, page domain.PaginationParams) (*domain.PaginatedResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	symbol = normalizeTicker(symbol)
+	users, err := uc.tickerFollowerRepo.GetFollowers(ctx, symbol, fetchPage(page))
+	if err != nil {
+		return nil, err
+	}
+	users, next := trimPage(users, page.Limit, followCursor)
+
+	responses := make([]*domain.FollowUserResponse, 0, len(users))
+	for _, user := range users {
+		responses = append(responses, &domain.FollowUserResponse{
+			Id:          user.Id,
+			Name:        user.Name,
+			AvatarEmoji: user.AvatarEmoji,
+		})
+	}
+
+	return newPageResponse(responses, page, next, func() (int, error) {
+		return uc.tickerFollowerRepo.GetFollowersCount(ctx, symbol)
+	})
+}
@@ -0,0 +1,347 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/internal/tickers"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+)
+
+const maxTickerSymbolLength = 20
+
+// Window of the recent ticker stats
+const tickerStatsWindow = 24 * time.Hour
+
+type tickerUseCase struct {
+	tickerRepository   repository.TickerRepository
+	tickerFollowerRepo repository.TickerFollowerRepository
+	contextTimeout     time.Duration
+}
+
+func NewTickerUseCase(
+	tickerRepo repository.TickerRepository,
+	tickerFollowerRepo repository.TickerFollowerRepository,
+	timeout time.Duration,
+) domain.TickerUseCase {
+	return &tickerUseCase{
+		tickerRepository:   tickerRepo,
+		tickerFollowerRepo: tickerFollowerRepo,
+		contextTimeout:     timeout,
+	}
+}
+
+func (uc *tickerUseCase) Search(ctx context.Context, query string, limit int) ([]*domain.Ticker, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	query = strings.TrimPrefix(strings.TrimSpace(query), "$")
+	if query == "" {
+		return []*domain.Ticker{}, nil
+	}
+
+	return uc.tickerRepository.SearchTickers(ctx, query, limit)
+}
+
+func (uc *tickerUseCase) Resolve(ctx context.Context, input string) (*domain.Ticker, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	symbol := normalizeTicker(input)
+	if symbol == "" {
+		return nil, errors.New("ticker is required")
+	}
+
+	ticker, err := uc.tickerRepository.GetTickerBySymbol(ctx, symbol)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrUnknownTicker
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ticker.IsActive {
+		return nil, domain.ErrUnknownTicker
+	}
+	return ticker, nil
+}
+
+func (uc *tickerUseCase) ResolveSymbols(ctx context.Context, symbols []string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	tickers, err := uc.tickerRepository.GetTickersBySymbols(ctx, symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		if ticker, ok := tickers[symbol]; ok && ticker.IsActive {
+			resolved = append(resolved, symbol)
+		}
+	}
+	return resolved, nil
+}
+
+func (uc *tickerUseCase) Seed(ctx context.Context) (int, error) {
+	list, err := parseTickerCSV(tickers.Bundled())
+	if err != nil {
+		return 0, err
+	}
+	return uc.tickerRepository.InsertMissingTickers(ctx, list)
+}
+
+func (uc *tickerUseCase) Import(ctx context.Context, r io.Reader) (int, error) {
+	list, err := parseTickerCSV(r)
+	if err != nil {
+		return 0, err
+	}
+	if len(list) == 0 {
+		return 0, errors.New("no tickers to import")
+	}
+	return uc.tickerRepository.UpsertTickers(ctx, list)
+}
+
+func (uc *tickerUseCase) GetTicker(ctx context.Context, userId int, symbol string) (*domain.TickerResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	ticker, err := uc.getTicker(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := uc.tickerRepository.GetTickerStats(ctx, ticker.Symbol, time.Now().Add(-tickerStatsWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	followersCount, err := uc.tickerFollowerRepo.GetFollowersCount(ctx, ticker.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	isFollowing, err := uc.tickerFollowerRepo.IsFollowing(ctx, userId, ticker.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TickerResponse{
+		Ticker:             *ticker,
+		PostCount:          stats.PostCount,
+		DistinctAuthors24h: stats.RecentAuthors,
+		Bullish24h:         stats.RecentBullish,
+		Bearish24h:         stats.RecentBearish,
+		BullishRatio:       bullishRatio(stats.RecentBullish, stats.RecentBearish),
+		FollowersCount:     followersCount,
+		IsFollowing:        isFollowing,
+	}, nil
+}
+
+func (uc *tickerUseCase) GetSentiment(ctx context.Context, symbol string) (*domain.TickerSentimentResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	ticker, err := uc.getTicker(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &domain.TickerSentimentResponse{Symbol: ticker.Symbol}
+	if response.Hour, err = uc.getSentimentCounts(ctx, ticker.Symbol, time.Hour); err != nil {
+		return nil, err
+	}
+	if response.Day, err = uc.getSentimentCounts(ctx, ticker.Symbol, 24*time.Hour); err != nil {
+		return nil, err
+	}
+	if response.Week, err = uc.getSentimentCounts(ctx, ticker.Symbol, 7*24*time.Hour); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (uc *tickerUseCase) GetSentimentSeries(ctx context.Context, symbol, interval string) (*domain.SentimentSeriesResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	var bucket time.Duration
+	var buckets int
+	switch interval {
+	case "", "hour":
+		interval, bucket, buckets = "hour", time.Hour, 24
+	case "day":
+		bucket, buckets = 24*time.Hour, 30
+	default:
+		return nil, domain.ErrInvalidSentimentInterval
+	}
+
+	ticker, err := uc.getTicker(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := uc.tickerRepository.GetSentimentSeries(ctx, ticker.Symbol, bucket, buckets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.SentimentSeriesResponse{
+		Symbol:   ticker.Symbol,
+		Interval: interval,
+		Points:   fillSentimentSeries(rows, time.Now(), bucket, buckets),
+	}, nil
+}
+
+func (uc *tickerUseCase) getSentimentCounts(ctx context.Context, symbol string, window time.Duration) (*domain.SentimentCounts, error) {
+	counts, err := uc.tickerRepository.GetSentimentCounts(ctx, symbol, window)
+	if err != nil {
+		return nil, err
+	}
+	counts.BullishRatio = bullishRatio(counts.Bullish, counts.Bearish)
+	return counts, nil
+}
+
+// getTicker looks a ticker up by symbol. Delisted tickers are returned too,
+// their posts are still around.
+func (uc *tickerUseCase) getTicker(ctx context.Context, symbol string) (*domain.Ticker, error) {
+	ticker, err := uc.tickerRepository.GetTickerBySymbol(ctx, normalizeTicker(symbol))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrUnknownTicker
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ticker, nil
+}
+
+// fillSentimentSeries lays the buckets that had posts out on a full series,
+// oldest first, zero-filling the periods without posts
+func fillSentimentSeries(rows []*domain.SentimentBucket, now time.Time, bucket time.Duration, buckets int) []*domain.SentimentBucket {
+	byAgo := make(map[int]*domain.SentimentBucket, len(rows))
+	for _, row := range rows {
+		byAgo[row.BucketsAgo] = row
+	}
+
+	points := make([]*domain.SentimentBucket, 0, buckets)
+	for ago := buckets - 1; ago >= 0; ago-- {
+		point, ok := byAgo[ago]
+		if !ok {
+			point = &domain.SentimentBucket{BucketsAgo: ago}
+		}
+		point.Start = now.Add(-time.Duration(ago+1) * bucket).UTC()
+		point.BullishRatio = bullishRatio(point.Bullish, point.Bearish)
+		points = append(points, point)
+	}
+	return points
+}
+
+// bullishRatio is the bullish share of the posts taking a side, nil when none do
+func bullishRatio(bullish, bearish int) *float64 {
+	if bullish+bearish == 0 {
+		return nil
+	}
+	ratio := float64(bullish) / float64(bullish+bearish)
+	return &ratio
+}
+
+// normalizeTicker turns the ways people write a symbol, such as "$aapl ",
+// "eur/usd" or "btc", into the form the registry stores
+func normalizeTicker(input string) string {
+	symbol := strings.TrimSpace(input)
+	symbol = strings.TrimPrefix(symbol, "$")
+	symbol = strings.ReplaceAll(symbol, "/", "")
+	return strings.ToUpper(strings.TrimSpace(symbol))
+}
+
+// parseTickerCSV reads tickers from a CSV with a header row. The symbol, name
+// and asset_class columns are required, exchange and active are optional.
+func parseTickerCSV(r io.Reader) ([]*domain.Ticker, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("csv is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"symbol", "name", "asset_class"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("csv is missing the %s column", required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	seen := make(map[string]bool)
+	list := make([]*domain.Ticker, 0)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		ticker := &domain.Ticker{
+			Symbol:     normalizeTicker(field(record, "symbol")),
+			Name:       field(record, "name"),
+			Exchange:   field(record, "exchange"),
+			AssetClass: strings.ToLower(field(record, "asset_class")),
+			IsActive:   true,
+		}
+
+		if ticker.Symbol == "" || len(ticker.Symbol) > maxTickerSymbolLength {
+			return nil, fmt.Errorf("line %d: invalid symbol", line)
+		}
+		if ticker.Name == "" {
+			return nil, fmt.Errorf("line %d: name is required", line)
+		}
+		if !isAssetClass(ticker.AssetClass) {
+			return nil, fmt.Errorf("line %d: unknown asset class %q", line, ticker.AssetClass)
+		}
+		if active := field(record, "active"); active != "" {
+			ticker.IsActive, err = strconv.ParseBool(active)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid active flag %q", line, active)
+			}
+		}
+		if seen[ticker.Symbol] {
+			return nil, fmt.Errorf("line %d: duplicate symbol %s", line, ticker.Symbol)
+		}
+		seen[ticker.Symbol] = true
+
+		list = append(list, ticker)
+	}
+
+	return list, nil
+}
+
+func isAssetClass(assetClass string) bool {
+	switch assetClass {
+	case domain.AssetClassStock, domain.AssetClassCrypto, domain.AssetClassForex, domain.AssetClassFutures:
+		return true
+	}
+	return false
+}
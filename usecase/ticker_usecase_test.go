@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/internal/tickers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTicker(t *testing.T) {
+	cases := map[string]string{
+		"AAPL":     "AAPL",
+		"aapl":     "AAPL",
+		"$AAPL":    "AAPL",
+		" $aapl  ": "AAPL",
+		"brk.b":    "BRK.B",
+		"eur/usd":  "EURUSD",
+		"   ":      "",
+	}
+	for input, expected := range cases {
+		assert.Equal(t, expected, normalizeTicker(input), "input %q", input)
+	}
+}
+
+func TestParseTickerCSV(t *testing.T) {
+	list, err := parseTickerCSV(strings.NewReader(
+		"Symbol,Name,Asset_Class,Active\n" +
+			"$tsla,Tesla Inc.,Stock,\n" +
+			"LUNA,Terra,crypto,false\n"))
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+
+	assert.Equal(t, &domain.Ticker{Symbol: "TSLA", Name: "Tesla Inc.", AssetClass: domain.AssetClassStock, IsActive: true}, list[0])
+	assert.False(t, list[1].IsActive)
+}
+
+func TestParseTickerCSVRejectsInvalidRows(t *testing.T) {
+	cases := map[string]string{
+		"missing column":  "symbol,name\nAAPL,Apple\n",
+		"asset class":     "symbol,name,asset_class\nAAPL,Apple,bond\n",
+		"empty name":      "symbol,name,asset_class\nAAPL,,stock\n",
+		"duplicate":       "symbol,name,asset_class\nAAPL,Apple,stock\n$aapl,Apple,stock\n",
+		"active flag":     "symbol,name,asset_class,active\nAAPL,Apple,stock,maybe\n",
+		"symbol too long": "symbol,name,asset_class\nABCDEFGHIJKLMNOPQRSTUVWXYZ,Alphabet,stock\n",
+	}
+	for name, input := range cases {
+		_, err := parseTickerCSV(strings.NewReader(input))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestBundledTickersParse(t *testing.T) {
+	list, err := parseTickerCSV(tickers.Bundled())
+	require.NoError(t, err)
+	assert.NotEmpty(t, list)
+}
+
+func TestBullishRatio(t *testing.T) {
+	assert.Nil(t, bullishRatio(0, 0))
+	assert.Equal(t, 0.75, *bullishRatio(3, 1))
+	assert.Equal(t, 0.0, *bullishRatio(0, 2))
+}
+
+func TestFillSentimentSeries(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	rows := []*domain.SentimentBucket{
+		{BucketsAgo: 0, SentimentCounts: domain.SentimentCounts{Bullish: 2, Bearish: 2}},
+		{BucketsAgo: 2, SentimentCounts: domain.SentimentCounts{Neutral: 1}},
+	}
+
+	points := fillSentimentSeries(rows, now, time.Hour, 3)
+	require.Len(t, points, 3)
+
+	assert.Equal(t, now.Add(-3*time.Hour), points[0].Start)
+	assert.Equal(t, 1, points[0].Neutral)
+	assert.Nil(t, points[0].BullishRatio)
+
+	assert.Zero(t, points[1].Bullish+points[1].Bearish+points[1].Neutral)
+
+	assert.Equal(t, now.Add(-time.Hour), points[2].Start)
+	assert.Equal(t, 0.5, *points[2].BullishRatio)
+}
@@ -0,0 +1,366 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Posts kept per timeline, older pages are read from the database
+	timelineLength = 800
+	// Authors and tickers with this many followers are not fanned out on write
+	largeAccountFollowers = 10000
+	// Reads of a timeline page that turned up ids of deleted posts, each
+	// removing them, before the page is served short
+	timelinePageReads = 3
+)
+
+type timelineUseCase struct {
+	timelineRepository repository.TimelineRepository
+	postRepository     repository.PostRepository
+	followerRepository repository.FollowerRepository
+	tickerFollowerRepo repository.TickerFollowerRepository
+	contextTimeout     time.Duration
+}
+
+func NewTimelineUseCase(
+	timelineRepo repository.TimelineRepository,
+	postRepo repository.PostRepository,
+	followerRepo repository.FollowerRepository,
+	tickerFollowerRepo repository.TickerFollowerRepository,
+	timeout time.Duration,
+) domain.TimelineUseCase {
+	return &timelineUseCase{
+		timelineRepository: timelineRepo,
+		postRepository:     postRepo,
+		followerRepository: followerRepo,
+		tickerFollowerRepo: tickerFollowerRepo,
+		contextTimeout:     timeout,
+	}
+}
+
+// FanOutPost pushes a new post into the timelines of the author's followers
+// and of the ticker's followers
+func (uc *timelineUseCase) FanOutPost(ctx context.Context, post *domain.Post) error {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	followerIds, err := uc.fanOutTargets(ctx, post)
+	if err != nil {
+		return err
+	}
+
+	return uc.timelineRepository.AddPost(ctx, followerIds, post, timelineLength)
+}
+
+// RemovePost takes a deleted post out of the followers' timelines. Timelines
+// it is missed in keep a dangling id until a read of the page it is on.
+func (uc *timelineUseCase) RemovePost(ctx context.Context, post *domain.Post) error {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	followerIds, err := uc.fanOutTargets(ctx, post)
+	if err != nil {
+		return err
+	}
+
+	return uc.timelineRepository.RemovePosts(ctx, followerIds, []int{post.Id})
+}
+
+// Follow backfills the recent posts of a newly followed user
+func (uc *timelineUseCase) Follow(ctx context.Context, followerId, followingId int) error {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	posts, err := uc.postRepository.GetPostsByUserId(ctx, followingId, domain.PaginationParams{Limit: timelineLength})
+	if err != nil {
+		return err
+	}
+
+	return uc.timelineRepository.AddPosts(ctx, followerId, posts, timelineLength)
+}
+
+// Unfollow trims the posts of an unfollowed user out of the timeline, except
+// the ones on tickers the follower still follows
+func (uc *timelineUseCase) Unfollow(ctx context.Context, followerId, followingId int) error {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	posts, err := uc.postRepository.GetPostsByUserId(ctx, followingId, domain.PaginationParams{Limit: timelineLength})
+	if err != nil {
+		return err
+	}
+
+	symbols, err := uc.tickerFollowerRepo.GetFollowedSymbols(ctx, followerId)
+	if err != nil {
+		return err
+	}
+	followedSymbols := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		followedSymbols[symbol] = true
+	}
+
+	postIds := make([]int, 0, len(posts))
+	for _, post := range posts {
+		if !slices.ContainsFunc(postListedTickers(post), func(symbol string) bool { return followedSymbols[symbol] }) {
+			postIds = append(postIds, post.Id)
+		}
+	}
+	return uc.timelineRepository.RemovePosts(ctx, []int{followerId}, postIds)
+}
+
+// FollowTicker backfills the recent posts of a newly followed ticker
+func (uc *timelineUseCase) FollowTicker(ctx context.Context, userId int, symbol string) error {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	posts, err := uc.postRepository.GetPostsByTickers(ctx, []string{symbol}, domain.PaginationParams{Limit: timelineLength})
+	if err != nil {
+		return err
+	}
+
+	others := make([]*domain.Post, 0, len(posts))
+	for _, post := range posts {
+		if post.UserId != userId {
+			others = append(others, post)
+		}
+	}
+	return uc.timelineRepository.AddPosts(ctx, userId, others, timelineLength)
+}
+
+// UnfollowTicker trims the posts of an unfollowed ticker out of the timeline,
+// except the ones by users or on other tickers the follower still follows
+func (uc *timelineUseCase) UnfollowTicker(ctx context.Context, userId int, symbol string) error {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	posts, err := uc.postRepository.GetPostsByTickers(ctx, []string{symbol}, domain.PaginationParams{Limit: timelineLength})
+	if err != nil {
+		return err
+	}
+
+	followingIds, err := uc.followerRepository.GetFollowingIds(ctx, userId)
+	if err != nil {
+		return err
+	}
+	following := make(map[int]bool, len(followingIds))
+	for _, id := range followingIds {
+		following[id] = true
+	}
+
+	symbols, err := uc.tickerFollowerRepo.GetFollowedSymbols(ctx, userId)
+	if err != nil {
+		return err
+	}
+	followedSymbols := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		followedSymbols[symbol] = true
+	}
+
+	postIds := make([]int, 0, len(posts))
+	for _, post := range posts {
+		if !following[post.UserId] &&
+			!slices.ContainsFunc(postListedTickers(post), func(symbol string) bool { return followedSymbols[symbol] }) {
+			postIds = append(postIds, post.Id)
+		}
+	}
+	return uc.timelineRepository.RemovePosts(ctx, []int{userId}, postIds)
+}
+
+// GetTimeline returns a page of the following feed, newest first. It reads the
+// cached timeline, merges in the posts of followed large accounts and tickers and falls
+// back to the database join past the cached range or when Redis is unavailable.
+func (uc *timelineUseCase) GetTimeline(ctx context.Context, userId int, page domain.PaginationParams) ([]*domain.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	posts, err := uc.readTimeline(ctx, userId, page)
+	if err != nil {
+		log.Warnf("Failed to read timeline of user %d, falling back to the database: %v", userId, err)
+		return uc.postRepository.GetFollowingPosts(ctx, userId, page)
+	}
+	return posts, nil
+}
+
+func (uc *timelineUseCase) readTimeline(ctx context.Context, userId int, page domain.PaginationParams) ([]*domain.Post, error) {
+	size, built, err := uc.timelineRepository.Len(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Never built, expired or evicted
+	if !built {
+		posts, err := uc.postRepository.GetFollowingPosts(ctx, userId, domain.PaginationParams{Limit: timelineLength})
+		if err != nil {
+			return nil, err
+		}
+		if err := uc.timelineRepository.Rebuild(ctx, userId, posts, timelineLength); err != nil {
+			return nil, err
+		}
+		size = int64(len(posts))
+	}
+
+	posts, cached, err := uc.readTimelinePage(ctx, userId, page, size)
+	if err != nil {
+		return nil, err
+	}
+	if !cached {
+		return uc.postRepository.GetFollowingPosts(ctx, userId, page)
+	}
+
+	largeIds, err := uc.followerRepository.GetLargeFollowingIds(ctx, userId, largeAccountFollowers)
+	if err != nil {
+		return nil, err
+	}
+	if len(largeIds) > 0 {
+		largePosts, err := uc.postRepository.GetPostsByUserIds(ctx, largeIds, domain.PaginationParams{
+			Limit:  page.Limit,
+			Cursor: page.Cursor,
+		})
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, largePosts...)
+	}
+
+	largeSymbols, err := uc.tickerFollowerRepo.GetLargeFollowedSymbols(ctx, userId, largeAccountFollowers)
+	if err != nil {
+		return nil, err
+	}
+	if len(largeSymbols) > 0 {
+		tickerPosts, err := uc.postRepository.GetPostsByTickers(ctx, largeSymbols, domain.PaginationParams{
+			Limit:  page.Limit,
+			Cursor: page.Cursor,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, post := range tickerPosts {
+			if post.UserId != userId {
+				posts = append(posts, post)
+			}
+		}
+	}
+
+	return mergeTimelinePosts(posts, page.Limit), nil
+}
+
+// readTimelinePage returns the posts of a page of the cached timeline, cached
+// is false when the page reaches past what it keeps. Ids of posts deleted without being
+// removed from the timeline are removed now and the page read again, a short
+// page would read as the last one.
+func (uc *timelineUseCase) readTimelinePage(ctx context.Context, userId int, page domain.PaginationParams, size int64) (posts []*domain.Post, cached bool, err error) {
+	for read := 1; ; read++ {
+		postIds, err := uc.timelineRepository.GetPostIds(ctx, userId, page.Cursor, page.Limit)
+		if err != nil {
+			return nil, false, err
+		}
+
+		// The page reaches past what the timeline keeps
+		if len(postIds) < page.Limit && size >= timelineLength {
+			return nil, false, nil
+		}
+
+		posts, err = uc.postRepository.GetPostsByIds(ctx, postIds)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(posts) == len(postIds) || read == timelinePageReads {
+			return posts, true, nil
+		}
+
+		found := make(map[int]bool, len(posts))
+		for _, post := range posts {
+			found[post.Id] = true
+		}
+		dangling := make([]int, 0, len(postIds)-len(posts))
+		for _, postId := range postIds {
+			if !found[postId] {
+				dangling = append(dangling, postId)
+			}
+		}
+		if err := uc.timelineRepository.RemovePosts(ctx, []int{userId}, dangling); err != nil {
+			return nil, false, err
+		}
+	}
+}
+
+// fanOutTargets returns the users whose timelines get a post: the author's
+// followers and the followers of every ticker it's listed under, leaving out
+// large audiences and the author
+func (uc *timelineUseCase) fanOutTargets(ctx context.Context, post *domain.Post) ([]int, error) {
+	targets := make([]int, 0)
+
+	count, err := uc.followerRepository.GetFollowersCount(ctx, post.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if count < largeAccountFollowers {
+		followerIds, err := uc.followerRepository.GetFollowerIds(ctx, post.UserId)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, followerIds...)
+	}
+
+	for _, symbol := range postListedTickers(post) {
+		count, err = uc.tickerFollowerRepo.GetFollowersCount(ctx, symbol)
+		if err != nil {
+			return nil, err
+		}
+		if count < largeAccountFollowers {
+			followerIds, err := uc.tickerFollowerRepo.GetFollowerIds(ctx, symbol)
+			if err != nil {
+				return nil, err
+			}
+			targets = append(targets, followerIds...)
+		}
+	}
+
+	seen := make(map[int]bool, len(targets))
+	unique := targets[:0]
+	for _, id := range targets {
+		if id != post.UserId && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique, nil
+}
+
+// postListedTickers lists the tickers a post is listed under
+func postListedTickers(post *domain.Post) []string {
+	if len(post.Tickers) == 0 {
+		return []string{post.Ticker}
+	}
+	return post.Tickers
+}
+
+// mergeTimelinePosts orders posts by (created_at, id) descending, drops duplicates and keeps at most limit
+func mergeTimelinePosts(posts []*domain.Post, limit int) []*domain.Post {
+	sort.Slice(posts, func(i, j int) bool {
+		if posts[i].CreatedAt.Equal(posts[j].CreatedAt) {
+			return posts[i].Id > posts[j].Id
+		}
+		return posts[i].CreatedAt.After(posts[j].CreatedAt)
+	})
+
+	merged := make([]*domain.Post, 0, min(len(posts), limit))
+	for i, post := range posts {
+		if i > 0 && posts[i-1].Id == post.Id {
+			continue
+		}
+		if len(merged) == limit {
+			break
+		}
+		merged = append(merged, post)
+	}
+	return merged
+}
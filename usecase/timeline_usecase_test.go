@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTimelinePostRepo serves the posts that still exist by id. The methods
+// the timeline doesn't read are left to the embedded nil interface.
+type fakeTimelinePostRepo struct {
+	repository.PostRepository
+	posts map[int]*domain.Post
+	// Reads of the following feed from the database
+	followingReads int
+}
+
+// GetFollowingPosts finds nothing, as for someone who follows nobody
+func (f *fakeTimelinePostRepo) GetFollowingPosts(ctx context.Context, userId int, params domain.PaginationParams) ([]*domain.Post, error) {
+	f.followingReads++
+	return []*domain.Post{}, nil
+}
+
+func (f *fakeTimelinePostRepo) GetPostsByIds(ctx context.Context, ids []int) ([]*domain.Post, error) {
+	posts := make([]*domain.Post, 0, len(ids))
+	for _, id := range ids {
+		if post, ok := f.posts[id]; ok {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+
+// fakeTimelineFollowerRepo follows no large accounts
+type fakeTimelineFollowerRepo struct {
+	repository.FollowerRepository
+}
+
+func (f *fakeTimelineFollowerRepo) GetLargeFollowingIds(ctx context.Context, userId int, minFollowers int) ([]int, error) {
+	return nil, nil
+}
+
+// fakeTimelineTickerFollowerRepo follows no large tickers
+type fakeTimelineTickerFollowerRepo struct {
+	repository.TickerFollowerRepository
+}
+
+func (f *fakeTimelineTickerFollowerRepo) GetLargeFollowedSymbols(ctx context.Context, userId int, minFollowers int) ([]string, error) {
+	return nil, nil
+}
+
+func TestTimelineSkipsDeletedPostsWithoutShortPages(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	timelineRepo := repository.NewTimelineRepository(client)
+	ctx := context.Background()
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	postRepo := &fakeTimelinePostRepo{posts: make(map[int]*domain.Post)}
+	timeline := make([]*domain.Post, 0)
+	for id := 1; id <= 6; id++ {
+		post := &domain.Post{Id: id, CreatedAt: base.Add(time.Duration(id) * time.Minute)}
+		postRepo.posts[id] = post
+		timeline = append(timeline, post)
+	}
+	require.NoError(t, timelineRepo.Rebuild(ctx, 1, timeline, timelineLength))
+
+	// Deleted without being removed from the timeline
+	delete(postRepo.posts, 5)
+	delete(postRepo.posts, 4)
+
+	uc := NewTimelineUseCase(timelineRepo, postRepo, &fakeTimelineFollowerRepo{}, &fakeTimelineTickerFollowerRepo{}, time.Second)
+
+	// Three posts and the one past the page, so the page isn't taken for the last
+	posts, err := uc.GetTimeline(ctx, 1, domain.PaginationParams{Limit: 3})
+	require.NoError(t, err)
+	ids := make([]int, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.Id)
+	}
+	assert.Equal(t, []int{6, 3, 2}, ids)
+
+	size, _, err := timelineRepo.Len(ctx, 1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, size)
+}
+
+func TestTimelineEmptyIsNotRebuiltOnEveryRead(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	ctx := context.Background()
+
+	postRepo := &fakeTimelinePostRepo{posts: make(map[int]*domain.Post)}
+	uc := NewTimelineUseCase(repository.NewTimelineRepository(client), postRepo, &fakeTimelineFollowerRepo{}, &fakeTimelineTickerFollowerRepo{}, time.Second)
+
+	for i := 0; i < 3; i++ {
+		posts, err := uc.GetTimeline(ctx, 1, domain.PaginationParams{Limit: 20})
+		require.NoError(t, err)
+		assert.Empty(t, posts)
+	}
+	assert.Equal(t, 1, postRepo.followingReads)
+}
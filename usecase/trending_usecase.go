@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+)
+
+type trendingUseCase struct {
+	trendingRedisRepo repository.TrendingRedisRepository
+	tickerRepository  repository.TickerRepository
+	postUseCase       domain.PostUseCase
+	contextTimeout    time.Duration
+}
+
+func NewTrendingUseCase(
+	trendingRedisRepo repository.TrendingRedisRepository,
+	tickerRepo repository.TickerRepository,
+	postUseCase domain.PostUseCase,
+	timeout time.Duration,
+) domain.TrendingUseCase {
+	return &trendingUseCase{
+		trendingRedisRepo: trendingRedisRepo,
+		tickerRepository:  tickerRepo,
+		postUseCase:       postUseCase,
+		contextTimeout:    timeout,
+	}
+}
+
+func (uc *trendingUseCase) GetTrendingTickers(ctx context.Context, limit int) ([]*domain.TrendingTicker, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	scores, err := uc.trendingRedisRepo.GetTickers(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := make([]string, 0, len(scores))
+	for _, score := range scores {
+		symbols = append(symbols, score.Member)
+	}
+	tickers, err := uc.tickerRepository.GetTickersBySymbols(ctx, symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	trending := make([]*domain.TrendingTicker, 0, len(scores))
+	for _, score := range scores {
+		ticker, ok := tickers[score.Member]
+		if !ok || !ticker.IsActive {
+			continue
+		}
+		trending = append(trending, &domain.TrendingTicker{
+			Symbol: ticker.Symbol,
+			Name:   ticker.Name,
+			Score:  score.Score,
+		})
+	}
+	return trending, nil
+}
+
+// GetTrendingPosts returns the trending posts, hottest first. Posts deleted
+// since the ranking was computed are left out.
+func (uc *trendingUseCase) GetTrendingPosts(ctx context.Context, userId, limit int) ([]*domain.PostResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	scores, err := uc.trendingRedisRepo.GetPosts(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	postIds := make([]int, 0, len(scores))
+	for _, score := range scores {
+		if postId, err := strconv.Atoi(score.Member); err == nil {
+			postIds = append(postIds, postId)
+		}
+	}
+	if len(postIds) == 0 {
+		return []*domain.PostResponse{}, nil
+	}
+
+	return uc.postUseCase.GetPostsByIds(ctx, userId, postIds)
+}
@@ -0,0 +1,299 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/internal/email"
+	"github.com/Pro100-Almaz/trading-chat/internal/totp"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	twoFactorIssuer = "Trading Chat"
+	// How long a login that passed the password waits for its second factor
+	challengeTTL = 5 * time.Minute
+	// Codes tried per challenge. Logging in again gives a new challenge, the
+	// attempt limiter is what stops guessing across them.
+	maxChallengeAttempts = 5
+	recoveryCodeCount    = 10
+)
+
+type twoFactorUseCase struct {
+	userRepository      repository.UserRepository
+	twoFactorRepository repository.TwoFactorRepository
+	sessionRepository   repository.SessionRepository
+	attemptLimiter      *attemptLimiter
+	contextTimeout      time.Duration
+}
+
+func NewTwoFactorUseCase(
+	userRepository repository.UserRepository,
+	twoFactorRepository repository.TwoFactorRepository,
+	sessionRepository repository.SessionRepository,
+	loginAttemptRepository repository.LoginAttemptRepository,
+	emailService *email.EmailService,
+	timeout time.Duration,
+) domain.TwoFactorUseCase {
+	return &twoFactorUseCase{
+		userRepository:      userRepository,
+		twoFactorRepository: twoFactorRepository,
+		sessionRepository:   sessionRepository,
+		attemptLimiter:      newAttemptLimiter(loginAttemptRepository, emailService, "2fa", twoFactorAttemptPolicy),
+		contextTimeout:      timeout,
+	}
+}
+
+func (tu *twoFactorUseCase) Enroll(ctx context.Context, userId int) (*domain.TwoFactorEnrollResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	twoFactor, err := tu.getTwoFactor(ctx, userId)
+	if err != nil && !errors.Is(err, domain.ErrTwoFactorNotEnrolled) {
+		return nil, err
+	}
+	if twoFactor != nil && twoFactor.EnabledAt != nil {
+		return nil, domain.ErrTwoFactorEnabled
+	}
+
+	user, err := tu.userRepository.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := tu.twoFactorRepository.SaveSecret(ctx, userId, secret); err != nil {
+		return nil, err
+	}
+
+	return &domain.TwoFactorEnrollResponse{
+		Secret:          secret,
+		ProvisioningUri: totp.ProvisioningURI(twoFactorIssuer, user.Email, secret),
+	}, nil
+}
+
+func (tu *twoFactorUseCase) Confirm(ctx context.Context, userId int, code, clientIp string) (*domain.RecoveryCodesResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	twoFactor, err := tu.getTwoFactor(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.EnabledAt != nil {
+		return nil, domain.ErrTwoFactorEnabled
+	}
+
+	var step int64
+	err = tu.limitAttempts(ctx, userId, clientIp, func() error {
+		var ok bool
+		if step, ok = totp.Validate(twoFactor.Secret, strings.TrimSpace(code), time.Now()); !ok {
+			return domain.ErrInvalidTwoFactorCode
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	enabled, err := tu.twoFactorRepository.Enable(ctx, userId, step, hashes)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, domain.ErrInvalidTwoFactorCode
+	}
+
+	log.Infof("User %d enabled two-factor authentication", userId)
+	return &domain.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (tu *twoFactorUseCase) Disable(ctx context.Context, userId int, request domain.TwoFactorDisableRequest, clientIp string) error {
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	twoFactor, err := tu.getTwoFactor(ctx, userId)
+	if err != nil {
+		if errors.Is(err, domain.ErrTwoFactorNotEnrolled) {
+			return domain.ErrTwoFactorNotEnabled
+		}
+		return err
+	}
+	if twoFactor.EnabledAt == nil {
+		return domain.ErrTwoFactorNotEnabled
+	}
+
+	user, err := tu.userRepository.GetUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	// A stolen access token alone must not be enough to turn it off
+	err = tu.limitAttempts(ctx, userId, clientIp, func() error {
+		if user.GoogleId == "" && bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(request.Password)) != nil {
+			return domain.ErrInvalidPassword
+		}
+		return tu.verifySecondFactor(ctx, twoFactor, request.Code)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tu.twoFactorRepository.Disable(ctx, userId); err != nil {
+		return err
+	}
+
+	log.Infof("User %d disabled two-factor authentication", userId)
+	return nil
+}
+
+func (tu *twoFactorUseCase) VerifyLogin(ctx context.Context, request domain.TwoFactorLoginRequest, client domain.SessionClient, env *bootstrap.Env) (*domain.LoginResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, tu.contextTimeout)
+	defer cancel()
+
+	challengeHash := hashToken(request.ChallengeToken)
+	userId, err := tu.twoFactorRepository.AttemptChallenge(ctx, challengeHash, maxChallengeAttempts)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrInvalidChallenge
+		}
+		return nil, err
+	}
+
+	twoFactor, err := tu.getTwoFactor(ctx, userId)
+	if err != nil {
+		// Disabled since the login began, nothing to check the code against
+		if errors.Is(err, domain.ErrTwoFactorNotEnrolled) {
+			return nil, domain.ErrInvalidChallenge
+		}
+		return nil, err
+	}
+	if twoFactor.EnabledAt == nil {
+		return nil, domain.ErrInvalidChallenge
+	}
+
+	err = tu.limitAttempts(ctx, userId, client.Ip, func() error {
+		return tu.verifySecondFactor(ctx, twoFactor, request.Code)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	completed, err := tu.twoFactorRepository.CompleteChallenge(ctx, challengeHash)
+	if err != nil {
+		return nil, err
+	}
+	if !completed {
+		return nil, domain.ErrInvalidChallenge
+	}
+
+	user, err := tu.userRepository.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, refreshToken, err := startSession(ctx, tu.sessionRepository, user, client, env)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.LoginResponse{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// getTwoFactor returns the enrollment of the user, or ErrTwoFactorNotEnrolled
+func (tu *twoFactorUseCase) getTwoFactor(ctx context.Context, userId int) (*domain.TwoFactor, error) {
+	twoFactor, err := tu.twoFactorRepository.GetTwoFactor(ctx, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrTwoFactorNotEnrolled
+	}
+	return twoFactor, err
+}
+
+// limitAttempts runs verify unless the user has to wait after failed second
+// factor attempts, and counts a wrong code or password it returns against the
+// user and the address
+func (tu *twoFactorUseCase) limitAttempts(ctx context.Context, userId int, clientIp string, verify func() error) error {
+	account := strconv.Itoa(userId)
+	if err := tu.attemptLimiter.check(ctx, account, clientIp); err != nil {
+		log.Warn(err)
+		return err
+	}
+
+	err := verify()
+	switch {
+	case err == nil:
+		tu.attemptLimiter.succeed(ctx, account)
+	case errors.Is(err, domain.ErrInvalidTwoFactorCode), errors.Is(err, domain.ErrInvalidPassword):
+		user, userErr := tu.userRepository.GetUserById(ctx, userId)
+		if userErr != nil {
+			log.Error("Failed to get user for lockout notice: ", userErr)
+			user = nil
+		}
+		tu.attemptLimiter.fail(ctx, account, clientIp, user)
+	}
+	return err
+}
+
+// verifySecondFactor accepts a code from the authenticator app or a recovery
+// code, once each
+func (tu *twoFactorUseCase) verifySecondFactor(ctx context.Context, twoFactor *domain.TwoFactor, code string) error {
+	code = strings.TrimSpace(code)
+
+	var accepted bool
+	var err error
+	if step, ok := totp.Validate(twoFactor.Secret, code, time.Now()); ok {
+		accepted, err = tu.twoFactorRepository.UseStep(ctx, twoFactor.UserId, step)
+	} else if len(code) != totp.Digits {
+		accepted, err = tu.twoFactorRepository.UseRecoveryCode(ctx, twoFactor.UserId, hashToken(normalizeRecoveryCode(code)))
+		if accepted {
+			log.Infof("User %d used a recovery code", twoFactor.UserId)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if !accepted {
+		return domain.ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// newRecoveryCodes returns recovery codes like "k3x9d-7qp2m", 50 random bits
+// each, along with the hashes they are stored as
+func newRecoveryCodes() (codes []string, hashes []string, err error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(encoding.EncodeToString(b)[:10])
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashes = append(hashes, hashToken(code))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode accepts recovery codes typed with other case or spacing
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
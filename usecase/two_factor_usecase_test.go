@@ -0,0 +1,254 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/bootstrap"
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/internal/email"
+	"github.com/Pro100-Almaz/trading-chat/internal/totp"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, hashes, err := newRecoveryCodes()
+	assert.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+	assert.Len(t, hashes, recoveryCodeCount)
+
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		assert.Regexp(t, regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`), code)
+		assert.False(t, seen[code])
+		seen[code] = true
+
+		// Typed back however, a code hashes to what was stored
+		assert.Equal(t, hashes[i], hashToken(normalizeRecoveryCode(code)))
+		assert.Equal(t, hashes[i], hashToken(normalizeRecoveryCode(" "+strings.ToUpper(strings.Replace(code, "-", " ", 1)))))
+	}
+}
+
+// fakeTwoFactorRepo holds the enrollment, recovery codes and challenges of users in memory
+type fakeTwoFactorRepo struct {
+	mu            sync.Mutex
+	twoFactors    map[int]*domain.TwoFactor
+	recoveryCodes map[int]map[string]bool
+	challenges    map[string]*fakeChallenge
+}
+
+type fakeChallenge struct {
+	userId   int
+	attempts int
+	used     bool
+}
+
+func newFakeTwoFactorRepo() *fakeTwoFactorRepo {
+	return &fakeTwoFactorRepo{
+		twoFactors:    make(map[int]*domain.TwoFactor),
+		recoveryCodes: make(map[int]map[string]bool),
+		challenges:    make(map[string]*fakeChallenge),
+	}
+}
+
+func (f *fakeTwoFactorRepo) GetTwoFactor(ctx context.Context, userId int) (*domain.TwoFactor, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	twoFactor, ok := f.twoFactors[userId]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *twoFactor
+	return &copied, nil
+}
+
+func (f *fakeTwoFactorRepo) SaveSecret(ctx context.Context, userId int, secret string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if twoFactor, ok := f.twoFactors[userId]; ok && twoFactor.EnabledAt != nil {
+		return nil
+	}
+	f.twoFactors[userId] = &domain.TwoFactor{UserId: userId, Secret: secret}
+	return nil
+}
+
+func (f *fakeTwoFactorRepo) Enable(ctx context.Context, userId int, step int64, recoveryCodeHashes []string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	twoFactor, ok := f.twoFactors[userId]
+	if !ok || twoFactor.EnabledAt != nil || twoFactor.LastUsedStep >= step {
+		return false, nil
+	}
+	now := time.Now()
+	twoFactor.EnabledAt = &now
+	twoFactor.LastUsedStep = step
+	f.recoveryCodes[userId] = make(map[string]bool)
+	for _, hash := range recoveryCodeHashes {
+		f.recoveryCodes[userId][hash] = true
+	}
+	return true, nil
+}
+
+func (f *fakeTwoFactorRepo) Disable(ctx context.Context, userId int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.twoFactors, userId)
+	delete(f.recoveryCodes, userId)
+	return nil
+}
+
+func (f *fakeTwoFactorRepo) UseStep(ctx context.Context, userId int, step int64) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	twoFactor, ok := f.twoFactors[userId]
+	if !ok || twoFactor.LastUsedStep >= step {
+		return false, nil
+	}
+	twoFactor.LastUsedStep = step
+	return true, nil
+}
+
+func (f *fakeTwoFactorRepo) UseRecoveryCode(ctx context.Context, userId int, codeHash string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.recoveryCodes[userId][codeHash] {
+		return false, nil
+	}
+	delete(f.recoveryCodes[userId], codeHash)
+	return true, nil
+}
+
+func (f *fakeTwoFactorRepo) CreateChallenge(ctx context.Context, userId int, tokenHash string, expiresAt time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.challenges[tokenHash] = &fakeChallenge{userId: userId}
+	return nil
+}
+
+func (f *fakeTwoFactorRepo) AttemptChallenge(ctx context.Context, tokenHash string, maxAttempts int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	challenge, ok := f.challenges[tokenHash]
+	if !ok || challenge.used || challenge.attempts >= maxAttempts {
+		return 0, sql.ErrNoRows
+	}
+	challenge.attempts++
+	return challenge.userId, nil
+}
+
+func (f *fakeTwoFactorRepo) CompleteChallenge(ctx context.Context, tokenHash string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	challenge, ok := f.challenges[tokenHash]
+	if !ok || challenge.used {
+		return false, nil
+	}
+	challenge.used = true
+	return true, nil
+}
+
+// newTestTwoFactorUseCase returns the use case for a user with the password
+// "password123" and two-factor authentication on, and its secret
+func newTestTwoFactorUseCase(t *testing.T) (*twoFactorUseCase, *fakeTwoFactorRepo, *miniredis.Miniredis, string) {
+	password, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	users := newFakeUserRepo(&domain.User{Id: 1, Email: "jane@example.com", Password: string(password)})
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	twoFactorRepo := newFakeTwoFactorRepo()
+	enabledAt := time.Now()
+	twoFactorRepo.twoFactors[1] = &domain.TwoFactor{UserId: 1, Secret: secret, EnabledAt: &enabledAt}
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	uc := NewTwoFactorUseCase(
+		users,
+		twoFactorRepo,
+		nil,
+		repository.NewLoginAttemptRedisRepository(client),
+		email.NewEmailService(&bootstrap.Env{}),
+		time.Second,
+	).(*twoFactorUseCase)
+	return uc, twoFactorRepo, server, secret
+}
+
+// wrongCode returns a code the secret doesn't give around now
+func wrongCode(t *testing.T, secret string) string {
+	for i := 0; ; i++ {
+		code := fmt.Sprintf("%06d", i)
+		if _, ok := totp.Validate(secret, code, time.Now()); !ok {
+			return code
+		}
+	}
+}
+
+func TestTwoFactorFailuresLockOutAcrossChallenges(t *testing.T) {
+	uc, twoFactorRepo, server, secret := newTestTwoFactorUseCase(t)
+	ctx := context.Background()
+	client := domain.SessionClient{Ip: "203.0.113.7"}
+
+	// Logging in again gives a new challenge each time, the failures still add up
+	for i := 0; i < twoFactorAttemptPolicy.lockoutFailures; i++ {
+		challengeToken := fmt.Sprintf("challenge-%d", i)
+		require.NoError(t, twoFactorRepo.CreateChallenge(ctx, 1, hashToken(challengeToken), time.Now().Add(challengeTTL)))
+
+		_, err := uc.VerifyLogin(ctx, domain.TwoFactorLoginRequest{ChallengeToken: challengeToken, Code: wrongCode(t, secret)}, client, &bootstrap.Env{})
+		assert.ErrorIs(t, err, domain.ErrInvalidTwoFactorCode)
+
+		// Wait out the backoff between failures, not the lockout
+		server.FastForward(twoFactorAttemptPolicy.maxBackoff)
+	}
+
+	require.NoError(t, twoFactorRepo.CreateChallenge(ctx, 1, hashToken("fresh"), time.Now().Add(challengeTTL)))
+	code, err := totp.Code(secret, time.Now())
+	require.NoError(t, err)
+	_, err = uc.VerifyLogin(ctx, domain.TwoFactorLoginRequest{ChallengeToken: "fresh", Code: code}, client, &bootstrap.Env{})
+
+	var tooMany *domain.TooManyAttemptsError
+	require.ErrorAs(t, err, &tooMany)
+	assert.GreaterOrEqual(t, tooMany.RetryAfter, twoFactorAttemptPolicy.lockoutBase-twoFactorAttemptPolicy.maxBackoff)
+
+	// Not from another address either
+	err = uc.Disable(ctx, 1, domain.TwoFactorDisableRequest{Password: "password123", Code: code}, "198.51.100.1")
+	assert.ErrorAs(t, err, &tooMany)
+}
+
+func TestTwoFactorDisableNeedsPassword(t *testing.T) {
+	uc, twoFactorRepo, server, secret := newTestTwoFactorUseCase(t)
+	ctx := context.Background()
+
+	code, err := totp.Code(secret, time.Now())
+	require.NoError(t, err)
+
+	// A valid code alone is not enough, and wrong passwords count as failures
+	for i := 0; i < twoFactorAttemptPolicy.lockoutFailures; i++ {
+		err = uc.Disable(ctx, 1, domain.TwoFactorDisableRequest{Password: "guess", Code: code}, "203.0.113.7")
+		assert.ErrorIs(t, err, domain.ErrInvalidPassword)
+		server.FastForward(twoFactorAttemptPolicy.maxBackoff)
+	}
+
+	var tooMany *domain.TooManyAttemptsError
+	err = uc.Disable(ctx, 1, domain.TwoFactorDisableRequest{Password: "password123", Code: code}, "203.0.113.7")
+	require.ErrorAs(t, err, &tooMany)
+
+	server.FastForward(twoFactorAttemptPolicy.lockoutBase)
+	err = uc.Disable(ctx, 1, domain.TwoFactorDisableRequest{Password: "password123", Code: code}, "203.0.113.7")
+	require.NoError(t, err)
+	_, err = twoFactorRepo.GetTwoFactor(ctx, 1)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
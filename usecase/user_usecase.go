@@ -9,14 +9,16 @@ import (
 )
 
 type userUseCase struct {
-	userRepository repository.UserRepository
-	contextTimeout time.Duration
+	userRepository       repository.UserRepository
+	predictionRepository repository.PredictionRepository
+	contextTimeout       time.Duration
 }
 
-func NewUserUseCase(userRepository repository.UserRepository, timeout time.Duration) domain.UserUseCase {
+func NewUserUseCase(userRepository repository.UserRepository, predictionRepository repository.PredictionRepository, timeout time.Duration) domain.UserUseCase {
 	return &userUseCase{
-		userRepository: userRepository,
-		contextTimeout: timeout,
+		userRepository:       userRepository,
+		predictionRepository: predictionRepository,
+		contextTimeout:       timeout,
 	}
 }
 
@@ -59,9 +61,34 @@ func (uu *userUseCase) GetUserById(c context.Context, id int) (*domain.UserRespo
 		Email:       user.Email,
 		CreatedAt:   user.CreatedAt,
 	}
+	ur.PredictionRecord, err = uu.getPredictionRecord(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 	return ur, nil
 }
 
+func (uu *userUseCase) GetPredictionRecord(c context.Context, id int) (*domain.PredictionRecord, error) {
+	ctx, cancel := context.WithTimeout(c, uu.contextTimeout)
+	defer cancel()
+	if _, err := uu.userRepository.GetUserById(ctx, id); err != nil {
+		return nil, domain.ErrUserNotFound
+	}
+	return uu.getPredictionRecord(ctx, id)
+}
+
+func (uu *userUseCase) getPredictionRecord(ctx context.Context, id int) (*domain.PredictionRecord, error) {
+	record, err := uu.predictionRepository.GetPredictionRecord(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if resolved := record.Hits + record.Misses; resolved > 0 {
+		accuracy := float64(record.Hits) / float64(resolved)
+		record.Accuracy = &accuracy
+	}
+	return record, nil
+}
+
 func (uu *userUseCase) UpdateUser(c context.Context, user *domain.User) error {
 	ctx, cancel := context.WithTimeout(c, uu.contextTimeout)
 	defer cancel()
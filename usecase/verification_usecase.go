@@ -17,12 +17,14 @@ type verificationUseCase struct {
 	userRepository         repository.UserRepository
 	verificationRepository repository.VerificationRepository
 	emailService           *email.EmailService
+	attemptLimiter         *attemptLimiter
 	contextTimeout         time.Duration
 }
 
 func NewVerificationUseCase(
 	userRepo repository.UserRepository,
 	verificationRepo repository.VerificationRepository,
+	loginAttemptRepo repository.LoginAttemptRepository,
 	emailService *email.EmailService,
 	timeout time.Duration,
 ) domain.VerificationUseCase {
@@ -30,6 +32,7 @@ func NewVerificationUseCase(
 		userRepository:         userRepo,
 		verificationRepository: verificationRepo,
 		emailService:           emailService,
+		attemptLimiter:         newAttemptLimiter(loginAttemptRepo, emailService, "verify", accountAttemptPolicy),
 		contextTimeout:         timeout,
 	}
 }
@@ -66,11 +69,18 @@ func (vu *verificationUseCase) SendVerificationCode(ctx context.Context, userId
 	return nil
 }
 
-func (vu *verificationUseCase) VerifyEmail(ctx context.Context, userEmail, code string) error {
+func (vu *verificationUseCase) VerifyEmail(ctx context.Context, userEmail, code, clientIp string) error {
+	// Six digits are guessed through quickly without a limit on attempts
+	if err := vu.attemptLimiter.check(ctx, userEmail, clientIp); err != nil {
+		log.Warn(err)
+		return err
+	}
+
 	// Get user by email
 	user, err := vu.userRepository.GetUserByEmail(ctx, userEmail)
 	if err != nil {
 		log.Error("User not found: ", err)
+		vu.attemptLimiter.fail(ctx, userEmail, clientIp, nil)
 		return domain.ErrUserNotFound
 	}
 
@@ -83,8 +93,10 @@ func (vu *verificationUseCase) VerifyEmail(ctx context.Context, userEmail, code
 	_, err = vu.verificationRepository.GetVerificationCode(ctx, user.Id, code)
 	if err != nil {
 		log.Error("Invalid verification code: ", err)
+		vu.attemptLimiter.fail(ctx, userEmail, clientIp, user)
 		return domain.ErrInvalidVerificationCode
 	}
+	vu.attemptLimiter.succeed(ctx, userEmail)
 
 	// Mark user as verified
 	err = vu.verificationRepository.MarkUserAsVerified(ctx, user.Id)
@@ -0,0 +1,323 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+)
+
+const (
+	maxWatchlists          = 20
+	maxWatchlistSymbols    = 100
+	maxWatchlistNameLength = 100
+	// Over all watchlists of a user
+	maxAlertRules = 50
+)
+
+type watchlistUseCase struct {
+	watchlistRepository repository.WatchlistRepository
+	alertRuleRepository repository.AlertRuleRepository
+	tickerUseCase       domain.TickerUseCase
+	contextTimeout      time.Duration
+}
+
+func NewWatchlistUseCase(
+	watchlistRepo repository.WatchlistRepository,
+	alertRuleRepo repository.AlertRuleRepository,
+	tickerUseCase domain.TickerUseCase,
+	timeout time.Duration,
+) domain.WatchlistUseCase {
+	return &watchlistUseCase{
+		watchlistRepository: watchlistRepo,
+		alertRuleRepository: alertRuleRepo,
+		tickerUseCase:       tickerUseCase,
+		contextTimeout:      timeout,
+	}
+}
+
+func (uc *watchlistUseCase) GetWatchlists(ctx context.Context, userId int) ([]*domain.WatchlistResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	watchlists, err := uc.watchlistRepository.GetWatchlists(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return uc.buildResponses(ctx, watchlists)
+}
+
+func (uc *watchlistUseCase) GetWatchlist(ctx context.Context, userId, watchlistId int) (*domain.WatchlistResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	watchlist, err := uc.getWatchlist(ctx, userId, watchlistId)
+	if err != nil {
+		return nil, err
+	}
+	responses, err := uc.buildResponses(ctx, []*domain.Watchlist{watchlist})
+	if err != nil {
+		return nil, err
+	}
+	return responses[0], nil
+}
+
+func (uc *watchlistUseCase) CreateWatchlist(ctx context.Context, userId int, request *domain.WatchlistRequest) (*domain.WatchlistResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	name, err := watchlistName(request.Name)
+	if err != nil {
+		return nil, err
+	}
+	symbols, err := uc.resolveSymbols(ctx, request.Symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := uc.watchlistRepository.GetWatchlistsCount(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxWatchlists {
+		return nil, domain.ErrWatchlistLimit
+	}
+
+	id, err := uc.watchlistRepository.CreateWatchlist(ctx, &domain.Watchlist{UserId: userId, Name: name}, symbols)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrWatchlistExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	return uc.GetWatchlist(ctx, userId, id)
+}
+
+func (uc *watchlistUseCase) UpdateWatchlist(ctx context.Context, userId, watchlistId int, request *domain.WatchlistRequest) (*domain.WatchlistResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	name, err := watchlistName(request.Name)
+	if err != nil {
+		return nil, err
+	}
+	var symbols []string
+	if request.Symbols != nil {
+		if symbols, err = uc.resolveSymbols(ctx, request.Symbols); err != nil {
+			return nil, err
+		}
+	}
+
+	watchlist, err := uc.getWatchlist(ctx, userId, watchlistId)
+	if err != nil {
+		return nil, err
+	}
+	watchlist.Name = name
+	err = uc.watchlistRepository.UpdateWatchlist(ctx, watchlist, symbols)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrWatchlistExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	return uc.GetWatchlist(ctx, userId, watchlistId)
+}
+
+func (uc *watchlistUseCase) DeleteWatchlist(ctx context.Context, userId, watchlistId int) error {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	if _, err := uc.getWatchlist(ctx, userId, watchlistId); err != nil {
+		return err
+	}
+	return uc.watchlistRepository.DeleteWatchlist(ctx, userId, watchlistId)
+}
+
+func (uc *watchlistUseCase) AddSymbol(ctx context.Context, userId, watchlistId int, symbol string) error {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	if _, err := uc.getWatchlist(ctx, userId, watchlistId); err != nil {
+		return err
+	}
+	ticker, err := uc.tickerUseCase.Resolve(ctx, symbol)
+	if err != nil {
+		return err
+	}
+
+	count, err := uc.watchlistRepository.GetSymbolsCount(ctx, watchlistId)
+	if err != nil {
+		return err
+	}
+	if count >= maxWatchlistSymbols {
+		return domain.ErrWatchlistFull
+	}
+	return uc.watchlistRepository.AddSymbol(ctx, watchlistId, ticker.Symbol)
+}
+
+func (uc *watchlistUseCase) RemoveSymbol(ctx context.Context, userId, watchlistId int, symbol string) error {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	if _, err := uc.getWatchlist(ctx, userId, watchlistId); err != nil {
+		return err
+	}
+	// Not resolved, a ticker that was deactivated can still be removed
+	return uc.watchlistRepository.RemoveSymbol(ctx, watchlistId, normalizeTicker(symbol))
+}
+
+func (uc *watchlistUseCase) CreateAlertRule(ctx context.Context, userId, watchlistId int, request *domain.AlertRuleRequest) (*domain.AlertRule, error) {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	rule := &domain.AlertRule{
+		WatchlistId: watchlistId,
+		Symbol:      normalizeTicker(request.Symbol),
+		Type:        request.Type,
+	}
+	switch request.Type {
+	case domain.AlertPriceCross:
+		if request.Level == nil || *request.Level <= 0 {
+			return nil, domain.ErrInvalidAlertRule
+		}
+		rule.Level = request.Level
+	case domain.AlertPercentMove:
+		if request.Percent == nil || *request.Percent <= 0 {
+			return nil, domain.ErrInvalidAlertRule
+		}
+		rule.Percent = request.Percent
+	case domain.AlertSentimentFlip:
+	default:
+		return nil, domain.ErrInvalidAlertRule
+	}
+
+	if _, err := uc.getWatchlist(ctx, userId, watchlistId); err != nil {
+		return nil, err
+	}
+	listed, err := uc.watchlistRepository.HasSymbol(ctx, watchlistId, rule.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if !listed {
+		return nil, domain.ErrNotInWatchlist
+	}
+
+	count, err := uc.alertRuleRepository.GetAlertRulesCount(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxAlertRules {
+		return nil, domain.ErrAlertRuleLimit
+	}
+
+	id, err := uc.alertRuleRepository.CreateAlertRule(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+	rule.Id = id
+	rule.UserId = userId
+	rule.CreatedAt = time.Now()
+	return rule, nil
+}
+
+func (uc *watchlistUseCase) DeleteAlertRule(ctx context.Context, userId, watchlistId, ruleId int) error {
+	ctx, cancel := context.WithTimeout(ctx, uc.contextTimeout)
+	defer cancel()
+
+	if _, err := uc.getWatchlist(ctx, userId, watchlistId); err != nil {
+		return err
+	}
+	deleted, err := uc.alertRuleRepository.DeleteAlertRule(ctx, watchlistId, ruleId)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return domain.ErrAlertRuleNotFound
+	}
+	return nil
+}
+
+// getWatchlist loads a watchlist of the user, the ones of other users read as missing
+func (uc *watchlistUseCase) getWatchlist(ctx context.Context, userId, watchlistId int) (*domain.Watchlist, error) {
+	watchlist, err := uc.watchlistRepository.GetWatchlist(ctx, userId, watchlistId)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrWatchlistNotFound
+	}
+	return watchlist, err
+}
+
+// resolveSymbols normalizes and deduplicates the tickers of a watchlist,
+// all of which have to be known and active
+func (uc *watchlistUseCase) resolveSymbols(ctx context.Context, input []string) ([]string, error) {
+	symbols := make([]string, 0, len(input))
+	seen := make(map[string]bool, len(input))
+	for _, symbol := range input {
+		symbol = normalizeTicker(symbol)
+		if symbol != "" && !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(symbols) > maxWatchlistSymbols {
+		return nil, domain.ErrWatchlistFull
+	}
+	if len(symbols) == 0 {
+		return symbols, nil
+	}
+
+	resolved, err := uc.tickerUseCase.ResolveSymbols(ctx, symbols)
+	if err != nil {
+		return nil, err
+	}
+	if len(resolved) != len(symbols) {
+		return nil, domain.ErrUnknownTicker
+	}
+	return resolved, nil
+}
+
+func (uc *watchlistUseCase) buildResponses(ctx context.Context, watchlists []*domain.Watchlist) ([]*domain.WatchlistResponse, error) {
+	ids := make([]int, 0, len(watchlists))
+	for _, watchlist := range watchlists {
+		ids = append(ids, watchlist.Id)
+	}
+
+	symbols, err := uc.watchlistRepository.GetSymbols(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := uc.alertRuleRepository.GetAlertRules(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*domain.WatchlistResponse, 0, len(watchlists))
+	for _, watchlist := range watchlists {
+		response := &domain.WatchlistResponse{
+			Id:        watchlist.Id,
+			Name:      watchlist.Name,
+			Symbols:   symbols[watchlist.Id],
+			Rules:     rules[watchlist.Id],
+			CreatedAt: watchlist.CreatedAt,
+		}
+		if response.Symbols == nil {
+			response.Symbols = []string{}
+		}
+		if response.Rules == nil {
+			response.Rules = []*domain.AlertRule{}
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+func watchlistName(input string) (string, error) {
+	name := strings.TrimSpace(input)
+	if name == "" || utf8.RuneCountInString(name) > maxWatchlistNameLength {
+		return "", domain.ErrWatchlistName
+	}
+	return name, nil
+}
@@ -45,6 +45,20 @@ func MigrateDB(db *sqlx.DB) {
 	// Create index on verification_codes for faster lookups
 	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_verification_codes_user_id ON verification_codes(user_id)`)
 
+	// Create password_reset_tokens table, kept once used so that requests can be rate limited
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS password_reset_tokens (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		token_hash VARCHAR(64) NOT NULL UNIQUE,
+		expires_at TIMESTAMP NOT NULL,
+		used_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens(user_id, created_at)`)
+
 	// Create token_blacklist table
 	db.MustExec(`
 		CREATE TABLE IF NOT EXISTS token_blacklist (
@@ -59,6 +73,60 @@ func MigrateDB(db *sqlx.DB) {
 	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_token_blacklist_token ON token_blacklist(token)`)
 	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_token_blacklist_expires_at ON token_blacklist(expires_at)`)
 
+	// Create sessions table, one row per login holding the refresh token it may redeem next
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS sessions (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		family_id TEXT NOT NULL UNIQUE,
+		token_id TEXT NOT NULL,
+		device TEXT NOT NULL DEFAULT '',
+		user_agent TEXT NOT NULL DEFAULT '',
+		ip TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		expires_at TIMESTAMP NOT NULL,
+		revoked_at TIMESTAMP
+		);
+	`)
+
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id)`)
+
+	// Create two_factor table, the TOTP secret of users who enrolled in two-factor authentication
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS two_factor (
+		user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		secret TEXT NOT NULL,
+		enabled_at TIMESTAMP,
+		last_used_step BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+
+	// Create two_factor_recovery_codes table, hashed single-use codes for logging in without the authenticator
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS two_factor_recovery_codes (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		code_hash VARCHAR(64) NOT NULL,
+		used_at TIMESTAMP,
+		UNIQUE(user_id, code_hash)
+		);
+	`)
+
+	// Create two_factor_challenges table, logins waiting on their second factor
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS two_factor_challenges (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		token_hash VARCHAR(64) NOT NULL UNIQUE,
+		attempts SMALLINT NOT NULL DEFAULT 0,
+		expires_at TIMESTAMP NOT NULL,
+		used_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+
 	// Create posts table
 	db.MustExec(`
 		CREATE TABLE IF NOT EXISTS posts (
@@ -71,6 +139,19 @@ func MigrateDB(db *sqlx.DB) {
 		);
 	`)
 
+	// Create tickers table
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS tickers (
+		symbol VARCHAR(20) PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		exchange VARCHAR(50) NOT NULL DEFAULT '',
+		asset_class VARCHAR(20) NOT NULL CHECK (asset_class IN ('stock', 'crypto', 'forex', 'futures')),
+		is_active BOOLEAN DEFAULT TRUE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP
+		);
+	`)
+
 	// Create post_views table
 	db.MustExec(`
 		CREATE TABLE IF NOT EXISTS post_views (
@@ -80,6 +161,26 @@ func MigrateDB(db *sqlx.DB) {
 		);
 	`)
 
+	// Create post_views_sync_batches table, remembers which Redis sync batches were applied
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS post_views_sync_batches (
+		batch_id VARCHAR(64) PRIMARY KEY,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+
+	// Create post_views_daily table, unique viewers and raw impressions per post per UTC day
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS post_views_daily (
+		post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+		day DATE NOT NULL,
+		unique_viewers BIGINT DEFAULT 0,
+		impressions BIGINT DEFAULT 0,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (post_id, day)
+		);
+	`)
+
 	// Create likes table
 	db.MustExec(`
 		CREATE TABLE IF NOT EXISTS likes (
@@ -113,12 +214,212 @@ func MigrateDB(db *sqlx.DB) {
 		);
 	`)
 
+	// Create ticker_followers table
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS ticker_followers (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		symbol VARCHAR(20) NOT NULL REFERENCES tickers(symbol) ON DELETE CASCADE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(user_id, symbol)
+		);
+	`)
+
+	// Create predictions table, a post's price call and how it turned out
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS predictions (
+		id SERIAL PRIMARY KEY,
+		post_id INTEGER NOT NULL UNIQUE REFERENCES posts(id) ON DELETE CASCADE,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		ticker VARCHAR(20) NOT NULL,
+		direction VARCHAR(4) NOT NULL CHECK (direction IN ('up', 'down')),
+		target_price NUMERIC(20, 8) NOT NULL CHECK (target_price > 0),
+		expires_at TIMESTAMP NOT NULL,
+		status VARCHAR(10) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'hit', 'miss', 'void')),
+		resolved_price NUMERIC(20, 8),
+		resolved_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+
+	// Create post_entities table, the cashtags, mentions and hashtags of post bodies
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS post_entities (
+		id SERIAL PRIMARY KEY,
+		post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+		type VARCHAR(10) NOT NULL CHECK (type IN ('cashtag', 'mention', 'hashtag')),
+		text VARCHAR(255) NOT NULL,
+		start_offset INTEGER NOT NULL,
+		end_offset INTEGER NOT NULL,
+		user_id INTEGER REFERENCES users(id) ON DELETE SET NULL
+		);
+	`)
+
+	// Create comment_entities table, the cashtags, mentions and hashtags of comment bodies
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS comment_entities (
+		id SERIAL PRIMARY KEY,
+		comment_id INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+		type VARCHAR(10) NOT NULL CHECK (type IN ('cashtag', 'mention', 'hashtag')),
+		text VARCHAR(255) NOT NULL,
+		start_offset INTEGER NOT NULL,
+		end_offset INTEGER NOT NULL,
+		user_id INTEGER REFERENCES users(id) ON DELETE SET NULL
+		);
+	`)
+
+	// Create post_revisions table, the earlier versions of edited posts
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS post_revisions (
+		id SERIAL PRIMARY KEY,
+		post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+		body TEXT NOT NULL,
+		sentiment VARCHAR(10),
+		created_at TIMESTAMP NOT NULL,
+		replaced_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+
+	// Create comment_revisions table, the earlier versions of edited comments
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS comment_revisions (
+		id SERIAL PRIMARY KEY,
+		comment_id INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+		body TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		replaced_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+
+	// Create watchlists table, named lists of tickers a user keeps
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS watchlists (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		name VARCHAR(100) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP,
+		UNIQUE(user_id, name)
+		);
+	`)
+
+	// Create watchlist_tickers table
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS watchlist_tickers (
+		watchlist_id INTEGER NOT NULL REFERENCES watchlists(id) ON DELETE CASCADE,
+		symbol VARCHAR(20) NOT NULL REFERENCES tickers(symbol) ON DELETE CASCADE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (watchlist_id, symbol)
+		);
+	`)
+
+	// Create alert_rules table, alerts on the tickers of a watchlist and the state they were last evaluated in
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS alert_rules (
+		id SERIAL PRIMARY KEY,
+		watchlist_id INTEGER NOT NULL,
+		symbol VARCHAR(20) NOT NULL,
+		type VARCHAR(20) NOT NULL CHECK (type IN ('price_cross', 'percent_move', 'sentiment_flip')),
+		level NUMERIC(20, 8) CHECK (level > 0),
+		percent NUMERIC(10, 4) CHECK (percent > 0),
+		last_price NUMERIC(20, 8),
+		last_sentiment VARCHAR(10),
+		triggered_at TIMESTAMP,
+		revision INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (watchlist_id, symbol) REFERENCES watchlist_tickers(watchlist_id, symbol) ON DELETE CASCADE
+		);
+	`)
+
+	// Create notifications table, one row per actor in each group of a user's notifications
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS notifications (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		actor_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+		type VARCHAR(10) NOT NULL CHECK (type IN ('follow', 'like', 'comment', 'mention', 'alert')),
+		post_id INTEGER REFERENCES posts(id) ON DELETE CASCADE,
+		comment_id INTEGER REFERENCES comments(id) ON DELETE SET NULL,
+		ticker VARCHAR(20),
+		alert_rule_id INTEGER REFERENCES alert_rules(id) ON DELETE SET NULL,
+		detail TEXT,
+		read_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+
+	// Create chat_messages table
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS chat_messages (
+		id SERIAL PRIMARY KEY,
+		ticker VARCHAR(20) NOT NULL,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		body TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+
+	// Create conversations table
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS conversations (
+		id SERIAL PRIMARY KEY,
+		is_group BOOLEAN DEFAULT FALSE,
+		created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		last_message_at TIMESTAMP,
+		direct_user_low INTEGER,
+		direct_user_high INTEGER,
+		UNIQUE (direct_user_low, direct_user_high)
+		);
+	`)
+
+	// Create conversation_members table
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS conversation_members (
+		conversation_id INTEGER NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		last_read_message_id INTEGER DEFAULT 0,
+		joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (conversation_id, user_id)
+		);
+	`)
+
+	// Create messages table
+	db.MustExec(`
+		CREATE TABLE IF NOT EXISTS messages (
+		id SERIAL PRIMARY KEY,
+		conversation_id INTEGER NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		body TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+
 	// Create indexes for posts feature
 	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_posts_user_id ON posts(user_id)`)
 	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_posts_created_at ON posts(created_at DESC)`)
 	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_likes_post_id ON likes(post_id)`)
 	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_comments_post_id ON comments(post_id)`)
 	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_followers_following_id ON followers(following_id)`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_posts_created_at_id ON posts(created_at DESC, id DESC)`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_comments_post_id_created_at ON comments(post_id, created_at DESC, id DESC)`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_followers_following_id_created_at ON followers(following_id, created_at DESC, id DESC)`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_followers_follower_id_created_at ON followers(follower_id, created_at DESC, id DESC)`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_posts_ticker_created_at ON posts(ticker, created_at DESC, id DESC)`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_ticker_followers_symbol_created_at ON ticker_followers(symbol, created_at DESC, id DESC)`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_predictions_pending_expires_at ON predictions(expires_at) WHERE status = 'pending'`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_predictions_user_id ON predictions(user_id)`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_post_entities_post_id ON post_entities(post_id)`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_comment_entities_comment_id ON comment_entities(comment_id)`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_post_revisions_post_id ON post_revisions(post_id, created_at)`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_comment_revisions_comment_id ON comment_revisions(comment_id, created_at)`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_watchlists_user_id ON watchlists(user_id)`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_alert_rules_watchlist_id ON alert_rules(watchlist_id)`)
+	db.MustExec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_group_actor ON notifications(user_id, type, COALESCE(post_id, 0), actor_id)`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_chat_messages_ticker_created_at ON chat_messages(ticker, created_at DESC)`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_conversation_members_user_id ON conversation_members(user_id)`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_messages_conversation_id ON messages(conversation_id, id DESC)`)
 
 	// Migration: rename profile_picture to avatar_emoji if old column exists
 	var columnExists bool
@@ -156,6 +457,53 @@ func MigrateDB(db *sqlx.DB) {
 		log.Info("Migrating: adding is_verified column")
 		db.MustExec(`ALTER TABLE users ADD COLUMN is_verified BOOLEAN DEFAULT FALSE`)
 	}
+
+	// Migration: normalize tickers of posts created before the ticker registry, e.g. "$aapl " to "AAPL"
+	db.MustExec(`
+		UPDATE posts SET ticker = UPPER(REPLACE(TRIM(BOTH ' $' FROM ticker), '/', ''))
+		WHERE ticker <> UPPER(REPLACE(TRIM(BOTH ' $' FROM ticker), '/', ''))
+	`)
+
+	// Migration: add the optional sentiment of posts
+	db.MustExec(`
+		ALTER TABLE posts ADD COLUMN IF NOT EXISTS sentiment VARCHAR(10)
+		CHECK (sentiment IN ('bullish', 'bearish', 'neutral'))
+	`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_posts_ticker_sentiment_created_at ON posts(ticker, created_at DESC) WHERE sentiment IS NOT NULL`)
+
+	// Migration: add the price of the ticker when a post was made
+	db.MustExec(`ALTER TABLE posts ADD COLUMN IF NOT EXISTS price_at_post NUMERIC(20, 8)`)
+
+	// Migration: list posts under every ticker they mention, earlier posts under their own ticker
+	db.MustExec(`ALTER TABLE posts ADD COLUMN IF NOT EXISTS tickers TEXT[] NOT NULL DEFAULT '{}'`)
+	db.MustExec(`UPDATE posts SET tickers = ARRAY[ticker] WHERE tickers = '{}'`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_posts_tickers ON posts USING GIN (tickers)`)
+
+	// Migration: let notifications carry alerts, which have no actor
+	db.MustExec(`ALTER TABLE notifications ALTER COLUMN actor_id DROP NOT NULL`)
+	db.MustExec(`ALTER TABLE notifications ADD COLUMN IF NOT EXISTS ticker VARCHAR(20)`)
+	db.MustExec(`ALTER TABLE notifications ADD COLUMN IF NOT EXISTS alert_rule_id INTEGER REFERENCES alert_rules(id) ON DELETE SET NULL`)
+	db.MustExec(`ALTER TABLE notifications ADD COLUMN IF NOT EXISTS detail TEXT`)
+	db.MustExec(`ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_type_check`)
+	db.MustExec(`
+		ALTER TABLE notifications ADD CONSTRAINT notifications_type_check
+		CHECK (type IN ('follow', 'like', 'comment', 'mention', 'alert'))
+	`)
+
+	// Migration: thread replies under comments, deleted comments with replies stay as placeholders
+	db.MustExec(`ALTER TABLE comments ADD COLUMN IF NOT EXISTS parent_comment_id INTEGER REFERENCES comments(id) ON DELETE CASCADE`)
+	db.MustExec(`ALTER TABLE comments ADD COLUMN IF NOT EXISTS depth SMALLINT NOT NULL DEFAULT 0`)
+	db.MustExec(`ALTER TABLE comments ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_comments_post_id_top_level ON comments(post_id, created_at DESC, id DESC) WHERE parent_comment_id IS NULL`)
+	db.MustExec(`CREATE INDEX IF NOT EXISTS idx_comments_parent_comment_id ON comments(parent_comment_id, created_at, id)`)
+
+	// Migration: mark edited comments, posts use updated_at
+	db.MustExec(`ALTER TABLE comments ADD COLUMN IF NOT EXISTS edited_at TIMESTAMP`)
+
+	// Migration: remember the device of sessions
+	db.MustExec(`ALTER TABLE sessions ADD COLUMN IF NOT EXISTS device TEXT NOT NULL DEFAULT ''`)
+	db.MustExec(`ALTER TABLE sessions ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT ''`)
+	db.MustExec(`ALTER TABLE sessions ADD COLUMN IF NOT EXISTS ip TEXT NOT NULL DEFAULT ''`)
 }
 
 func SetCookie(w http.ResponseWriter, name string, value string) {
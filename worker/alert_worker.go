@@ -0,0 +1,282 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/internal/marketdata"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	alertEvaluateTimeout = 50 * time.Second
+	// Posts a sentiment_flip rule weighs
+	alertSentimentWindow = 24 * time.Hour
+)
+
+type AlertWorker struct {
+	alertRuleRepo    repository.AlertRuleRepository
+	tickerRepo       repository.TickerRepository
+	notificationRepo repository.NotificationRepository
+	provider         marketdata.Provider
+	interval         time.Duration
+	stopCh           chan struct{}
+}
+
+func NewAlertWorker(
+	alertRuleRepo repository.AlertRuleRepository,
+	tickerRepo repository.TickerRepository,
+	notificationRepo repository.NotificationRepository,
+	provider marketdata.Provider,
+	interval time.Duration,
+) *AlertWorker {
+	return &AlertWorker{
+		alertRuleRepo:    alertRuleRepo,
+		tickerRepo:       tickerRepo,
+		notificationRepo: notificationRepo,
+		provider:         provider,
+		interval:         interval,
+		stopCh:           make(chan struct{}),
+	}
+}
+
+// Start begins the worker that evaluates watchlist alert rules
+func (w *AlertWorker) Start() {
+	log.Info("Alert worker started")
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.evaluateRules()
+		case <-w.stopCh:
+			log.Info("Alert worker stopped")
+			return
+		}
+	}
+}
+
+// Stop stops the worker
+func (w *AlertWorker) Stop() {
+	close(w.stopCh)
+}
+
+// alertObservation is what a ticker looked like when its rules were evaluated
+type alertObservation struct {
+	// Latest price, nil without a recent one
+	Price *float64
+	// Price at the start of the UTC day, nil without one
+	DayOpen  *float64
+	DayStart time.Time
+	// Bullish or bearish majority of the recent posts, nil when there is none
+	Sentiment *string
+	At        time.Time
+}
+
+// evaluateRules checks every rule against its ticker, quoting each ticker
+// once. Replicas may race on a rule, only the one that stores the new state
+// sends the notification.
+func (w *AlertWorker) evaluateRules() {
+	ctx, cancel := context.WithTimeout(context.Background(), alertEvaluateTimeout)
+	defer cancel()
+
+	rules, err := w.alertRuleRepo.GetAllAlertRules(ctx)
+	if err != nil {
+		log.Errorf("Failed to load alert rules: %v", err)
+		return
+	}
+
+	bySymbol := make(map[string][]*domain.AlertRule)
+	symbols := make([]string, 0)
+	for _, rule := range rules {
+		if _, ok := bySymbol[rule.Symbol]; !ok {
+			symbols = append(symbols, rule.Symbol)
+		}
+		bySymbol[rule.Symbol] = append(bySymbol[rule.Symbol], rule)
+	}
+
+	fired := 0
+	for _, symbol := range symbols {
+		observation, err := w.observe(ctx, symbol, bySymbol[symbol])
+		if err != nil {
+			log.Warnf("Failed to observe %s for alerts: %v", symbol, err)
+			continue
+		}
+
+		for _, rule := range bySymbol[symbol] {
+			next, detail := evaluateRule(rule, observation)
+			if !alertStateChanged(rule, next) {
+				continue
+			}
+
+			stored, err := w.alertRuleRepo.UpdateAlertState(ctx, next)
+			if err != nil {
+				log.Errorf("Failed to store state of alert rule %d: %v", rule.Id, err)
+				return
+			}
+			if !stored || detail == "" {
+				continue
+			}
+
+			err = w.notificationRepo.CreateNotification(ctx, &domain.Notification{
+				UserId:      rule.UserId,
+				Type:        domain.NotificationAlert,
+				Ticker:      &rule.Symbol,
+				AlertRuleId: &rule.Id,
+				Detail:      &detail,
+			})
+			if err != nil {
+				log.Errorf("Failed to notify user %d of alert rule %d: %v", rule.UserId, rule.Id, err)
+				continue
+			}
+			fired++
+		}
+	}
+
+	if fired > 0 {
+		log.Infof("Fired %d alerts", fired)
+	}
+}
+
+// observe gathers what the rules of a ticker need, leaving out the price
+// when none of them look at it and the sentiment likewise
+func (w *AlertWorker) observe(ctx context.Context, symbol string, rules []*domain.AlertRule) (alertObservation, error) {
+	observation := alertObservation{At: time.Now().UTC()}
+
+	needsPrice, needsDayOpen, needsSentiment := false, false, false
+	for _, rule := range rules {
+		switch rule.Type {
+		case domain.AlertPriceCross:
+			needsPrice = true
+		case domain.AlertPercentMove:
+			needsPrice, needsDayOpen = true, true
+		case domain.AlertSentimentFlip:
+			needsSentiment = true
+		}
+	}
+
+	if needsPrice {
+		quote, err := w.provider.Quote(ctx, symbol)
+		if err != nil && !errors.Is(err, domain.ErrNoPrice) {
+			return observation, err
+		}
+		if err == nil {
+			observation.Price = &quote.Price
+			observation.At = quote.At.UTC()
+		}
+	}
+	observation.DayStart = observation.At.Truncate(24 * time.Hour)
+
+	if needsDayOpen && observation.Price != nil {
+		open, err := w.provider.PriceAt(ctx, symbol, observation.DayStart)
+		if err != nil && !errors.Is(err, domain.ErrNoPrice) {
+			return observation, err
+		}
+		if err == nil {
+			observation.DayOpen = &open
+		}
+	}
+
+	if needsSentiment {
+		counts, err := w.tickerRepo.GetSentimentCounts(ctx, symbol, alertSentimentWindow)
+		if err != nil {
+			return observation, err
+		}
+		observation.Sentiment = sentimentMajority(counts)
+	}
+	return observation, nil
+}
+
+func sentimentMajority(counts *domain.SentimentCounts) *string {
+	var majority string
+	switch {
+	case counts.Bullish > counts.Bearish:
+		majority = domain.SentimentBullish
+	case counts.Bearish > counts.Bullish:
+		majority = domain.SentimentBearish
+	default:
+		return nil
+	}
+	return &majority
+}
+
+// evaluateRule returns the state a rule moves to on an observation and, when
+// it fires, what to tell its owner. A rule that hasn't seen its ticker before
+// only records it, so creating a rule never fires it right away.
+func evaluateRule(rule *domain.AlertRule, observation alertObservation) (*domain.AlertRule, string) {
+	next := *rule
+	detail := ""
+
+	switch rule.Type {
+	case domain.AlertPriceCross:
+		if observation.Price == nil || rule.Level == nil {
+			break
+		}
+		price, level := *observation.Price, *rule.Level
+		if rule.LastPrice != nil {
+			last := *rule.LastPrice
+			if last < level && price >= level {
+				detail = fmt.Sprintf("%s crossed above %s at %s", rule.Symbol, formatPrice(level), formatPrice(price))
+			} else if last > level && price <= level {
+				detail = fmt.Sprintf("%s crossed below %s at %s", rule.Symbol, formatPrice(level), formatPrice(price))
+			}
+		}
+		next.LastPrice = observation.Price
+
+	case domain.AlertPercentMove:
+		if observation.Price == nil || observation.DayOpen == nil || *observation.DayOpen <= 0 || rule.Percent == nil {
+			break
+		}
+		price, open := *observation.Price, *observation.DayOpen
+		change := (price - open) / open * 100
+		firedToday := rule.TriggeredAt != nil && !rule.TriggeredAt.Before(observation.DayStart)
+		if math.Abs(change) >= *rule.Percent && !firedToday {
+			direction := "up"
+			if change < 0 {
+				direction = "down"
+			}
+			detail = fmt.Sprintf("%s is %s %.2f%% today at %s", rule.Symbol, direction, math.Abs(change), formatPrice(price))
+		}
+		next.LastPrice = observation.Price
+
+	case domain.AlertSentimentFlip:
+		if observation.Sentiment == nil {
+			break
+		}
+		if rule.LastSentiment != nil && *rule.LastSentiment != *observation.Sentiment {
+			detail = fmt.Sprintf("Sentiment on %s flipped to %s", rule.Symbol, *observation.Sentiment)
+		}
+		next.LastSentiment = observation.Sentiment
+	}
+
+	if detail != "" {
+		at := observation.At
+		next.TriggeredAt = &at
+	}
+	return &next, detail
+}
+
+func alertStateChanged(rule, next *domain.AlertRule) bool {
+	return !equalPointers(rule.LastPrice, next.LastPrice) ||
+		!equalPointers(rule.LastSentiment, next.LastSentiment) ||
+		!equalPointers(rule.TriggeredAt, next.TriggeredAt)
+}
+
+func equalPointers[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func formatPrice(price float64) string {
+	return strconv.FormatFloat(price, 'f', -1, 64)
+}
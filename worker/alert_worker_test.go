@@ -0,0 +1,116 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateRule(t *testing.T) {
+	price := func(p float64) *float64 { return &p }
+	sentiment := func(s string) *string { return &s }
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	dayStart := now.Truncate(24 * time.Hour)
+	yesterday := now.Add(-24 * time.Hour)
+
+	tests := []struct {
+		name        string
+		rule        domain.AlertRule
+		observation alertObservation
+		detail      string
+		changed     bool
+	}{
+		{
+			name:        "first price is only recorded",
+			rule:        domain.AlertRule{Symbol: "AAPL", Type: domain.AlertPriceCross, Level: price(200)},
+			observation: alertObservation{Price: price(210), At: now},
+			changed:     true,
+		},
+		{
+			name:        "crosses above",
+			rule:        domain.AlertRule{Symbol: "AAPL", Type: domain.AlertPriceCross, Level: price(200), LastPrice: price(199.5)},
+			observation: alertObservation{Price: price(201.35), At: now},
+			detail:      "AAPL crossed above 200 at 201.35",
+			changed:     true,
+		},
+		{
+			name:        "crosses below",
+			rule:        domain.AlertRule{Symbol: "AAPL", Type: domain.AlertPriceCross, Level: price(200), LastPrice: price(201)},
+			observation: alertObservation{Price: price(200), At: now},
+			detail:      "AAPL crossed below 200 at 200",
+			changed:     true,
+		},
+		{
+			name:        "stays above",
+			rule:        domain.AlertRule{Symbol: "AAPL", Type: domain.AlertPriceCross, Level: price(200), LastPrice: price(201)},
+			observation: alertObservation{Price: price(201), At: now},
+		},
+		{
+			name:        "no price",
+			rule:        domain.AlertRule{Symbol: "AAPL", Type: domain.AlertPriceCross, Level: price(200), LastPrice: price(199)},
+			observation: alertObservation{At: now},
+		},
+		{
+			name:        "moves up enough",
+			rule:        domain.AlertRule{Symbol: "BTCUSD", Type: domain.AlertPercentMove, Percent: price(5)},
+			observation: alertObservation{Price: price(105.5), DayOpen: price(100), DayStart: dayStart, At: now},
+			detail:      "BTCUSD is up 5.50% today at 105.5",
+			changed:     true,
+		},
+		{
+			name:        "moves down enough, fired yesterday",
+			rule:        domain.AlertRule{Symbol: "BTCUSD", Type: domain.AlertPercentMove, Percent: price(5), LastPrice: price(94), TriggeredAt: &yesterday},
+			observation: alertObservation{Price: price(94), DayOpen: price(100), DayStart: dayStart, At: now},
+			detail:      "BTCUSD is down 6.00% today at 94",
+			changed:     true,
+		},
+		{
+			name:        "fires once a day",
+			rule:        domain.AlertRule{Symbol: "BTCUSD", Type: domain.AlertPercentMove, Percent: price(5), LastPrice: price(106), TriggeredAt: &dayStart},
+			observation: alertObservation{Price: price(106), DayOpen: price(100), DayStart: dayStart, At: now},
+		},
+		{
+			name:        "moves too little",
+			rule:        domain.AlertRule{Symbol: "BTCUSD", Type: domain.AlertPercentMove, Percent: price(5), LastPrice: price(104)},
+			observation: alertObservation{Price: price(104), DayOpen: price(100), DayStart: dayStart, At: now},
+		},
+		{
+			name:        "first sentiment is only recorded",
+			rule:        domain.AlertRule{Symbol: "TSLA", Type: domain.AlertSentimentFlip},
+			observation: alertObservation{Sentiment: sentiment(domain.SentimentBullish), At: now},
+			changed:     true,
+		},
+		{
+			name:        "sentiment flips",
+			rule:        domain.AlertRule{Symbol: "TSLA", Type: domain.AlertSentimentFlip, LastSentiment: sentiment(domain.SentimentBullish)},
+			observation: alertObservation{Sentiment: sentiment(domain.SentimentBearish), At: now},
+			detail:      "Sentiment on TSLA flipped to bearish",
+			changed:     true,
+		},
+		{
+			name:        "tie keeps the last majority",
+			rule:        domain.AlertRule{Symbol: "TSLA", Type: domain.AlertSentimentFlip, LastSentiment: sentiment(domain.SentimentBullish)},
+			observation: alertObservation{At: now},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, detail := evaluateRule(&tt.rule, tt.observation)
+			assert.Equal(t, tt.detail, detail)
+			assert.Equal(t, tt.changed, alertStateChanged(&tt.rule, next))
+			if detail != "" {
+				assert.Equal(t, &now, next.TriggeredAt)
+			}
+		})
+	}
+}
+
+func TestSentimentMajority(t *testing.T) {
+	assert.Equal(t, domain.SentimentBullish, *sentimentMajority(&domain.SentimentCounts{Bullish: 3, Bearish: 1, Neutral: 9}))
+	assert.Equal(t, domain.SentimentBearish, *sentimentMajority(&domain.SentimentCounts{Bullish: 1, Bearish: 2}))
+	assert.Nil(t, sentimentMajority(&domain.SentimentCounts{Bullish: 2, Bearish: 2}))
+}
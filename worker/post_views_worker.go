@@ -2,6 +2,10 @@ package worker
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
 	"time"
 
 	"github.com/Pro100-Almaz/trading-chat/repository"
@@ -9,10 +13,17 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	syncTimeout = 25 * time.Second
+	// Outlives a sync, so the lease only expires if its holder died mid-flush
+	syncLeaseTTL = syncTimeout + 5*time.Second
+)
+
 type PostViewsWorker struct {
 	redisRepo repository.PostViewsRedisRepository
 	dbRepo    repository.PostViewsDBRepository
 	interval  time.Duration
+	owner     string
 	stopCh    chan struct{}
 }
 
@@ -25,10 +36,22 @@ func NewPostViewsWorker(
 		redisRepo: redisRepo,
 		dbRepo:    dbRepo,
 		interval:  interval,
+		owner:     newWorkerOwner(),
 		stopCh:    make(chan struct{}),
 	}
 }
 
+// newWorkerOwner identifies this replica as the holder of the sync lease
+func newWorkerOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	b := make([]byte, 8)
+	rand.Read(b)
+	return fmt.Sprintf("%s-%s", hostname, hex.EncodeToString(b))
+}
+
 // Start begins the worker that syncs Redis views to PostgreSQL
 func (w *PostViewsWorker) Start() {
 	log.Info("Post views worker started")
@@ -51,39 +74,87 @@ func (w *PostViewsWorker) Stop() {
 	close(w.stopCh)
 }
 
-// syncViewsToDatabase syncs view counts from Redis to PostgreSQL
+// syncViewsToDatabase syncs view counts from Redis to PostgreSQL. Every
+// replica runs the worker, the Redis lease makes sure only one flushes at a time.
 func (w *PostViewsWorker) syncViewsToDatabase() {
-	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
 	defer cancel()
 
-	// Get all view counts from Redis
-	viewCounts, err := w.redisRepo.GetAllViewCounts(ctx)
+	acquired, err := w.redisRepo.AcquireSyncLease(ctx, w.owner, syncLeaseTTL)
+	if err != nil {
+		log.Errorf("Failed to acquire views sync lease: %v", err)
+		return
+	}
+	if !acquired {
+		log.Debug("Views sync lease held by another replica")
+		return
+	}
+	defer func() {
+		if err := w.redisRepo.ReleaseSyncLease(context.Background(), w.owner); err != nil {
+			log.Warnf("Failed to release views sync lease: %v", err)
+		}
+	}()
+
+	w.syncTotals(ctx)
+	w.syncDailyViews(ctx)
+}
+
+// syncTotals adds the view counters accumulated since the last sync to the post totals
+func (w *PostViewsWorker) syncTotals(ctx context.Context) {
+	// Atomically move the live counters into a sync batch
+	batchId, viewCounts, err := w.redisRepo.PrepareSyncBatch(ctx)
 	if err != nil {
-		log.Errorf("Failed to get view counts from Redis: %v", err)
+		log.Errorf("Failed to prepare views sync batch: %v", err)
 		return
 	}
 
 	if len(viewCounts) == 0 {
+		if batchId != "" {
+			w.completeBatch(ctx, batchId)
+		}
 		log.Debug("No views to sync")
 		return
 	}
 
 	log.Infof("Syncing %d post views to database", len(viewCounts))
 
-	// Upsert to database
-	err = w.dbRepo.UpsertViewCounts(ctx, viewCounts)
+	// On failure the batch stays in Redis and is retried with the same id next time
+	err = w.dbRepo.UpsertViewCounts(ctx, batchId, viewCounts)
 	if err != nil {
 		log.Errorf("Failed to upsert view counts to database: %v", err)
 		return
 	}
 
-	// Reset Redis counters after successful sync
-	for postId := range viewCounts {
-		err = w.redisRepo.ResetViewCount(ctx, postId)
-		if err != nil {
-			log.Warnf("Failed to reset view count for post %d: %v", postId, err)
+	w.completeBatch(ctx, batchId)
+
+	log.Infof("Successfully synced %d post views to database", len(viewCounts))
+}
+
+// syncDailyViews stores the unique viewer and impression counts of the post days that had views
+func (w *PostViewsWorker) syncDailyViews(ctx context.Context) {
+	days, err := w.redisRepo.PrepareDailySync(ctx)
+	if err != nil {
+		log.Errorf("Failed to prepare daily views sync: %v", err)
+		return
+	}
+
+	if len(days) > 0 {
+		// On failure the snapshot stays in Redis and is retried next time
+		if err := w.dbRepo.UpsertDailyViews(ctx, days); err != nil {
+			log.Errorf("Failed to upsert daily views to database: %v", err)
+			return
 		}
+		log.Infof("Synced daily views of %d posts to database", len(days))
 	}
 
-	log.Infof("Successfully synced %d post views to database", len(viewCounts))
+	if err := w.redisRepo.CompleteDailySync(ctx); err != nil {
+		log.Warnf("Failed to complete daily views sync: %v", err)
+	}
+}
+
+func (w *PostViewsWorker) completeBatch(ctx context.Context, batchId string) {
+	if err := w.redisRepo.CompleteSyncBatch(ctx, batchId); err != nil {
+		// Safe to leave behind, the database ignores a batch id it has already applied
+		log.Warnf("Failed to complete views sync batch %s: %v", batchId, err)
+	}
 }
@@ -0,0 +1,261 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeViewsDB is an in-memory PostViewsDBRepository that, like the real one,
+// applies each batch id at most once
+type fakeViewsDB struct {
+	mu      sync.Mutex
+	totals  map[int]int64
+	applied map[string]bool
+	daily   map[string]domain.PostViewsDay
+	// failAfterApply makes the next upsert commit and then report an error,
+	// as if the connection dropped before the commit was acknowledged
+	failAfterApply bool
+}
+
+func newFakeViewsDB() *fakeViewsDB {
+	return &fakeViewsDB{
+		totals:  make(map[int]int64),
+		applied: make(map[string]bool),
+		daily:   make(map[string]domain.PostViewsDay),
+	}
+}
+
+func (f *fakeViewsDB) UpsertViewCounts(ctx context.Context, batchId string, viewCounts map[int]int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.applied[batchId] {
+		f.applied[batchId] = true
+		for postId, count := range viewCounts {
+			f.totals[postId] += count
+		}
+	}
+
+	if f.failAfterApply {
+		f.failAfterApply = false
+		return errors.New("connection reset")
+	}
+	return nil
+}
+
+func (f *fakeViewsDB) GetViewCount(ctx context.Context, postId int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.totals[postId], nil
+}
+
+func (f *fakeViewsDB) GetMultipleViewCounts(ctx context.Context, postIds []int) (map[int]int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	viewCounts := make(map[int]int64, len(postIds))
+	for _, postId := range postIds {
+		viewCounts[postId] = f.totals[postId]
+	}
+	return viewCounts, nil
+}
+
+func (f *fakeViewsDB) UpsertDailyViews(ctx context.Context, days []*domain.PostViewsDay) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, day := range days {
+		key := fmt.Sprintf("%d:%s", day.PostId, day.Date)
+		stored := f.daily[key]
+		stored.PostId, stored.Date = day.PostId, day.Date
+		stored.UniqueViewers = max(stored.UniqueViewers, day.UniqueViewers)
+		stored.Impressions = max(stored.Impressions, day.Impressions)
+		f.daily[key] = stored
+	}
+	return nil
+}
+
+func (f *fakeViewsDB) GetDailyViews(ctx context.Context, postId int, from time.Time) ([]*domain.PostViewsDay, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	days := make([]*domain.PostViewsDay, 0)
+	for _, day := range f.daily {
+		if day.PostId == postId && day.Date >= from.Format("2006-01-02") {
+			day := day
+			days = append(days, &day)
+		}
+	}
+	return days, nil
+}
+
+func newTestRedisRepo(t *testing.T) repository.PostViewsRedisRepository {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return repository.NewPostViewsRedisRepository(client)
+}
+
+func TestSyncViewsLosesNoIncrementsUnderConcurrency(t *testing.T) {
+	redisRepo := newTestRedisRepo(t)
+	db := newFakeViewsDB()
+	ctx := context.Background()
+
+	const (
+		posts      = 20
+		clients    = 8
+		increments = 300
+	)
+
+	// Three replicas syncing continuously while clients keep viewing posts
+	replicas := []*PostViewsWorker{
+		NewPostViewsWorker(redisRepo, db, time.Millisecond),
+		NewPostViewsWorker(redisRepo, db, time.Millisecond),
+		NewPostViewsWorker(redisRepo, db, time.Millisecond),
+	}
+
+	stop := make(chan struct{})
+	var syncers sync.WaitGroup
+	for _, replica := range replicas {
+		syncers.Add(1)
+		go func(w *PostViewsWorker) {
+			defer syncers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					w.syncViewsToDatabase()
+				}
+			}
+		}(replica)
+	}
+
+	// Every view comes from a different user, so each one counted by Redis
+	// must end up in the database
+	var countedMu sync.Mutex
+	expected := make(map[int]int64)
+
+	var viewers sync.WaitGroup
+	for c := 0; c < clients; c++ {
+		viewers.Add(1)
+		go func(c int) {
+			defer viewers.Done()
+			for i := 0; i < increments; i++ {
+				postId := (c+i)%posts + 1
+				counted, err := redisRepo.TrackViews(ctx, c*increments+i, []int{postId})
+				assert.NoError(t, err)
+				countedMu.Lock()
+				expected[postId] += int64(counted)
+				countedMu.Unlock()
+			}
+		}(c)
+	}
+
+	viewers.Wait()
+	close(stop)
+	syncers.Wait()
+
+	// Flush whatever the last concurrent round left behind
+	replicas[0].syncViewsToDatabase()
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	assert.Equal(t, expected, db.totals)
+
+	pending, err := redisRepo.GetViewCounts(ctx, []int{1, 2, 3})
+	require.NoError(t, err)
+	assert.Empty(t, pending, "all views should have been flushed")
+}
+
+func TestSyncViewsRetriesFailedBatchWithoutDoubleCounting(t *testing.T) {
+	redisRepo := newTestRedisRepo(t)
+	db := newFakeViewsDB()
+	ctx := context.Background()
+	w := NewPostViewsWorker(redisRepo, db, time.Second)
+
+	for userId := 1; userId <= 2; userId++ {
+		counted, err := redisRepo.TrackViews(ctx, userId, []int{1})
+		require.NoError(t, err)
+		require.Equal(t, 1, counted)
+	}
+	_, err := redisRepo.TrackViews(ctx, 1, []int{2})
+	require.NoError(t, err)
+
+	db.failAfterApply = true
+	w.syncViewsToDatabase()
+
+	// The unacknowledged batch is still pending and keeps showing in counts
+	pending, err := redisRepo.GetViewCount(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), pending)
+
+	_, err = redisRepo.TrackViews(ctx, 2, []int{2})
+	require.NoError(t, err)
+
+	// The retry re-sends the same batch, which the database ignores, then picks up the new view
+	w.syncViewsToDatabase()
+	w.syncViewsToDatabase()
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	assert.Equal(t, map[int]int64{1: 2, 2: 2}, db.totals)
+}
+
+func TestTrackViewsCountsEachUserOncePerDay(t *testing.T) {
+	redisRepo := newTestRedisRepo(t)
+	db := newFakeViewsDB()
+	ctx := context.Background()
+	w := NewPostViewsWorker(redisRepo, db, time.Second)
+
+	// User 1 refreshes the feed three times, user 2 sees the post twice
+	for i := 0; i < 3; i++ {
+		_, err := redisRepo.TrackViews(ctx, 1, []int{7})
+		require.NoError(t, err)
+	}
+	for i := 0; i < 2; i++ {
+		_, err := redisRepo.TrackViews(ctx, 2, []int{7})
+		require.NoError(t, err)
+	}
+
+	w.syncViewsToDatabase()
+
+	today := time.Now().UTC().Format("2006-01-02")
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	assert.Equal(t, int64(2), db.totals[7])
+	assert.Equal(t, domain.PostViewsDay{PostId: 7, Date: today, UniqueViewers: 2, Impressions: 5}, db.daily["7:"+today])
+}
+
+func TestSyncLeaseIsExclusive(t *testing.T) {
+	redisRepo := newTestRedisRepo(t)
+	ctx := context.Background()
+
+	acquired, err := redisRepo.AcquireSyncLease(ctx, "replica-a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	acquired, err = redisRepo.AcquireSyncLease(ctx, "replica-b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired, "a second replica must not get the lease")
+
+	// Only the holder can release it
+	require.NoError(t, redisRepo.ReleaseSyncLease(ctx, "replica-b"))
+	acquired, err = redisRepo.AcquireSyncLease(ctx, "replica-b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	require.NoError(t, redisRepo.ReleaseSyncLease(ctx, "replica-a"))
+	acquired, err = redisRepo.AcquireSyncLease(ctx, "replica-b", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
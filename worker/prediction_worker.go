@@ -0,0 +1,131 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	resolveTimeout = 50 * time.Second
+	// Predictions resolved per query, a backlog is worked through batch after batch
+	resolveBatchSize = 100
+	// How long an expired prediction waits for a price before it is voided
+	predictionGracePeriod = 7 * 24 * time.Hour
+)
+
+type PredictionWorker struct {
+	predictionRepo repository.PredictionRepository
+	provider       domain.MarketDataProvider
+	interval       time.Duration
+	stopCh         chan struct{}
+}
+
+func NewPredictionWorker(
+	predictionRepo repository.PredictionRepository,
+	provider domain.MarketDataProvider,
+	interval time.Duration,
+) *PredictionWorker {
+	return &PredictionWorker{
+		predictionRepo: predictionRepo,
+		provider:       provider,
+		interval:       interval,
+		stopCh:         make(chan struct{}),
+	}
+}
+
+// Start begins the worker that resolves expired predictions
+func (w *PredictionWorker) Start() {
+	log.Info("Prediction worker started")
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.resolvePredictions()
+		case <-w.stopCh:
+			log.Info("Prediction worker stopped")
+			return
+		}
+	}
+}
+
+// Stop stops the worker
+func (w *PredictionWorker) Stop() {
+	close(w.stopCh)
+}
+
+// resolvePredictions settles the expired predictions against the price at
+// their expiry. Ones without a price stay pending and are retried until the
+// grace period runs out. Replicas may race on a prediction, only the first
+// resolution is stored.
+func (w *PredictionWorker) resolvePredictions() {
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+
+	resolved := 0
+	// Ids stay pending when their price is missing, skip them on later batches
+	skipped := make(map[int]bool)
+	for {
+		predictions, err := w.predictionRepo.GetDuePredictions(ctx, resolveBatchSize+len(skipped))
+		if err != nil {
+			log.Errorf("Failed to load due predictions: %v", err)
+			return
+		}
+
+		progressed := false
+		for _, prediction := range predictions {
+			if skipped[prediction.Id] {
+				continue
+			}
+			progressed = true
+
+			price, err := w.provider.PriceAt(ctx, prediction.Ticker, prediction.ExpiresAt)
+			if errors.Is(err, domain.ErrNoPrice) {
+				skipped[prediction.Id] = true
+				continue
+			}
+			if err != nil {
+				log.Errorf("Failed to get price of %s: %v", prediction.Ticker, err)
+				return
+			}
+
+			if err := w.predictionRepo.ResolvePrediction(ctx, prediction.Id, predictionOutcome(prediction, price), price); err != nil {
+				log.Errorf("Failed to resolve prediction %d: %v", prediction.Id, err)
+				return
+			}
+			resolved++
+		}
+
+		if !progressed || len(predictions) < resolveBatchSize+len(skipped) {
+			break
+		}
+	}
+
+	voided, err := w.predictionRepo.VoidOverduePredictions(ctx, predictionGracePeriod)
+	if err != nil {
+		log.Errorf("Failed to void overdue predictions: %v", err)
+		return
+	}
+
+	if resolved > 0 || voided > 0 {
+		log.Infof("Resolved %d predictions, voided %d without a price", resolved, voided)
+	}
+}
+
+// predictionOutcome tells whether the price at expiry reached the target
+func predictionOutcome(prediction *domain.Prediction, price float64) string {
+	if prediction.Direction == domain.PredictionUp && price >= prediction.TargetPrice {
+		return domain.PredictionHit
+	}
+	if prediction.Direction == domain.PredictionDown && price <= prediction.TargetPrice {
+		return domain.PredictionHit
+	}
+	return domain.PredictionMiss
+}
@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/internal/marketdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePredictionRepo holds predictions in memory, those not pending are never due
+type fakePredictionRepo struct {
+	predictions []*domain.Prediction
+	now         time.Time
+}
+
+func (f *fakePredictionRepo) GetPredictionsByPostIds(ctx context.Context, postIds []int) (map[int]*domain.Prediction, error) {
+	return nil, nil
+}
+
+func (f *fakePredictionRepo) GetDuePredictions(ctx context.Context, limit int) ([]*domain.Prediction, error) {
+	due := make([]*domain.Prediction, 0)
+	for _, prediction := range f.predictions {
+		if prediction.Status == domain.PredictionPending && !prediction.ExpiresAt.After(f.now) && len(due) < limit {
+			due = append(due, prediction)
+		}
+	}
+	return due, nil
+}
+
+func (f *fakePredictionRepo) ResolvePrediction(ctx context.Context, id int, status string, price float64) error {
+	for _, prediction := range f.predictions {
+		if prediction.Id == id && prediction.Status == domain.PredictionPending {
+			prediction.Status = status
+			prediction.ResolvedPrice = &price
+		}
+	}
+	return nil
+}
+
+func (f *fakePredictionRepo) VoidOverduePredictions(ctx context.Context, grace time.Duration) (int, error) {
+	voided := 0
+	for _, prediction := range f.predictions {
+		if prediction.Status == domain.PredictionPending && prediction.ExpiresAt.Before(f.now.Add(-grace)) {
+			prediction.Status = domain.PredictionVoid
+			voided++
+		}
+	}
+	return voided, nil
+}
+
+func (f *fakePredictionRepo) GetPredictionRecord(ctx context.Context, userId int) (*domain.PredictionRecord, error) {
+	return nil, nil
+}
+
+func TestResolvePredictions(t *testing.T) {
+	provider, err := marketdata.NewCSVProvider(strings.NewReader(
+		"symbol,time,price\n" +
+			"AAPL,2024-01-02T21:00:00Z,190\n" +
+			"TSLA,2024-01-02T21:00:00Z,240\n"))
+	require.NoError(t, err)
+
+	expiry := time.Date(2024, 1, 2, 21, 0, 0, 0, time.UTC)
+	repo := &fakePredictionRepo{now: expiry.Add(time.Hour), predictions: []*domain.Prediction{
+		{Id: 1, Ticker: "AAPL", Direction: domain.PredictionUp, TargetPrice: 190, ExpiresAt: expiry, Status: domain.PredictionPending},
+		{Id: 2, Ticker: "TSLA", Direction: domain.PredictionDown, TargetPrice: 230, ExpiresAt: expiry, Status: domain.PredictionPending},
+		// No price known yet
+		{Id: 3, Ticker: "NVDA", Direction: domain.PredictionUp, TargetPrice: 500, ExpiresAt: expiry, Status: domain.PredictionPending},
+		// Not expired
+		{Id: 4, Ticker: "AAPL", Direction: domain.PredictionUp, TargetPrice: 100, ExpiresAt: expiry.Add(24 * time.Hour), Status: domain.PredictionPending},
+		// Waited for a price for too long
+		{Id: 5, Ticker: "NVDA", Direction: domain.PredictionUp, TargetPrice: 500, ExpiresAt: expiry.Add(-8 * 24 * time.Hour), Status: domain.PredictionPending},
+	}}
+
+	NewPredictionWorker(repo, provider, time.Minute).resolvePredictions()
+
+	statuses := make([]string, 0, len(repo.predictions))
+	for _, prediction := range repo.predictions {
+		statuses = append(statuses, prediction.Status)
+	}
+	assert.Equal(t, []string{
+		domain.PredictionHit,
+		domain.PredictionMiss,
+		domain.PredictionPending,
+		domain.PredictionPending,
+		domain.PredictionVoid,
+	}, statuses)
+	assert.Equal(t, 190.0, *repo.predictions[0].ResolvedPrice)
+}
@@ -0,0 +1,170 @@
+package worker
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Activity older than the window is ignored, within it weight halves every half-life
+	trendingWindow   = 24 * time.Hour
+	trendingHalfLife = 3 * time.Hour
+
+	trendingTimeout = 50 * time.Second
+	// Outlives a run, so the lease only expires if its holder died mid-run
+	trendingLeaseTTL = trendingTimeout + 5*time.Second
+
+	// Entries kept per ranking
+	trendingPostsKept   = 200
+	trendingTickersKept = 100
+
+	likeWeight    = 1.0
+	commentWeight = 2.0
+	// Per unique viewer per hour, views are cheap compared to likes and comments
+	viewVelocityWeight = 0.2
+	// A new post on a ticker, on top of the engagement the post gets
+	mentionWeight = 3.0
+)
+
+type TrendingWorker struct {
+	dbRepo    repository.TrendingDBRepository
+	redisRepo repository.TrendingRedisRepository
+	interval  time.Duration
+	owner     string
+	stopCh    chan struct{}
+}
+
+func NewTrendingWorker(
+	dbRepo repository.TrendingDBRepository,
+	redisRepo repository.TrendingRedisRepository,
+	interval time.Duration,
+) *TrendingWorker {
+	return &TrendingWorker{
+		dbRepo:    dbRepo,
+		redisRepo: redisRepo,
+		interval:  interval,
+		owner:     newWorkerOwner(),
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// Start begins the worker that recomputes the trending rankings
+func (w *TrendingWorker) Start() {
+	log.Info("Trending worker started")
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.computeTrending()
+	for {
+		select {
+		case <-ticker.C:
+			w.computeTrending()
+		case <-w.stopCh:
+			log.Info("Trending worker stopped")
+			return
+		}
+	}
+}
+
+// Stop stops the worker
+func (w *TrendingWorker) Stop() {
+	close(w.stopCh)
+}
+
+// computeTrending scores the posts and tickers active within the window and
+// replaces the rankings in Redis. Every replica runs the worker, the lease
+// makes sure only one computes at a time.
+func (w *TrendingWorker) computeTrending() {
+	ctx, cancel := context.WithTimeout(context.Background(), trendingTimeout)
+	defer cancel()
+
+	acquired, err := w.redisRepo.AcquireLease(ctx, w.owner, trendingLeaseTTL)
+	if err != nil {
+		log.Errorf("Failed to acquire trending lease: %v", err)
+		return
+	}
+	if !acquired {
+		log.Debug("Trending lease held by another replica")
+		return
+	}
+	defer func() {
+		if err := w.redisRepo.ReleaseLease(context.Background(), w.owner); err != nil {
+			log.Warnf("Failed to release trending lease: %v", err)
+		}
+	}()
+
+	signals, err := w.dbRepo.GetPostSignals(ctx, trendingWindow, trendingHalfLife)
+	if err != nil {
+		log.Errorf("Failed to load trending signals: %v", err)
+		return
+	}
+
+	posts, tickers := scoreTrending(signals)
+
+	if err := w.redisRepo.ReplacePosts(ctx, topScores(posts, trendingPostsKept)); err != nil {
+		log.Errorf("Failed to store trending posts: %v", err)
+		return
+	}
+	if err := w.redisRepo.ReplaceTickers(ctx, topScores(tickers, trendingTickersKept)); err != nil {
+		log.Errorf("Failed to store trending tickers: %v", err)
+		return
+	}
+
+	log.Debugf("Computed trending from %d active posts", len(signals))
+}
+
+// scoreTrending turns the activity of posts into post and ticker scores. A
+// post scores on its decayed likes and comments and its view velocity, a
+// ticker on the scores of its posts plus a decayed weight per new post.
+func scoreTrending(signals []*domain.PostSignals) (posts, tickers []*domain.TrendingScore) {
+	tickerScores := make(map[string]float64)
+	posts = make([]*domain.TrendingScore, 0, len(signals))
+
+	for _, signal := range signals {
+		score := likeWeight*signal.Likes +
+			commentWeight*signal.Comments +
+			viewVelocityWeight*viewVelocity(signal)
+
+		if score > 0 {
+			posts = append(posts, &domain.TrendingScore{Member: strconv.Itoa(signal.PostId), Score: score})
+		}
+		tickerScores[signal.Ticker] += score + mentionWeight*signal.Mention
+	}
+
+	tickers = make([]*domain.TrendingScore, 0, len(tickerScores))
+	for symbol, score := range tickerScores {
+		if score > 0 {
+			tickers = append(tickers, &domain.TrendingScore{Member: symbol, Score: score})
+		}
+	}
+	return posts, tickers
+}
+
+// viewVelocity is the unique viewers per hour over the part of the window the
+// post has been around, at least an hour so brand new posts don't spike
+func viewVelocity(signal *domain.PostSignals) float64 {
+	hours := math.Min(signal.AgeSeconds, trendingWindow.Seconds()) / 3600
+	return float64(signal.Viewers) / math.Max(hours, 1)
+}
+
+// topScores keeps the n highest scores, ties broken by member for a stable order
+func topScores(scores []*domain.TrendingScore, n int) []*domain.TrendingScore {
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score == scores[j].Score {
+			return scores[i].Member < scores[j].Member
+		}
+		return scores[i].Score > scores[j].Score
+	})
+	if len(scores) > n {
+		scores = scores[:n]
+	}
+	return scores
+}
@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Pro100-Almaz/trading-chat/domain"
+	"github.com/Pro100-Almaz/trading-chat/repository"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTrendingDB struct {
+	signals []*domain.PostSignals
+}
+
+func (f *fakeTrendingDB) GetPostSignals(ctx context.Context, window, halfLife time.Duration) ([]*domain.PostSignals, error) {
+	return f.signals, nil
+}
+
+func TestScoreTrendingRanksEngagementAndMentions(t *testing.T) {
+	hour := time.Hour.Seconds()
+	posts, tickers := scoreTrending([]*domain.PostSignals{
+		// A fresh discussion
+		{PostId: 1, Ticker: "NVDA", AgeSeconds: hour, Mention: 0.8, Likes: 4, Comments: 3},
+		// Liked a lot, but long ago
+		{PostId: 2, Ticker: "AAPL", AgeSeconds: 20 * hour, Likes: 1.5},
+		// New and untouched, only counts as a mention of its ticker
+		{PostId: 3, Ticker: "TSLA", AgeSeconds: 0.1 * hour, Mention: 1},
+		// Viewed fast
+		{PostId: 4, Ticker: "AAPL", AgeSeconds: 2 * hour, Viewers: 120},
+	})
+
+	ranked := topScores(posts, 10)
+	require.Len(t, ranked, 3)
+	assert.Equal(t, []string{"4", "1", "2"}, []string{ranked[0].Member, ranked[1].Member, ranked[2].Member})
+	assert.InDelta(t, viewVelocityWeight*60, ranked[0].Score, 1e-9)
+
+	rankedTickers := topScores(tickers, 2)
+	require.Len(t, rankedTickers, 2)
+	assert.Equal(t, "AAPL", rankedTickers[0].Member)
+	assert.Equal(t, "NVDA", rankedTickers[1].Member)
+}
+
+func TestViewVelocityIsAtLeastPerHour(t *testing.T) {
+	assert.Equal(t, 30.0, viewVelocity(&domain.PostSignals{AgeSeconds: 60, Viewers: 30}))
+	assert.Equal(t, 1.0, viewVelocity(&domain.PostSignals{AgeSeconds: 48 * time.Hour.Seconds(), Viewers: 24}))
+}
+
+func TestComputeTrendingReplacesRankings(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	redisRepo := repository.NewTrendingRedisRepository(client)
+	ctx := context.Background()
+
+	db := &fakeTrendingDB{signals: []*domain.PostSignals{
+		{PostId: 1, Ticker: "NVDA", Mention: 1, Likes: 2},
+	}}
+	w := NewTrendingWorker(db, redisRepo, time.Minute)
+	w.computeTrending()
+
+	db.signals = []*domain.PostSignals{
+		{PostId: 2, Ticker: "AAPL", Comments: 1},
+	}
+	w.computeTrending()
+
+	posts, err := redisRepo.GetPosts(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []*domain.TrendingScore{{Member: "2", Score: commentWeight}}, posts)
+
+	tickers, err := redisRepo.GetTickers(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []*domain.TrendingScore{{Member: "AAPL", Score: commentWeight}}, tickers)
+
+	// The lease was given back after each run
+	assert.False(t, server.Exists("trending:lease"))
+}